@@ -22,7 +22,15 @@ type ShortenerClient interface {
 	BatchShorten(ctx context.Context, in *BatchShortenRequest, opts ...grpc.CallOption) (*BatchShortenResponse, error)
 	Expand(ctx context.Context, in *ExpandRequest, opts ...grpc.CallOption) (*ExpandResponse, error)
 	BatchRemove(ctx context.Context, in *BatchRemoveRequest, opts ...grpc.CallOption) (*BatchRemoveResponse, error)
+	Restore(ctx context.Context, in *RestoreRequest, opts ...grpc.CallOption) (*RestoreResponse, error)
 	UserData(ctx context.Context, in *UserDataRequest, opts ...grpc.CallOption) (*UserDataResponse, error)
+	LinkStats(ctx context.Context, in *LinkStatsRequest, opts ...grpc.CallOption) (*LinkStatsResponse, error)
+	GetOperation(ctx context.Context, in *GetOperationRequest, opts ...grpc.CallOption) (*GetOperationResponse, error)
+	UpdateURL(ctx context.Context, in *UpdateURLRequest, opts ...grpc.CallOption) (*URLVersion, error)
+	ListURLVersions(ctx context.Context, in *ListURLVersionsRequest, opts ...grpc.CallOption) (*ListURLVersionsResponse, error)
+	RollbackURL(ctx context.Context, in *RollbackURLRequest, opts ...grpc.CallOption) (*URLVersion, error)
+	ExportURLs(ctx context.Context, in *ExportURLsRequest, opts ...grpc.CallOption) (Shortener_ExportURLsClient, error)
+	ImportURLs(ctx context.Context, opts ...grpc.CallOption) (Shortener_ImportURLsClient, error)
 }
 
 type shortenerClient struct {
@@ -69,6 +77,15 @@ func (c *shortenerClient) BatchRemove(ctx context.Context, in *BatchRemoveReques
 	return out, nil
 }
 
+func (c *shortenerClient) Restore(ctx context.Context, in *RestoreRequest, opts ...grpc.CallOption) (*RestoreResponse, error) {
+	out := new(RestoreResponse)
+	err := c.cc.Invoke(ctx, "/api.Shortener/Restore", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *shortenerClient) UserData(ctx context.Context, in *UserDataRequest, opts ...grpc.CallOption) (*UserDataResponse, error) {
 	out := new(UserDataResponse)
 	err := c.cc.Invoke(ctx, "/api.Shortener/UserData", in, out, opts...)
@@ -78,6 +95,117 @@ func (c *shortenerClient) UserData(ctx context.Context, in *UserDataRequest, opt
 	return out, nil
 }
 
+func (c *shortenerClient) LinkStats(ctx context.Context, in *LinkStatsRequest, opts ...grpc.CallOption) (*LinkStatsResponse, error) {
+	out := new(LinkStatsResponse)
+	err := c.cc.Invoke(ctx, "/api.Shortener/LinkStats", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shortenerClient) GetOperation(ctx context.Context, in *GetOperationRequest, opts ...grpc.CallOption) (*GetOperationResponse, error) {
+	out := new(GetOperationResponse)
+	err := c.cc.Invoke(ctx, "/api.Shortener/GetOperation", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shortenerClient) UpdateURL(ctx context.Context, in *UpdateURLRequest, opts ...grpc.CallOption) (*URLVersion, error) {
+	out := new(URLVersion)
+	err := c.cc.Invoke(ctx, "/api.Shortener/UpdateURL", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shortenerClient) ListURLVersions(ctx context.Context, in *ListURLVersionsRequest, opts ...grpc.CallOption) (*ListURLVersionsResponse, error) {
+	out := new(ListURLVersionsResponse)
+	err := c.cc.Invoke(ctx, "/api.Shortener/ListURLVersions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shortenerClient) RollbackURL(ctx context.Context, in *RollbackURLRequest, opts ...grpc.CallOption) (*URLVersion, error) {
+	out := new(URLVersion)
+	err := c.cc.Invoke(ctx, "/api.Shortener/RollbackURL", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shortenerClient) ExportURLs(ctx context.Context, in *ExportURLsRequest, opts ...grpc.CallOption) (Shortener_ExportURLsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Shortener_ServiceDesc.Streams[0], "/api.Shortener/ExportURLs", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &shortenerExportURLsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Shortener_ExportURLsClient interface {
+	Recv() (*ExportedURL, error)
+	grpc.ClientStream
+}
+
+type shortenerExportURLsClient struct {
+	grpc.ClientStream
+}
+
+func (x *shortenerExportURLsClient) Recv() (*ExportedURL, error) {
+	m := new(ExportedURL)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *shortenerClient) ImportURLs(ctx context.Context, opts ...grpc.CallOption) (Shortener_ImportURLsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Shortener_ServiceDesc.Streams[1], "/api.Shortener/ImportURLs", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &shortenerImportURLsClient{stream}
+	return x, nil
+}
+
+type Shortener_ImportURLsClient interface {
+	Send(*ImportURLRequest) error
+	CloseAndRecv() (*ImportURLsResponse, error)
+	grpc.ClientStream
+}
+
+type shortenerImportURLsClient struct {
+	grpc.ClientStream
+}
+
+func (x *shortenerImportURLsClient) Send(m *ImportURLRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *shortenerImportURLsClient) CloseAndRecv() (*ImportURLsResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(ImportURLsResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // ShortenerServer is the server API for Shortener service.
 // All implementations must embed UnimplementedShortenerServer
 // for forward compatibility
@@ -86,7 +214,15 @@ type ShortenerServer interface {
 	BatchShorten(context.Context, *BatchShortenRequest) (*BatchShortenResponse, error)
 	Expand(context.Context, *ExpandRequest) (*ExpandResponse, error)
 	BatchRemove(context.Context, *BatchRemoveRequest) (*BatchRemoveResponse, error)
+	Restore(context.Context, *RestoreRequest) (*RestoreResponse, error)
 	UserData(context.Context, *UserDataRequest) (*UserDataResponse, error)
+	LinkStats(context.Context, *LinkStatsRequest) (*LinkStatsResponse, error)
+	GetOperation(context.Context, *GetOperationRequest) (*GetOperationResponse, error)
+	UpdateURL(context.Context, *UpdateURLRequest) (*URLVersion, error)
+	ListURLVersions(context.Context, *ListURLVersionsRequest) (*ListURLVersionsResponse, error)
+	RollbackURL(context.Context, *RollbackURLRequest) (*URLVersion, error)
+	ExportURLs(*ExportURLsRequest, Shortener_ExportURLsServer) error
+	ImportURLs(Shortener_ImportURLsServer) error
 	mustEmbedUnimplementedShortenerServer()
 }
 
@@ -106,9 +242,33 @@ func (UnimplementedShortenerServer) Expand(context.Context, *ExpandRequest) (*Ex
 func (UnimplementedShortenerServer) BatchRemove(context.Context, *BatchRemoveRequest) (*BatchRemoveResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method BatchRemove not implemented")
 }
+func (UnimplementedShortenerServer) Restore(context.Context, *RestoreRequest) (*RestoreResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Restore not implemented")
+}
 func (UnimplementedShortenerServer) UserData(context.Context, *UserDataRequest) (*UserDataResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UserData not implemented")
 }
+func (UnimplementedShortenerServer) LinkStats(context.Context, *LinkStatsRequest) (*LinkStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LinkStats not implemented")
+}
+func (UnimplementedShortenerServer) GetOperation(context.Context, *GetOperationRequest) (*GetOperationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetOperation not implemented")
+}
+func (UnimplementedShortenerServer) UpdateURL(context.Context, *UpdateURLRequest) (*URLVersion, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateURL not implemented")
+}
+func (UnimplementedShortenerServer) ListURLVersions(context.Context, *ListURLVersionsRequest) (*ListURLVersionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListURLVersions not implemented")
+}
+func (UnimplementedShortenerServer) RollbackURL(context.Context, *RollbackURLRequest) (*URLVersion, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RollbackURL not implemented")
+}
+func (UnimplementedShortenerServer) ExportURLs(*ExportURLsRequest, Shortener_ExportURLsServer) error {
+	return status.Errorf(codes.Unimplemented, "method ExportURLs not implemented")
+}
+func (UnimplementedShortenerServer) ImportURLs(Shortener_ImportURLsServer) error {
+	return status.Errorf(codes.Unimplemented, "method ImportURLs not implemented")
+}
 func (UnimplementedShortenerServer) mustEmbedUnimplementedShortenerServer() {}
 
 // UnsafeShortenerServer may be embedded to opt out of forward compatibility for this service.
@@ -194,6 +354,24 @@ func _Shortener_BatchRemove_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Shortener_Restore_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShortenerServer).Restore(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Shortener/Restore",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShortenerServer).Restore(ctx, req.(*RestoreRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Shortener_UserData_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(UserDataRequest)
 	if err := dec(in); err != nil {
@@ -212,6 +390,143 @@ func _Shortener_UserData_Handler(srv interface{}, ctx context.Context, dec func(
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Shortener_LinkStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LinkStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShortenerServer).LinkStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Shortener/LinkStats",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShortenerServer).LinkStats(ctx, req.(*LinkStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Shortener_GetOperation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetOperationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShortenerServer).GetOperation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Shortener/GetOperation",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShortenerServer).GetOperation(ctx, req.(*GetOperationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Shortener_UpdateURL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateURLRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShortenerServer).UpdateURL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Shortener/UpdateURL",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShortenerServer).UpdateURL(ctx, req.(*UpdateURLRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Shortener_ListURLVersions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListURLVersionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShortenerServer).ListURLVersions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Shortener/ListURLVersions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShortenerServer).ListURLVersions(ctx, req.(*ListURLVersionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Shortener_RollbackURL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RollbackURLRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShortenerServer).RollbackURL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Shortener/RollbackURL",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShortenerServer).RollbackURL(ctx, req.(*RollbackURLRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Shortener_ExportURLs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportURLsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ShortenerServer).ExportURLs(m, &shortenerExportURLsServer{stream})
+}
+
+type Shortener_ExportURLsServer interface {
+	Send(*ExportedURL) error
+	grpc.ServerStream
+}
+
+type shortenerExportURLsServer struct {
+	grpc.ServerStream
+}
+
+func (x *shortenerExportURLsServer) Send(m *ExportedURL) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Shortener_ImportURLs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ShortenerServer).ImportURLs(&shortenerImportURLsServer{stream})
+}
+
+type Shortener_ImportURLsServer interface {
+	SendAndClose(*ImportURLsResponse) error
+	Recv() (*ImportURLRequest, error)
+	grpc.ServerStream
+}
+
+type shortenerImportURLsServer struct {
+	grpc.ServerStream
+}
+
+func (x *shortenerImportURLsServer) SendAndClose(m *ImportURLsResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *shortenerImportURLsServer) Recv() (*ImportURLRequest, error) {
+	m := new(ImportURLRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // Shortener_ServiceDesc is the grpc.ServiceDesc for Shortener service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -235,11 +550,46 @@ var Shortener_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "BatchRemove",
 			Handler:    _Shortener_BatchRemove_Handler,
 		},
+		{
+			MethodName: "Restore",
+			Handler:    _Shortener_Restore_Handler,
+		},
 		{
 			MethodName: "UserData",
 			Handler:    _Shortener_UserData_Handler,
 		},
+		{
+			MethodName: "LinkStats",
+			Handler:    _Shortener_LinkStats_Handler,
+		},
+		{
+			MethodName: "GetOperation",
+			Handler:    _Shortener_GetOperation_Handler,
+		},
+		{
+			MethodName: "UpdateURL",
+			Handler:    _Shortener_UpdateURL_Handler,
+		},
+		{
+			MethodName: "ListURLVersions",
+			Handler:    _Shortener_ListURLVersions_Handler,
+		},
+		{
+			MethodName: "RollbackURL",
+			Handler:    _Shortener_RollbackURL_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ExportURLs",
+			Handler:       _Shortener_ExportURLs_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ImportURLs",
+			Handler:       _Shortener_ImportURLs_Handler,
+			ClientStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "shortener.proto",
 }
@@ -2,12 +2,13 @@ package app
 
 import (
 	"context"
-	"database/sql"
+	"expvar"
 	"fmt"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"golang.org/x/crypto/acme/autocert"
 	"google.golang.org/grpc"
+	"net"
 	"net/http"
 	"net/http/pprof"
 	"shortener/internal/app/config"
@@ -15,17 +16,25 @@ import (
 	"shortener/internal/app/handler/basic"
 	"shortener/internal/app/logger"
 	mw "shortener/internal/app/middleware"
+	"shortener/internal/app/service/clicks"
 	"shortener/internal/app/service/grpcservice"
-	"shortener/internal/app/service/store/sqlstore"
-	"shortener/internal/migrate"
+	"shortener/internal/app/service/policy"
+	"shortener/internal/app/service/store"
+	"shortener/internal/app/service/threat"
 	"time"
 )
 
 type App struct {
 	config *config.AppConfig
-	store  *sqlstore.Store
+	store  store.Backend
+	clicks *clicks.BufferedTracker
 	grpc   *grpcservice.Server
 	log    logger.Logger
+	// proxies are trusted to set X-Real-IP of the client
+	proxies []*net.IPNet
+	// threats and scanner are nil if threat checks are disabled
+	threats *threat.Blocklist
+	scanner *threat.Scanner
 }
 
 func (a *App) LoggerComponent() string {
@@ -33,19 +42,25 @@ func (a *App) LoggerComponent() string {
 }
 
 func New(config *config.AppConfig, l logger.Logger) (*App, error) {
-	db, err := sql.Open("postgres", config.DSN)
+	proxies, err := config.TrustedProxyNets()
 	if err != nil {
-		return nil, fmt.Errorf("db open: %w", err)
+		return nil, fmt.Errorf("config: %w", err)
 	}
 
-	if err = migrate.Up(db); err != nil {
-		return nil, fmt.Errorf("migrate up: %w", err)
+	var (
+		threats   *threat.Blocklist
+		storeOpts []policy.StoreOption
+	)
+	if config.ThreatBlocklistFile != "" {
+		b, err := threat.NewBlocklist(config.ThreatBlocklistFile, threat.WithReloadInterval(config.ThreatReloadInterval))
+		if err != nil {
+			return nil, fmt.Errorf("threat blocklist init: %w", err)
+		}
+		threats = b
+		storeOpts = append(storeOpts, policy.WithThreatChecker(b))
 	}
 
-	st, err := sqlstore.New(
-		db,
-		sqlstore.WithBaseURL(config.BaseURL),
-	)
+	st, err := newStore(config, storeOpts...)
 	if err != nil {
 		return nil, fmt.Errorf("store init: %w", err)
 	}
@@ -53,8 +68,28 @@ func New(config *config.AppConfig, l logger.Logger) (*App, error) {
 	a := &App{
 		config: config,
 		store:  st,
-		log:    l,
-		grpc:   grpcservice.New(grpc.UnaryInterceptor(grpcservice.UID())),
+		clicks: clicks.NewBufferedTracker(
+			st,
+			clicks.WithBufferSize(config.ClickBufferSize),
+			clicks.WithBatchSize(config.ClickBatchSize),
+			clicks.WithFlushInterval(config.ClickFlushInterval),
+			clicks.WithOverflowPolicy(config.ClickOverflowPolicy, config.ClickBlockTimeout),
+			clicks.WithTimeout(config.StoreBatchTimeout),
+		),
+		log:     l,
+		proxies: proxies,
+		grpc:    grpcservice.New(grpc.UnaryInterceptor(grpcservice.UID()), grpc.StreamInterceptor(grpcservice.StreamUID())),
+	}
+	if threats != nil {
+		a.threats = threats
+		a.scanner = threat.NewScanner(st, threats, threat.WithScanInterval(config.ThreatScanInterval))
+	}
+
+	// expvar registry is global, so only the first app publishes its metrics
+	if expvar.Get("clicks") == nil {
+		expvar.Publish("clicks", expvar.Func(func() interface{} {
+			return a.clicks.Metrics()
+		}))
 	}
 
 	svc := grpcservice.NewShortenerService(st)
@@ -64,8 +99,8 @@ func New(config *config.AppConfig, l logger.Logger) (*App, error) {
 }
 
 func (a *App) Serve(ctx context.Context) error {
-	if err := a.store.Start(); err != nil {
-		return fmt.Errorf("store start: %w", err)
+	if err := a.start(); err != nil {
+		return err
 	}
 
 	srv := &http.Server{
@@ -76,6 +111,7 @@ func (a *App) Serve(ctx context.Context) error {
 	go func() {
 		a.log.Debug().Msgf("Listening on %s", a.config.ListenAddr)
 		a.log.Debug().Msgf("Base URL %s", a.config.BaseURL)
+		a.log.Debug().Msgf("Storage %s", a.config.Storage())
 
 		if a.config.EnableHTTPS {
 			manager := &autocert.Manager{
@@ -97,10 +133,7 @@ func (a *App) Serve(ctx context.Context) error {
 	<-ctx.Done()
 	a.log.Debug().Msgf("Server stopped")
 
-	if err := a.store.Stop(); err != nil {
-		return fmt.Errorf("store shutdown: %w", err)
-	}
-
+	// stop accepting requests first, so all the clicks are tracked before the final flush
 	a.grpc.Stop()
 
 	ctxShutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -108,8 +141,28 @@ func (a *App) Serve(ctx context.Context) error {
 		cancel()
 	}()
 
-	if err := srv.Shutdown(ctxShutdown); err != nil {
-		return fmt.Errorf("server shutdown: %w", err)
+	// buffered clicks are flushed even if some requests were not completed in time
+	shutdownErr := srv.Shutdown(ctxShutdown)
+
+	if err := a.clicks.Stop(); err != nil {
+		return fmt.Errorf("click tracker shutdown: %w", err)
+	}
+
+	if a.threats != nil {
+		if err := a.scanner.Stop(); err != nil {
+			return fmt.Errorf("threat scanner shutdown: %w", err)
+		}
+		if err := a.threats.Stop(); err != nil {
+			return fmt.Errorf("threat blocklist shutdown: %w", err)
+		}
+	}
+
+	if err := a.store.Stop(); err != nil {
+		return fmt.Errorf("store shutdown: %w", err)
+	}
+
+	if shutdownErr != nil {
+		return fmt.Errorf("server shutdown: %w", shutdownErr)
 	}
 
 	a.log.Debug().Msgf("Server exited properly")
@@ -117,9 +170,47 @@ func (a *App) Serve(ctx context.Context) error {
 	return nil
 }
 
+// start the components, the ones already started are stopped in reverse order if any of them fails to start
+func (a *App) start() (err error) {
+	var stops []func() error
+	defer func() {
+		if err == nil {
+			return
+		}
+		for i := len(stops) - 1; i >= 0; i-- {
+			if stopErr := stops[i](); stopErr != nil {
+				a.log.Error().Err(stopErr).Msg("Stop after start failure")
+			}
+		}
+	}()
+
+	if err := a.store.Start(); err != nil {
+		return fmt.Errorf("store start: %w", err)
+	}
+	stops = append(stops, a.store.Stop)
+
+	if err := a.clicks.Start(); err != nil {
+		return fmt.Errorf("click tracker start: %w", err)
+	}
+	stops = append(stops, a.clicks.Stop)
+
+	if a.threats != nil {
+		if err := a.threats.Start(); err != nil {
+			return fmt.Errorf("threat blocklist start: %w", err)
+		}
+		stops = append(stops, a.threats.Stop)
+		if err := a.scanner.Start(); err != nil {
+			return fmt.Errorf("threat scanner start: %w", err)
+		}
+	}
+
+	return nil
+}
+
 func (a *App) router() http.Handler {
 	r := chi.NewRouter()
 	r.Use(middleware.Recoverer)
+	r.Use(middleware.RequestID)
 	r.Use(mw.Log(a.log))
 
 	r.Use(a.grpc.Middleware())
@@ -131,11 +222,29 @@ func (a *App) router() http.Handler {
 	AttachProfiler(r)
 
 	r.With(mw.ContentTypeJSON).Get("/api/user/urls", api.UserDataHandler(a.store))
+	r.Get("/api/user/urls/export", api.ExportHandler(a.store))
+	r.Post("/api/user/urls/import", api.ImportHandler(a.store))
+	r.With(mw.ContentTypeJSON).Get("/api/user/urls/{id}/stats", api.LinkStatHandler(a.store))
 	r.With(mw.ContentTypeJSON).Post("/api/shorten", api.WriteHandler(a.store))
 	r.With(mw.ContentTypeJSON).Post("/api/shorten/batch", api.BatchWriteHandler(a.store))
+	r.Post("/api/shorten/stream", api.StreamWriteHandler(a.store))
 	r.With(mw.ContentTypeJSON).Delete("/api/user/urls", api.BatchRemoveHandler(a.store))
-	r.With(mw.ContentTypeJSON, mw.TrustedNetwork(a.config.TrustedNetwork)).Get("/api/internal/stats", api.StatHandler(a.store))
-	r.Get("/{id:[0-9a-z]+}", basic.ReadHandler(a.store))
+	r.With(mw.ContentTypeJSON).Post("/api/user/urls/restore", api.RestoreHandler(a.store))
+	r.With(mw.ContentTypeJSON).Patch("/api/user/urls/{id}", api.UpdateURLHandler(a.store))
+	r.With(mw.ContentTypeJSON).Get("/api/user/urls/{id}/history", api.HistoryHandler(a.store))
+	r.With(mw.ContentTypeJSON).Post("/api/user/urls/{id}/rollback", api.RollbackURLHandler(a.store))
+	r.With(mw.ContentTypeJSON).Get("/api/user/operations/{id}", api.OperationHandler(a.store))
+	r.With(mw.ContentTypeJSON, mw.RateLimit(a.config.ReportRateLimit, a.config.ReportRateWindow, a.proxies)).Post("/api/report/{id}", api.ReportHandler(a.store, a.proxies))
+	r.Route("/api/internal", func(r chi.Router) {
+		r.Use(mw.ContentTypeJSON, mw.TrustedNetwork(a.config.TrustedNetwork))
+		r.Get("/stats", api.StatHandler(a.store))
+		r.Get("/reports", api.ReportsHandler(a.store))
+		r.Post("/urls/{id}/disable", api.DisableURLHandler(a.store, true))
+		r.Post("/urls/{id}/enable", api.DisableURLHandler(a.store, false))
+		r.Put("/users/{uid}/ban", api.BanUserHandler(a.store, true))
+		r.Delete("/users/{uid}/ban", api.BanUserHandler(a.store, false))
+	})
+	r.Get("/{id:[0-9A-Za-z_-]+}", basic.ReadHandler(a.store, a.clicks, a.proxies))
 	r.Post("/", basic.WriteHandler(a.store))
 	r.Get("/ping", basic.PingHandler(a.store))
 
@@ -147,6 +256,7 @@ func AttachProfiler(router *chi.Mux) {
 	router.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
 	router.HandleFunc("/debug/pprof/profile", pprof.Profile)
 	router.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	router.Handle("/debug/vars", expvar.Handler())
 
 	// Manually add support for paths linked to by index page at /debug/pprof/
 	router.Handle("/debug/pprof/goroutine", pprof.Handler("goroutine"))
@@ -12,33 +12,83 @@ import (
 	"github.com/joho/godotenv"
 	"github.com/spf13/pflag"
 	"io/fs"
+	"net"
 	"net/url"
 	"os"
+	"shortener/internal/app/service/policy"
+	"shortener/internal/app/service/store"
+	"strings"
 	"time"
 )
 
-/**
-{
-    "server_address": "localhost:80", // аналог переменной окружения SERVER_ADDRESS или флага -a
-    "base_url": "http://localhost", // аналог переменной окружения BASE_URL или флага -b
-    "file_storage_path": "/path/to/file.db", // аналог переменной окружения FILE_STORAGE_PATH или флага -f
-    "database_dsn": "", // аналог переменной окружения DATABASE_DSN или флага -d
-    "enable_https": true // аналог переменной окружения ENABLE_HTTPS или флага -s
-}
+/*
+*
+
+	{
+	    "server_address": "localhost:80", // аналог переменной окружения SERVER_ADDRESS или флага -a
+	    "base_url": "http://localhost", // аналог переменной окружения BASE_URL или флага -b
+	    "file_storage_path": "/path/to/file.db", // аналог переменной окружения FILE_STORAGE_PATH или флага -f
+	    "database_dsn": "", // аналог переменной окружения DATABASE_DSN или флага -d
+	    "enable_https": true // аналог переменной окружения ENABLE_HTTPS или флага -s
+	}
 */
 type AppConfig struct {
 	ListenAddr           string `env:"SERVER_ADDRESS,default=localhost:8080" validate:"required,hostname_port" json:"server_address"`
 	BaseURL              string `env:"BASE_URL,default=http://localhost:8080" validate:"required,base_url" json:"base_url"`
-	StorageFilePath      string `env:"FILE_STORAGE_PATH,default=urls.gob" validate:"required" json:"file_storage_path"`
+	StorageType          string `env:"STORAGE_TYPE" validate:"omitempty,oneof=postgres file memory" json:"storage_type"`
+	StorageFilePath      string `env:"FILE_STORAGE_PATH,default=urls.gob" validate:"required_if=StorageType file" json:"file_storage_path"`
 	SecretKey            string `env:"SECRET_KEY,default=change_me" validate:"required"`
-	DSN                  string `env:"DATABASE_DSN" json:"database_dsn"`
+	DSN                  string `env:"DATABASE_DSN" validate:"required_if=StorageType postgres" json:"database_dsn"`
 	StorageFlushInterval time.Duration
-	Verbose              bool   `env:"APP_VERBOSE,default=0"`
-	EnableHTTPS          bool   `env:"ENABLE_HTTPS,default=0" json:"enable_https"`
-	ConfigFile           string `env:"CONFIG"`
-	TrustedNetwork       string `env:"TRUSTED_SUBNET,default=127.0.0.0/8" json:"trusted_network" validate:"cidr"`
+	StoreReadTimeout     time.Duration `env:"STORE_READ_TIMEOUT,default=1s"`
+	StoreWriteTimeout    time.Duration `env:"STORE_WRITE_TIMEOUT,default=3s"`
+	StoreBatchTimeout    time.Duration `env:"STORE_BATCH_TIMEOUT,default=30s"`
+	IDStrategy           string        `env:"ID_STRATEGY,default=sequential" validate:"oneof=sequential random obfuscated" json:"id_strategy"`
+	IDLength             int           `env:"ID_LENGTH,default=8" validate:"min=4,max=64" json:"id_length"`
+	IDSecret             string        `env:"ID_SECRET"`
+	DedupScope           string        `env:"DEDUP_SCOPE,default=global" validate:"oneof=global user none" json:"dedup_scope"`
+	URLCanonicalize      bool          `env:"URL_CANONICALIZE,default=1" json:"url_canonicalize"`
+	URLSortQuery         bool          `env:"URL_SORT_QUERY,default=0" json:"url_sort_query"`
+	URLStripParams       string        `env:"URL_STRIP_PARAMS" json:"url_strip_params"`
+	AllowedSchemes       string        `env:"ALLOWED_SCHEMES" json:"allowed_schemes"`
+	PolicyFile           string        `env:"POLICY_FILE" json:"policy_file"`
+	PolicyReloadInterval time.Duration `env:"POLICY_RELOAD_INTERVAL,default=10s"`
+	ThreatBlocklistFile  string        `env:"THREAT_BLOCKLIST_FILE" json:"threat_blocklist_file"`
+	ThreatReloadInterval time.Duration `env:"THREAT_RELOAD_INTERVAL,default=1m"`
+	ThreatScanInterval   time.Duration `env:"THREAT_SCAN_INTERVAL,default=1h"`
+	ReportRateLimit      int           `env:"REPORT_RATE_LIMIT,default=5" validate:"min=1"`
+	ReportRateWindow     time.Duration `env:"REPORT_RATE_WINDOW,default=1m" validate:"min=1s"`
+	TrustedProxies       string        `env:"TRUSTED_PROXIES" json:"trusted_proxies"`
+	ExpireSweepInterval  time.Duration `env:"EXPIRE_SWEEP_INTERVAL,default=1m"`
+	ExpireGracePeriod    time.Duration `env:"EXPIRE_GRACE_PERIOD,default=24h"`
+	DeletedRetention     time.Duration `env:"DELETED_RETENTION,default=720h"`
+	DeletionPollInterval time.Duration `env:"DELETION_POLL_INTERVAL,default=1s" validate:"min=10ms"`
+	DeletionWindow       time.Duration `env:"DELETION_WINDOW,default=100ms"`
+	ClickBufferSize      int           `env:"CLICK_BUFFER_SIZE,default=10000" validate:"min=1"`
+	ClickBatchSize       int           `env:"CLICK_BATCH_SIZE,default=500" validate:"min=1"`
+	ClickFlushInterval   time.Duration `env:"CLICK_FLUSH_INTERVAL,default=1s" validate:"min=1ms"`
+	ClickOverflowPolicy  string        `env:"CLICK_OVERFLOW_POLICY,default=drop" validate:"oneof=drop block"`
+	ClickBlockTimeout    time.Duration `env:"CLICK_BLOCK_TIMEOUT,default=50ms"`
+	Verbose              bool          `env:"APP_VERBOSE,default=0"`
+	EnableHTTPS          bool          `env:"ENABLE_HTTPS,default=0" json:"enable_https"`
+	ConfigFile           string        `env:"CONFIG"`
+	TrustedNetwork       string        `env:"TRUSTED_SUBNET,default=127.0.0.0/8" json:"trusted_network" validate:"cidr"`
 }
 
+// Storage types
+const (
+	StoragePostgres = "postgres"
+	StorageFile     = "file"
+	StorageMemory   = "memory"
+)
+
+// Short id generation strategies
+const (
+	IDSequential = "sequential"
+	IDRandom     = "random"
+	IDObfuscated = "obfuscated"
+)
+
 // New constructor
 func New() *AppConfig {
 	const defaultStorageFlushInterval = time.Second * 5
@@ -81,15 +131,129 @@ func (c *AppConfig) Load() error {
 	pflag.StringVarP(&c.ListenAddr, "listen-addr", "a", c.ListenAddr, "Server address to listen on")
 	pflag.StringVarP(&c.BaseURL, "base-url", "b", c.BaseURL, "Base URL for shortened links")
 	pflag.StringVarP(&c.StorageFilePath, "storage-file-path", "f", c.StorageFilePath, "Storage file path")
+	pflag.StringVar(&c.StorageType, "storage", c.StorageType, "Storage type (postgres, file, memory)")
 	pflag.StringVarP(&c.DSN, "dsn", "d", c.DSN, "Database connection DSN")
 	pflag.BoolVarP(&c.Verbose, "verbose", "v", c.Verbose, "Verbose output")
 	pflag.BoolVarP(&c.EnableHTTPS, "secure", "s", c.EnableHTTPS, "Enable HTTPS")
+	pflag.DurationVar(&c.StoreReadTimeout, "store-read-timeout", c.StoreReadTimeout, "Store read operations timeout")
+	pflag.DurationVar(&c.StoreWriteTimeout, "store-write-timeout", c.StoreWriteTimeout, "Store write operations timeout")
+	pflag.DurationVar(&c.StoreBatchTimeout, "store-batch-timeout", c.StoreBatchTimeout, "Store batch operations timeout")
+	pflag.StringVar(&c.IDStrategy, "id-strategy", c.IDStrategy, "Short id generation strategy (sequential, random, obfuscated)")
+	pflag.IntVar(&c.IDLength, "id-length", c.IDLength, "Length of the random short ids")
+	pflag.StringVar(&c.DedupScope, "dedup-scope", c.DedupScope, "Scope of the unique original urls (global, user, none)")
+	pflag.BoolVar(&c.URLCanonicalize, "url-canonicalize", c.URLCanonicalize, "Compare original urls in the canonical form")
+	pflag.BoolVar(&c.URLSortQuery, "url-sort-query", c.URLSortQuery, "Sort query parameters of the canonical urls")
+	pflag.StringVar(&c.URLStripParams, "url-strip-params", c.URLStripParams, "Comma separated query parameters removed from the canonical urls, e.g. utm_*,fbclid")
+	pflag.StringVar(&c.AllowedSchemes, "allowed-schemes", c.AllowedSchemes, "Comma separated url schemes allowed to be shortened, http and https if empty")
+	pflag.StringVar(&c.PolicyFile, "policy-file", c.PolicyFile, "JSON file with allow and deny lists of the destination hosts")
+	pflag.DurationVar(&c.PolicyReloadInterval, "policy-reload-interval", c.PolicyReloadInterval, "Policy file changes check interval, 0 disables reloading")
+	pflag.StringVar(&c.ThreatBlocklistFile, "threat-blocklist-file", c.ThreatBlocklistFile, "File with hash prefixes of the phishing and malware urls, empty disables threat checks")
+	pflag.DurationVar(&c.ThreatReloadInterval, "threat-reload-interval", c.ThreatReloadInterval, "Threat blocklist file changes check interval, 0 disables reloading")
+	pflag.DurationVar(&c.ThreatScanInterval, "threat-scan-interval", c.ThreatScanInterval, "Existing links re-check interval, 0 disables re-checks")
+	pflag.IntVar(&c.ReportRateLimit, "report-rate-limit", c.ReportRateLimit, "Max number of abuse reports sent from one address per window")
+	pflag.DurationVar(&c.ReportRateWindow, "report-rate-window", c.ReportRateWindow, "Abuse reports rate limit window")
+	pflag.StringVar(&c.TrustedProxies, "trusted-proxies", c.TrustedProxies, "Comma separated CIDRs of the proxies whose X-Real-IP is trusted as the client address")
+	pflag.DurationVar(&c.ExpireSweepInterval, "expire-sweep-interval", c.ExpireSweepInterval, "Expired and removed urls purge interval, 0 disables purging")
+	pflag.DurationVar(&c.ExpireGracePeriod, "expire-grace-period", c.ExpireGracePeriod, "Expired urls are kept for the period before purge")
+	pflag.DurationVar(&c.DeletedRetention, "deleted-retention", c.DeletedRetention, "Removed urls can be restored for the period before purge")
+	pflag.DurationVar(&c.DeletionPollInterval, "deletion-poll-interval", c.DeletionPollInterval, "Pending deletion requests check interval")
+	pflag.DurationVar(&c.DeletionWindow, "deletion-window", c.DeletionWindow, "Time new deletion requests are collected for to be removed together")
+	pflag.IntVar(&c.ClickBufferSize, "click-buffer-size", c.ClickBufferSize, "Max number of buffered click events")
+	pflag.IntVar(&c.ClickBatchSize, "click-batch-size", c.ClickBatchSize, "Number of click events written at once")
+	pflag.DurationVar(&c.ClickFlushInterval, "click-flush-interval", c.ClickFlushInterval, "Max time click event waits in the buffer")
+	pflag.StringVar(&c.ClickOverflowPolicy, "click-overflow-policy", c.ClickOverflowPolicy, "Click buffer overflow policy (drop, block)")
+	pflag.DurationVar(&c.ClickBlockTimeout, "click-block-timeout", c.ClickBlockTimeout, "Max redirect delay waiting for the click buffer space with block policy")
 	pflag.StringVarP(&c.TrustedNetwork, "trusted-network", "t", c.TrustedNetwork, "Trusted network")
 	pflag.Parse()
 
 	return nil
 }
 
+// Storage returns storage type selected explicitly or detected from the other params
+func (c *AppConfig) Storage() string {
+	switch {
+	case c.StorageType != "":
+		return c.StorageType
+	case c.DSN != "":
+		return StoragePostgres
+	case c.StorageFilePath != "":
+		return StorageFile
+	default:
+		return StorageMemory
+	}
+}
+
+// StoreTimeouts returns store operations timeouts
+func (c *AppConfig) StoreTimeouts() store.Timeouts {
+	return store.Timeouts{
+		Read:  c.StoreReadTimeout,
+		Write: c.StoreWriteTimeout,
+		Batch: c.StoreBatchTimeout,
+	}
+}
+
+// IDGenerator returns short id generator selected by the strategy.
+// Obfuscation key is derived from IDSecret or SecretKey if it is not set.
+func (c *AppConfig) IDGenerator() (store.IDGenerator, error) {
+	const sequentialBase = 36
+
+	switch c.IDStrategy {
+	case IDSequential, "":
+		return store.NewSequentialGenerator(sequentialBase), nil
+	case IDRandom:
+		return store.NewRandomGenerator(c.IDLength)
+	case IDObfuscated:
+		secret := c.IDSecret
+		if secret == "" {
+			secret = c.SecretKey
+		}
+		return store.NewPermutationGenerator(secret), nil
+	default:
+		return nil, fmt.Errorf("unknown id strategy %q", c.IDStrategy)
+	}
+}
+
+// Canonicalizer returns conversion of the urls into the canonical form used to detect duplicates,
+// nil if the urls are compared as is
+func (c *AppConfig) Canonicalizer() *store.Canonicalizer {
+	if !c.URLCanonicalize {
+		return nil
+	}
+
+	opts := []store.CanonicalOption{store.WithStrippedParams(strings.Split(c.URLStripParams, ",")...)}
+	if c.URLSortQuery {
+		opts = append(opts, store.WithSortedQuery())
+	}
+	return store.NewCanonicalizer(opts...)
+}
+
+// Policy returns the checks of the shortened destinations, links to the base url are rejected
+func (c *AppConfig) Policy() (*policy.Policy, error) {
+	return policy.New(
+		policy.WithSchemes(strings.Split(c.AllowedSchemes, ",")...),
+		policy.WithSelfURL(c.BaseURL),
+		policy.WithRulesFile(c.PolicyFile),
+		policy.WithReloadInterval(c.PolicyReloadInterval),
+	)
+}
+
+// TrustedProxyNets returns parsed TrustedProxies, empty config means no trusted proxies
+func (c *AppConfig) TrustedProxyNets() ([]*net.IPNet, error) {
+	var res []*net.IPNet
+	for _, v := range strings.Split(c.TrustedProxies, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		_, n, err := net.ParseCIDR(v)
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxies parse: %w", err)
+		}
+		res = append(res, n)
+	}
+	return res, nil
+}
+
 func (c *AppConfig) Validate() error {
 	validate := validator.New()
 
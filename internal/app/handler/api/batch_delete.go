@@ -7,9 +7,14 @@ import (
 	"shortener/internal/app/service/store"
 )
 
-// BatchRemoveHandler removes multiple urls.
+type BatchRemoveResponse struct {
+	OperationID string `json:"operation_id"`
+}
+
+// BatchRemoveHandler removes multiple urls asynchronously, removal state is available by the operation id.
 //
 //	curl -v -X DELETE -H "Content-Type: application/json" -d '["xxx", "xxy"]' http://localhost:8080/api/user/urls
+//	{"operation_id":"1"}
 func BatchRemoveHandler(s store.BatchRemover) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		req := make([]string, 0)
@@ -21,7 +26,8 @@ func BatchRemoveHandler(s store.BatchRemover) http.HandlerFunc {
 
 		uid := handler.ReadContextString(r.Context(), handler.ContextKeyUID{})
 
-		if err := s.BatchRemove(uid, req...); err != nil {
+		opID, err := s.BatchRemove(r.Context(), uid, req...)
+		if err != nil {
 			if errors.Is(err, store.ErrBadInput) {
 				writeError(w, err, http.StatusBadRequest)
 			} else {
@@ -30,6 +36,7 @@ func BatchRemoveHandler(s store.BatchRemover) http.HandlerFunc {
 			return
 		}
 
-		writeResponse(w, nil, http.StatusAccepted)
+		w.Header().Set("Location", "/api/user/operations/"+opID)
+		writeResponse(w, &BatchRemoveResponse{OperationID: opID}, http.StatusAccepted)
 	}
 }
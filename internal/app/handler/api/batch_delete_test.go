@@ -37,6 +37,7 @@ func TestBatchDeleteHandler(t *testing.T) {
 			},
 			want{
 				code: http.StatusAccepted,
+				body: `{"operation_id":"op1"}`,
 			},
 		},
 		{
@@ -67,9 +68,9 @@ func TestBatchDeleteHandler(t *testing.T) {
 	defer ctrl.Finish()
 
 	s := storemock.NewMockBatchRemover(ctrl)
-	s.EXPECT().BatchRemove("test1", "ok1", "ok2").Return(nil)
-	s.EXPECT().BatchRemove("test1", "bad", "input").Return(store.ErrBadInput)
-	s.EXPECT().BatchRemove("test1", "error", "500").Return(errors.New("internal"))
+	s.EXPECT().BatchRemove(gomock.Any(), "test1", "ok1", "ok2").Return("op1", nil)
+	s.EXPECT().BatchRemove(gomock.Any(), "test1", "bad", "input").Return("", store.ErrBadInput)
+	s.EXPECT().BatchRemove(gomock.Any(), "test1", "error", "500").Return("", errors.New("internal"))
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"shortener/internal/app/handler"
+	"shortener/internal/app/service/store"
+	"time"
+)
+
+const (
+	// streamChunkSize is a number of records written by a single store batch
+	streamChunkSize = 500
+	// streamMaxLineSize limits a single input line
+	streamMaxLineSize = 64 << 10
+)
+
+// StreamWriteResponseItem is a result line of the input line
+type StreamWriteResponseItem struct {
+	// Line is a number of the input line starting from 1
+	Line          int    `json:"line"`
+	CorrelationID string `json:"correlation_id,omitempty"`
+	// ShortURL of the new link, or of the existing one on conflict
+	ShortURL string `json:"short_url,omitempty"`
+	// Code is set on failure, one of bad_input, conflict, alias_taken, forbidden_target or internal
+	Code  string `json:"code,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// streamItem is a parsed input line waiting for the chunk write, record Err is set if the line is invalid
+type streamItem struct {
+	line   int
+	record store.Record
+}
+
+// StreamWriteHandler stores original urls read from the newline-delimited json stream
+// and streams back one result line per input line in the same order.
+// Input lines have the same format as BatchWriteHandler items, blank lines are skipped.
+//
+// Records are written in chunks and the result lines are spooled to a temporary file, so the input size is not limited.
+// Results are sent once the whole input is read, HTTP/1.x server closes the request body as soon as the response is
+// flushed. Failures are reported per line the same way as by BatchWriteHandler, invalid or conflicting items
+// do not prevent the others from being stored.
+//
+//	curl -X POST -H "Content-Type: application/x-ndjson" --data-binary @links.ndjson http://localhost:8080/api/shorten/stream
+//	{"line":1,"correlation_id":"abc","short_url":"http://localhost:8080/xxy"}
+//	{"line":2,"correlation_id":"abd","code":"bad_input","error":"json decode: unexpected end of JSON input"}
+func StreamWriteHandler(s store.BatchWriter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			_ = r.Body.Close()
+		}()
+
+		spool, err := os.CreateTemp("", "stream-*.ndjson")
+		if err != nil {
+			http.Error(w, fmt.Sprintf("result spool: %v", err), http.StatusInternalServerError)
+			return
+		}
+		defer func() {
+			_ = spool.Close()
+			_ = os.Remove(spool.Name())
+		}()
+
+		uid := handler.ReadContextString(r.Context(), handler.ContextKeyUID{})
+		buf := bufio.NewWriter(spool)
+		out := json.NewEncoder(buf)
+		chunk := make([]streamItem, 0, streamChunkSize)
+
+		scanner := bufio.NewScanner(r.Body)
+		scanner.Buffer(make([]byte, 0, 4096), streamMaxLineSize)
+		line := 0
+		for scanner.Scan() {
+			line++
+			data := bytes.TrimSpace(scanner.Bytes())
+			if len(data) == 0 {
+				continue
+			}
+
+			chunk = append(chunk, streamItem{line: line, record: parseStreamItem(data, time.Now())})
+			if len(chunk) == streamChunkSize {
+				writeStreamChunk(r, s, uid, chunk, out)
+				chunk = chunk[:0]
+			}
+			if r.Context().Err() != nil {
+				return
+			}
+		}
+		writeStreamChunk(r, s, uid, chunk, out)
+
+		if err := scanner.Err(); err != nil {
+			_ = out.Encode(StreamWriteResponseItem{
+				Line:  line + 1,
+				Code:  store.ItemErrBadInput,
+				Error: fmt.Sprintf("body read: %v", err),
+			})
+		}
+
+		if err := buf.Flush(); err != nil {
+			http.Error(w, fmt.Sprintf("result spool: %v", err), http.StatusInternalServerError)
+			return
+		}
+		if _, err := spool.Seek(0, io.SeekStart); err != nil {
+			http.Error(w, fmt.Sprintf("result spool: %v", err), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.WriteHeader(http.StatusOK)
+		_, _ = io.Copy(w, spool)
+	}
+}
+
+// parseStreamItem decodes input line into the store record, record Err is set if the line is invalid
+func parseStreamItem(data []byte, now time.Time) store.Record {
+	var reqObj BatchWriteRequestItem
+	if err := json.Unmarshal(data, &reqObj); err != nil {
+		return store.Record{Err: fmt.Errorf("json decode: %w: %v", store.ErrBadInput, err)}
+	}
+
+	rec := store.Record{
+		CorrelationID: reqObj.CorrelationID,
+		OriginalURL:   reqObj.OriginalURL,
+		ID:            reqObj.Alias,
+	}
+	rec.ExpiresAt, rec.Err = store.ResolveExpiration(reqObj.ExpiresAt, time.Duration(reqObj.TTL)*time.Second, now)
+
+	return rec
+}
+
+// writeStreamChunk writes the chunk in one batch, results are written in the input order
+func writeStreamChunk(r *http.Request, s store.BatchWriter, uid string, chunk []streamItem, out *json.Encoder) {
+	if len(chunk) == 0 {
+		return
+	}
+
+	records := make([]store.Record, len(chunk))
+	for i := range chunk {
+		records[i] = chunk[i].record
+	}
+
+	written, err := s.BatchWrite(r.Context(), uid, records)
+	for i, item := range chunk {
+		res := StreamWriteResponseItem{Line: item.line, CorrelationID: item.record.CorrelationID}
+		switch {
+		case err != nil:
+			res.Code = store.ItemErrInternal
+			res.Error = err.Error()
+		case written[i].Err != nil:
+			res.ShortURL = written[i].ShortURL
+			res.Code = store.ItemErrorCode(written[i].Err)
+			res.Error = written[i].Err.Error()
+		default:
+			res.ShortURL = written[i].ShortURL
+		}
+		_ = out.Encode(res)
+	}
+}
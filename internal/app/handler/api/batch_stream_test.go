@@ -0,0 +1,164 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"shortener/internal/app/handler"
+	"shortener/internal/app/service/store"
+	"shortener/internal/app/service/store/memorystore"
+	storemock "shortener/internal/app/service/store/mock"
+	"strings"
+	"testing"
+)
+
+func TestStreamWriteHandler(t *testing.T) {
+	type want struct {
+		code int
+		body string
+	}
+	tests := []struct {
+		name    string
+		body    string
+		prepare func(s *storemock.MockStore)
+		want    want
+	}{
+		{
+			"stream ok",
+			"{\"correlation_id\":\"1\",\"original_url\":\"https://example.org/1\"}\n\n" +
+				"{\"correlation_id\":\"2\",\"original_url\":\"https://example.org/2\",\"ttl\":-1}\n" +
+				"{broken\n" +
+				"{\"correlation_id\":\"4\",\"original_url\":\"https://example.org/4\",\"alias\":\"four\"}",
+			func(s *storemock.MockStore) {
+				s.EXPECT().BatchWrite(gomock.Any(), "test", gomock.Len(4)).DoAndReturn(
+					func(_ context.Context, _ string, in []store.Record, _ ...store.WriteOption) ([]store.Record, error) {
+						assert.Equal(t, store.Record{CorrelationID: "1", OriginalURL: "https://example.org/1"}, in[0])
+						assert.ErrorIs(t, in[1].Err, store.ErrBadInput, "invalid ttl must be reported")
+						assert.ErrorIs(t, in[2].Err, store.ErrBadInput, "malformed line must be reported")
+						assert.Equal(t, store.Record{CorrelationID: "4", OriginalURL: "https://example.org/4", ID: "four"}, in[3])
+						in[0].ShortURL = "http://short/1"
+						in[3].ShortURL = "http://short/four"
+						return in, nil
+					})
+			},
+			want{
+				code: http.StatusOK,
+				body: `{"line":1,"correlation_id":"1","short_url":"http://short/1"}
+{"line":3,"correlation_id":"2","code":"bad_input","error":"bad input: negative ttl"}
+{"line":4,"code":"bad_input","error":"json decode: bad input: invalid character 'b' looking for beginning of object key string"}
+{"line":5,"correlation_id":"4","short_url":"http://short/four"}
+`,
+			},
+		},
+		{
+			"item failures",
+			"{\"correlation_id\":\"1\",\"original_url\":\"https://example.org/1\"}\n" +
+				"{\"correlation_id\":\"2\",\"original_url\":\"https://example.org/2\"}\n" +
+				"{\"correlation_id\":\"3\",\"original_url\":\"https://example.org/3\",\"alias\":\"taken\"}\n" +
+				"{\"correlation_id\":\"4\",\"original_url\":\"bad\"}\n",
+			func(s *storemock.MockStore) {
+				s.EXPECT().BatchWrite(gomock.Any(), "test", gomock.Len(4)).Return([]store.Record{
+					{CorrelationID: "1", ShortURL: "http://short/1"},
+					{CorrelationID: "2", ShortURL: "http://short/old", Err: &store.ConflictError{ExistingURL: "http://short/old"}},
+					{CorrelationID: "3", Err: store.ErrAliasTaken},
+					{CorrelationID: "4", Err: store.ErrBadInput},
+				}, nil)
+			},
+			want{
+				code: http.StatusOK,
+				body: `{"line":1,"correlation_id":"1","short_url":"http://short/1"}
+{"line":2,"correlation_id":"2","short_url":"http://short/old","code":"conflict","error":"conflict"}
+{"line":3,"correlation_id":"3","code":"alias_taken","error":"alias taken"}
+{"line":4,"correlation_id":"4","code":"bad_input","error":"bad input"}
+`,
+			},
+		},
+		{
+			"batch failure",
+			"{\"correlation_id\":\"1\",\"original_url\":\"https://example.org/1\"}\n",
+			func(s *storemock.MockStore) {
+				s.EXPECT().BatchWrite(gomock.Any(), "test", gomock.Len(1)).Return(nil, errors.New("db is down"))
+			},
+			want{
+				code: http.StatusOK,
+				body: `{"line":1,"correlation_id":"1","code":"internal","error":"db is down"}
+`,
+			},
+		},
+		{
+			"line too long",
+			"{\"original_url\":\"https://example.org/" + strings.Repeat("a", streamMaxLineSize) + "\"}\n",
+			func(s *storemock.MockStore) {},
+			want{
+				code: http.StatusOK,
+				body: `{"line":1,"code":"bad_input","error":"body read: bufio.Scanner: token too long"}
+`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s := storemock.NewMockStore(ctrl)
+			tt.prepare(s)
+
+			request := httptest.NewRequest("POST", "/api/shorten/stream", strings.NewReader(tt.body))
+			request = request.WithContext(context.WithValue(request.Context(), handler.ContextKeyUID{}, "test"))
+			w := httptest.NewRecorder()
+			StreamWriteHandler(s).ServeHTTP(w, request)
+
+			res := w.Result()
+			resBody, _ := ioutil.ReadAll(res.Body)
+			assert.Equal(t, tt.want.code, res.StatusCode)
+			assert.Equal(t, "application/x-ndjson", res.Header.Get("Content-Type"))
+			assert.Equal(t, tt.want.body, string(resBody))
+			_ = res.Body.Close()
+		})
+	}
+}
+
+func TestStreamWriteHandler_Server(t *testing.T) {
+	const lines = 3*streamChunkSize + 1
+
+	s := memorystore.NewStore(memorystore.WithBaseURL("http://localhost:8080"))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r = r.WithContext(context.WithValue(r.Context(), handler.ContextKeyUID{}, "test"))
+		StreamWriteHandler(s).ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	var body strings.Builder
+	for i := 1; i <= lines; i++ {
+		fmt.Fprintf(&body, "{\"correlation_id\":\"%d\",\"original_url\":\"https://example.org/%d\"}\n", i, i)
+	}
+
+	res, err := http.Post(srv.URL, "application/x-ndjson", strings.NewReader(body.String()))
+	require.NoError(t, err)
+	defer func() {
+		_ = res.Body.Close()
+	}()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	got := 0
+	scanner := bufio.NewScanner(res.Body)
+	for scanner.Scan() {
+		var item StreamWriteResponseItem
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &item))
+		got++
+		assert.Equal(t, got, item.Line)
+		assert.Empty(t, item.Error, "line %d", item.Line)
+		assert.NotEmpty(t, item.ShortURL, "line %d", item.Line)
+	}
+	require.NoError(t, scanner.Err())
+	assert.Equal(t, lines, got, "every input line must get a result")
+}
@@ -5,22 +5,40 @@ import (
 	"net/http"
 	"shortener/internal/app/handler"
 	"shortener/internal/app/service/store"
+	"strconv"
+	"time"
 )
 
 type BatchWriteRequestItem struct {
 	CorrelationID string `json:"correlation_id"`
 	OriginalURL   string `json:"original_url"`
+	Alias         string `json:"alias,omitempty"`
+	// ExpiresAt is an absolute expiration time in RFC 3339 format
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// TTL is an expiration time in seconds from now
+	TTL int64 `json:"ttl,omitempty"`
 }
 
 type BatchWriteResponseItem struct {
 	CorrelationID string `json:"correlation_id"`
-	ShortURL      string `json:"short_url"`
+	// ShortURL of the new link, or of the existing one on conflict
+	ShortURL string `json:"short_url,omitempty"`
+	// Code is set on failure, one of bad_input, conflict, alias_taken, forbidden_target or internal
+	Code  string `json:"code,omitempty"`
+	Error string `json:"error,omitempty"`
 }
 
 // BatchWriteHandler stores multiple original urls and returns the short versions.
 //
 //	curl -X POST -H "Content-Type: application/json" -d '[{"correlation_id":"abc","original_url":"https://example.org"}]' http://localhost:8080/api/shorten/batch
 //	[{"correlation_id":"abc","short_url":"http://localhost:8080/xxy"}]
+//
+// Items with alias get it as a custom short id. Optional expires_at or ttl (in seconds) limit the item link lifetime.
+// Repeated urls are shortened once.
+//
+// Items are stored independently, failed ones are reported with the error code, the conflicting ones also get
+// the existing short url. Response status is 201 if all items are stored and 207 otherwise.
+// With ?atomic=true the whole batch fails on the first failed item.
 func BatchWriteHandler(s store.BatchWriter) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		reqObj := make([]BatchWriteRequestItem, 0)
@@ -30,19 +48,32 @@ func BatchWriteHandler(s store.BatchWriter) http.HandlerFunc {
 			return
 		}
 
+		var opts []store.WriteOption
+		if atomic, _ := strconv.ParseBool(r.URL.Query().Get("atomic")); atomic {
+			opts = append(opts, store.WithAtomic())
+		}
+
 		uid := handler.ReadContextString(r.Context(), handler.ContextKeyUID{})
 
+		now := time.Now()
 		storeReq := make([]store.Record, len(reqObj))
 		for i, rec := range reqObj {
 			storeReq[i] = store.Record{
 				CorrelationID: rec.CorrelationID,
 				OriginalURL:   rec.OriginalURL,
+				ID:            rec.Alias,
 			}
+			storeReq[i].ExpiresAt, storeReq[i].Err = store.ResolveExpiration(rec.ExpiresAt, time.Duration(rec.TTL)*time.Second, now)
 		}
 
-		storeRes, err := s.BatchWrite(uid, storeReq)
+		storeRes, err := s.BatchWrite(r.Context(), uid, storeReq, opts...)
 		if err != nil {
-			if errors.Is(err, store.ErrBadInput) {
+			var errConflict *store.ConflictError
+			if errors.Is(err, store.ErrAliasTaken) || errors.As(err, &errConflict) {
+				writeError(w, err, http.StatusConflict)
+			} else if errors.Is(err, store.ErrForbiddenTarget) {
+				writeError(w, err, http.StatusUnprocessableEntity)
+			} else if errors.Is(err, store.ErrBadInput) {
 				writeError(w, err, http.StatusBadRequest)
 			} else {
 				writeError(w, err, http.StatusInternalServerError)
@@ -50,14 +81,20 @@ func BatchWriteHandler(s store.BatchWriter) http.HandlerFunc {
 			return
 		}
 
-		respObj := make([]BatchWriteResponseItem, len(reqObj))
+		statusCode := http.StatusCreated
+		respObj := make([]BatchWriteResponseItem, len(storeRes))
 		for i, rec := range storeRes {
 			respObj[i] = BatchWriteResponseItem{
 				CorrelationID: rec.CorrelationID,
 				ShortURL:      rec.ShortURL,
 			}
+			if rec.Err != nil {
+				respObj[i].Code = store.ItemErrorCode(rec.Err)
+				respObj[i].Error = rec.Err.Error()
+				statusCode = http.StatusMultiStatus
+			}
 		}
 
-		writeResponse(w, respObj, http.StatusCreated)
+		writeResponse(w, respObj, statusCode)
 	}
 }
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"shortener/internal/app/handler"
+	"shortener/internal/app/service/store"
+	storemock "shortener/internal/app/service/store/mock"
+	"strings"
+	"testing"
+)
+
+func TestBatchWriteHandler(t *testing.T) {
+	type want struct {
+		code int
+		body string
+	}
+	tests := []struct {
+		name    string
+		query   string
+		body    string
+		prepare func(s *storemock.MockBatchWriter)
+		want    want
+	}{
+		{
+			"write ok",
+			"",
+			`[{"correlation_id":"a","original_url":"https://example.org/a"}]`,
+			func(s *storemock.MockBatchWriter) {
+				s.EXPECT().BatchWrite(gomock.Any(), "test", []store.Record{{CorrelationID: "a", OriginalURL: "https://example.org/a"}}).
+					Return([]store.Record{{CorrelationID: "a", ShortURL: "http://short/a"}}, nil)
+			},
+			want{
+				code: http.StatusCreated,
+				body: `[{"correlation_id":"a","short_url":"http://short/a"}]`,
+			},
+		},
+		{
+			"partial success",
+			"",
+			`[{"correlation_id":"a","original_url":"https://example.org/a"},{"correlation_id":"b","original_url":"https://example.org/b","ttl":-1},` +
+				`{"correlation_id":"c","original_url":"https://example.org/c"},{"correlation_id":"d","original_url":"https://example.org/d","alias":"d"}]`,
+			func(s *storemock.MockBatchWriter) {
+				s.EXPECT().BatchWrite(gomock.Any(), "test", gomock.Len(4)).DoAndReturn(
+					func(_ context.Context, _ string, in []store.Record, _ ...store.WriteOption) ([]store.Record, error) {
+						assert.ErrorIs(t, in[1].Err, store.ErrBadInput, "invalid ttl must be passed as the item error")
+						in[0].ShortURL = "http://short/a"
+						in[2].ShortURL, in[2].Err = "http://short/old", &store.ConflictError{ExistingURL: "http://short/old"}
+						in[3].Err = fmt.Errorf("%w: %q", store.ErrAliasTaken, "d")
+						return in, nil
+					})
+			},
+			want{
+				code: http.StatusMultiStatus,
+				body: `[{"correlation_id":"a","short_url":"http://short/a"},` +
+					`{"correlation_id":"b","code":"bad_input","error":"bad input: negative ttl"},` +
+					`{"correlation_id":"c","short_url":"http://short/old","code":"conflict","error":"conflict"},` +
+					`{"correlation_id":"d","code":"alias_taken","error":"alias taken: \"d\""}]`,
+			},
+		},
+		{
+			"atomic conflict",
+			"?atomic=true",
+			`[{"correlation_id":"a","original_url":"https://example.org/a"}]`,
+			func(s *storemock.MockBatchWriter) {
+				s.EXPECT().BatchWrite(gomock.Any(), "test", gomock.Len(1), gomock.Any()).
+					Return(nil, fmt.Errorf("batch item 0: %w", &store.ConflictError{ExistingURL: "http://short/old"}))
+			},
+			want{
+				code: http.StatusConflict,
+				body: `{"error":"batch item 0: conflict"}`,
+			},
+		},
+		{
+			"atomic bad input",
+			"?atomic=1",
+			`[{"correlation_id":"a","original_url":"bad"}]`,
+			func(s *storemock.MockBatchWriter) {
+				s.EXPECT().BatchWrite(gomock.Any(), "test", gomock.Len(1), gomock.Any()).
+					Return(nil, fmt.Errorf("batch item 0: %w", store.ErrBadInput))
+			},
+			want{
+				code: http.StatusBadRequest,
+				body: `{"error":"batch item 0: bad input"}`,
+			},
+		},
+		{
+			"store error",
+			"",
+			`[{"correlation_id":"a","original_url":"https://example.org/a"}]`,
+			func(s *storemock.MockBatchWriter) {
+				s.EXPECT().BatchWrite(gomock.Any(), "test", gomock.Len(1)).Return(nil, errors.New("internal"))
+			},
+			want{
+				code: http.StatusInternalServerError,
+				body: `{"error":"internal"}`,
+			},
+		},
+		{
+			"malformed body",
+			"",
+			`{`,
+			func(s *storemock.MockBatchWriter) {},
+			want{
+				code: http.StatusBadRequest,
+				body: `{"error":"json decode: unexpected end of JSON input"}`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s := storemock.NewMockBatchWriter(ctrl)
+			tt.prepare(s)
+
+			request := httptest.NewRequest("POST", "/api/shorten/batch"+tt.query, strings.NewReader(tt.body))
+			request = request.WithContext(context.WithValue(request.Context(), handler.ContextKeyUID{}, "test"))
+			w := httptest.NewRecorder()
+			BatchWriteHandler(s).ServeHTTP(w, request)
+
+			res := w.Result()
+			resBody, _ := ioutil.ReadAll(res.Body)
+			assert.Equal(t, tt.want.code, res.StatusCode)
+			assert.Equal(t, tt.want.body, string(resBody))
+			_ = res.Body.Close()
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"shortener/internal/app/handler"
+	"shortener/internal/app/service/store"
+	"time"
+)
+
+type UpdateURLRequest struct {
+	URL string `json:"url"`
+}
+
+type RollbackURLRequest struct {
+	Version int `json:"version"`
+}
+
+type URLVersionResponse struct {
+	Version     int       `json:"version"`
+	OriginalURL string    `json:"original_url"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type EditConflictResponse struct {
+	Error       string `json:"error"`
+	ExistingURL string `json:"existing_url"`
+}
+
+// UpdateURLHandler changes destination of the user link, the previous one is kept in the history.
+//
+//	curl -X PATCH -H "Content-Type: application/json" --cookie "uid=XXX" -d '{"url":"https://example.org/new"}' http://localhost:8080/api/user/urls/xxx
+//	{"version":2,"original_url":"https://example.org/new","created_at":"2022-05-03T10:00:00Z"}
+func UpdateURLHandler(s store.Editor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		reqObj := &UpdateURLRequest{}
+		if err := readBody(r, reqObj); err != nil {
+			writeError(w, err, http.StatusBadRequest)
+			return
+		}
+
+		uid := handler.ReadContextString(r.Context(), handler.ContextKeyUID{})
+		v, err := s.UpdateURL(r.Context(), uid, chi.URLParam(r, "id"), reqObj.URL)
+		if err != nil {
+			writeEditError(w, err)
+			return
+		}
+
+		writeResponse(w, newURLVersionResponse(*v), http.StatusOK)
+	}
+}
+
+// HistoryHandler lists destinations of the user link, the current one goes first.
+//
+//	curl -X GET --cookie "uid=XXX" http://localhost:8080/api/user/urls/xxx/history
+//	[{"version":2,"original_url":"https://example.org/new","created_at":"2022-05-03T10:00:00Z"},
+//	 {"version":1,"original_url":"https://example.org","created_at":"2022-05-01T10:00:00Z"}]
+func HistoryHandler(s store.Editor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		uid := handler.ReadContextString(r.Context(), handler.ContextKeyUID{})
+		history, err := s.ReadHistory(r.Context(), uid, chi.URLParam(r, "id"))
+		if err != nil {
+			writeEditError(w, err)
+			return
+		}
+
+		respObj := make([]URLVersionResponse, len(history))
+		for i, v := range history {
+			respObj[i] = newURLVersionResponse(v)
+		}
+
+		writeResponse(w, respObj, http.StatusOK)
+	}
+}
+
+// RollbackURLHandler sets destination of the previous version as the new version of the user link.
+//
+//	curl -X POST -H "Content-Type: application/json" --cookie "uid=XXX" -d '{"version":1}' http://localhost:8080/api/user/urls/xxx/rollback
+//	{"version":3,"original_url":"https://example.org","created_at":"2022-05-03T11:00:00Z"}
+func RollbackURLHandler(s store.Editor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		reqObj := &RollbackURLRequest{}
+		if err := readBody(r, reqObj); err != nil {
+			writeError(w, err, http.StatusBadRequest)
+			return
+		}
+
+		uid := handler.ReadContextString(r.Context(), handler.ContextKeyUID{})
+		v, err := s.RollbackURL(r.Context(), uid, chi.URLParam(r, "id"), reqObj.Version)
+		if err != nil {
+			writeEditError(w, err)
+			return
+		}
+
+		writeResponse(w, newURLVersionResponse(*v), http.StatusOK)
+	}
+}
+
+func newURLVersionResponse(v store.URLVersion) URLVersionResponse {
+	return URLVersionResponse{
+		Version:     v.Version,
+		OriginalURL: v.OriginalURL,
+		CreatedAt:   v.CreatedAt,
+	}
+}
+
+// writeEditError maps store errors of the link editing to the response status
+func writeEditError(w http.ResponseWriter, err error) {
+	var errConflict *store.ConflictError
+	switch {
+	case errors.As(err, &errConflict):
+		writeResponse(w, &EditConflictResponse{Error: err.Error(), ExistingURL: errConflict.ExistingURL}, http.StatusConflict)
+	case errors.Is(err, store.ErrNotFound):
+		writeError(w, err, http.StatusNotFound)
+	case errors.Is(err, store.ErrDeleted) || errors.Is(err, store.ErrExpired):
+		writeError(w, err, http.StatusGone)
+	case errors.Is(err, store.ErrForbiddenTarget):
+		writeError(w, err, http.StatusUnprocessableEntity)
+	case errors.Is(err, store.ErrBadInput):
+		writeError(w, err, http.StatusBadRequest)
+	default:
+		writeError(w, err, http.StatusInternalServerError)
+	}
+}
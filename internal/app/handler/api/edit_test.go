@@ -0,0 +1,160 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"shortener/internal/app/handler"
+	"shortener/internal/app/service/store"
+	storemock "shortener/internal/app/service/store/mock"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEditHandlers(t *testing.T) {
+	type want struct {
+		code int
+		body string
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	created := time.Date(2022, 5, 1, 10, 0, 0, 0, time.UTC)
+	updated := created.Add(time.Hour)
+	s := storemock.NewMockEditor(ctrl)
+	s.EXPECT().UpdateURL(gomock.Any(), "test", "abc", "https://example.org/b").
+		Return(&store.URLVersion{Version: 2, OriginalURL: "https://example.org/b", CreatedAt: updated}, nil)
+	s.EXPECT().UpdateURL(gomock.Any(), "test", "abc", "https://example.org/taken").
+		Return(nil, &store.ConflictError{ExistingURL: "http://localhost/abd"})
+	s.EXPECT().UpdateURL(gomock.Any(), "test", "gone", gomock.Any()).Return(nil, store.ErrDeleted)
+	s.EXPECT().UpdateURL(gomock.Any(), "test", "other", gomock.Any()).Return(nil, store.ErrNotFound)
+	s.EXPECT().ReadHistory(gomock.Any(), "test", "abc").Return([]store.URLVersion{
+		{Version: 2, OriginalURL: "https://example.org/b", CreatedAt: updated},
+		{Version: 1, OriginalURL: "https://example.org/a", CreatedAt: created},
+	}, nil)
+	s.EXPECT().RollbackURL(gomock.Any(), "test", "abc", 1).
+		Return(&store.URLVersion{Version: 3, OriginalURL: "https://example.org/a", CreatedAt: updated}, nil)
+	s.EXPECT().RollbackURL(gomock.Any(), "test", "abc", 9).Return(nil, store.ErrVersionNotFound)
+	s.EXPECT().RollbackURL(gomock.Any(), "test", "err", gomock.Any()).Return(nil, errors.New("internal"))
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		want   want
+	}{
+		{
+			"update ok",
+			http.MethodPatch,
+			"/api/user/urls/abc",
+			`{"url":"https://example.org/b"}`,
+			want{
+				code: http.StatusOK,
+				body: `{"version":2,"original_url":"https://example.org/b","created_at":"2022-05-01T11:00:00Z"}`,
+			},
+		},
+		{
+			"update conflict",
+			http.MethodPatch,
+			"/api/user/urls/abc",
+			`{"url":"https://example.org/taken"}`,
+			want{
+				code: http.StatusConflict,
+				body: `{"error":"conflict","existing_url":"http://localhost/abd"}`,
+			},
+		},
+		{
+			"update removed",
+			http.MethodPatch,
+			"/api/user/urls/gone",
+			`{"url":"https://example.org/c"}`,
+			want{
+				code: http.StatusGone,
+				body: `{"error":"deleted"}`,
+			},
+		},
+		{
+			"update not owned",
+			http.MethodPatch,
+			"/api/user/urls/other",
+			`{"url":"https://example.org/c"}`,
+			want{
+				code: http.StatusNotFound,
+				body: `{"error":"not found"}`,
+			},
+		},
+		{
+			"history",
+			http.MethodGet,
+			"/api/user/urls/abc/history",
+			"",
+			want{
+				code: http.StatusOK,
+				body: `[{"version":2,"original_url":"https://example.org/b","created_at":"2022-05-01T11:00:00Z"},` +
+					`{"version":1,"original_url":"https://example.org/a","created_at":"2022-05-01T10:00:00Z"}]`,
+			},
+		},
+		{
+			"rollback ok",
+			http.MethodPost,
+			"/api/user/urls/abc/rollback",
+			`{"version":1}`,
+			want{
+				code: http.StatusOK,
+				body: `{"version":3,"original_url":"https://example.org/a","created_at":"2022-05-01T11:00:00Z"}`,
+			},
+		},
+		{
+			"rollback missing version",
+			http.MethodPost,
+			"/api/user/urls/abc/rollback",
+			`{"version":9}`,
+			want{
+				code: http.StatusNotFound,
+				body: `{"error":"version: not found"}`,
+			},
+		},
+		{
+			"rollback 500",
+			http.MethodPost,
+			"/api/user/urls/err/rollback",
+			`{"version":1}`,
+			want{
+				code: http.StatusInternalServerError,
+				body: `{"error":"internal"}`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := chi.NewRouter()
+			r.Patch("/api/user/urls/{id}", UpdateURLHandler(s))
+			r.Get("/api/user/urls/{id}/history", HistoryHandler(s))
+			r.Post("/api/user/urls/{id}/rollback", RollbackURLHandler(s))
+
+			var body io.Reader
+			if tt.body != "" {
+				body = strings.NewReader(tt.body)
+			}
+			request := httptest.NewRequest(tt.method, tt.path, body)
+			request = request.WithContext(context.WithValue(request.Context(), handler.ContextKeyUID{}, "test"))
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, request)
+
+			res := w.Result()
+			resBody, _ := ioutil.ReadAll(res.Body)
+			assert.Equal(t, tt.want.code, res.StatusCode, "Body was: %s", resBody)
+			assert.Equal(t, tt.want.body, string(resBody))
+			_ = res.Body.Close()
+		})
+	}
+}
@@ -0,0 +1,274 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"shortener/internal/app/handler"
+	"shortener/internal/app/service/store"
+	"strings"
+	"time"
+)
+
+const (
+	contentTypeCSV  = "text/csv"
+	contentTypeJSON = "application/json"
+	// importChunkSize is a number of records written by a single store batch
+	importChunkSize = 500
+)
+
+// exportColumns of the csv export, import requires original_url column only
+var exportColumns = []string{"id", "short_url", "original_url", "created_at", "expires_at"}
+
+// ExportItem is a link in the export, import uses id as the custom alias
+type ExportItem struct {
+	ID          string     `json:"id"`
+	ShortURL    string     `json:"short_url,omitempty"`
+	OriginalURL string     `json:"original_url"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+type ImportResponseItem struct {
+	// Line is a number of the csv line or json array item starting from 1
+	Line        int    `json:"line"`
+	OriginalURL string `json:"original_url"`
+	ShortURL    string `json:"short_url,omitempty"`
+	// AliasReplaced is set if the imported id was taken, so the link got generated one
+	AliasReplaced bool `json:"alias_replaced,omitempty"`
+	// Code is set on failure, one of bad_input, conflict, alias_taken, forbidden_target or internal
+	Code  string `json:"code,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// ExportHandler returns all active links of the user in json, or in csv if it is requested by Accept header.
+//
+//	curl -X GET -H "Accept: text/csv" --cookie "uid=XXX" http://localhost:8080/api/user/urls/export
+//	id,short_url,original_url,created_at,expires_at
+//	xxy,http://localhost:8080/xxy,https://example.org,2022-05-05T10:00:00Z,
+func ExportHandler(s store.UserDataReader) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uid := handler.ReadContextString(r.Context(), handler.ContextKeyUID{})
+
+		// the first page is read before the response is started, so the store failure is reported with status
+		q := store.UserDataQuery{Limit: store.MaxPageLimit}
+		page, err := s.ReadUserData(r.Context(), uid, q)
+		if err != nil {
+			writeError(w, err, http.StatusInternalServerError)
+			return
+		}
+
+		enc := newExportEncoder(w, strings.Contains(r.Header.Get("Accept"), contentTypeCSV))
+		for {
+			for _, rec := range page.Records {
+				if err := enc.encode(exportItem(rec)); err != nil {
+					return
+				}
+			}
+			if page.NextCursor == "" {
+				break
+			}
+			q.Cursor = page.NextCursor
+			if page, err = s.ReadUserData(r.Context(), uid, q); err != nil {
+				// response is already started, so the broken export is signalled by the connection abort
+				panic(http.ErrAbortHandler)
+			}
+		}
+		enc.close()
+	}
+}
+
+// ImportHandler stores links in the format of ExportHandler, the format is chosen by Content-Type header.
+// Ids are kept as custom aliases where they are free, otherwise links get generated ids.
+// Failures are reported per item the same way as by BatchWriteHandler, status is 201 if all items are stored
+// and 207 otherwise.
+//
+//	curl -X POST -H "Content-Type: text/csv" --data-binary @links.csv --cookie "uid=XXX" http://localhost:8080/api/user/urls/import
+//	[{"line":1,"original_url":"https://example.org","short_url":"http://localhost:8080/xxy"}]
+func ImportHandler(s store.BatchWriter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentTypeJSON)
+
+		items, err := readImport(r)
+		if err != nil {
+			writeError(w, err, http.StatusBadRequest)
+			return
+		}
+
+		uid := handler.ReadContextString(r.Context(), handler.ContextKeyUID{})
+
+		statusCode := http.StatusCreated
+		respObj := make([]ImportResponseItem, 0, len(items))
+		for start := 0; start < len(items); start += importChunkSize {
+			chunk := items[start:min(start+importChunkSize, len(items))]
+			records := make([]store.Record, len(chunk))
+			for i, item := range chunk {
+				records[i] = store.Record{ID: item.ID, OriginalURL: item.OriginalURL, ExpiresAt: item.ExpiresAt}
+			}
+
+			written, err := store.Import(r.Context(), s, uid, records)
+			if err != nil {
+				writeError(w, err, http.StatusInternalServerError)
+				return
+			}
+
+			for i, rec := range written {
+				item := ImportResponseItem{
+					Line:        start + i + 1,
+					OriginalURL: chunk[i].OriginalURL,
+					ShortURL:    rec.ShortURL,
+				}
+				if rec.Err != nil {
+					item.Code = store.ItemErrorCode(rec.Err)
+					item.Error = rec.Err.Error()
+					statusCode = http.StatusMultiStatus
+				} else {
+					item.AliasReplaced = chunk[i].ID != "" && rec.ID != chunk[i].ID
+				}
+				respObj = append(respObj, item)
+			}
+		}
+
+		writeResponse(w, respObj, statusCode)
+	}
+}
+
+func exportItem(rec store.Record) ExportItem {
+	createdAt := rec.CreatedAt
+	return ExportItem{
+		ID:          rec.ID,
+		ShortURL:    rec.ShortURL,
+		OriginalURL: rec.OriginalURL,
+		CreatedAt:   &createdAt,
+		ExpiresAt:   rec.ExpiresAt,
+	}
+}
+
+// readImport reads json array or csv with header depending on the request content type
+func readImport(r *http.Request) ([]ExportItem, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != contentTypeCSV {
+		items := make([]ExportItem, 0)
+		if err := readBody(r, &items); err != nil {
+			return nil, err
+		}
+		return items, nil
+	}
+
+	defer func() {
+		_ = r.Body.Close()
+	}()
+	return readCSV(r.Body)
+}
+
+// readCSV reads items from csv with header, columns are matched by name
+func readCSV(r io.Reader) ([]ExportItem, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("csv header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+	if _, ok := columns["original_url"]; !ok {
+		return nil, errors.New("csv header: original_url column is required")
+	}
+
+	field := func(row []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	items := make([]ExportItem, 0)
+	for {
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return items, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("csv read: %w", err)
+		}
+
+		item := ExportItem{ID: field(row, "id"), OriginalURL: field(row, "original_url")}
+		if v := field(row, "expires_at"); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return nil, fmt.Errorf("csv line %d: expires_at must be in RFC 3339 format", len(items)+2)
+			}
+			item.ExpiresAt = &t
+		}
+		items = append(items, item)
+	}
+}
+
+// exportEncoder writes items as the json array or csv with header, the response is started by the constructor
+type exportEncoder struct {
+	w     io.Writer
+	csv   *csv.Writer
+	count int
+}
+
+func newExportEncoder(w http.ResponseWriter, csvFormat bool) *exportEncoder {
+	e := &exportEncoder{w: w}
+	if csvFormat {
+		w.Header().Set("Content-Type", contentTypeCSV)
+		w.Header().Set("Content-Disposition", `attachment; filename="urls.csv"`)
+		w.WriteHeader(http.StatusOK)
+		e.csv = csv.NewWriter(w)
+		_ = e.csv.Write(exportColumns)
+	} else {
+		w.Header().Set("Content-Type", contentTypeJSON)
+		w.WriteHeader(http.StatusOK)
+		_, _ = io.WriteString(w, "[")
+	}
+	return e
+}
+
+func (e *exportEncoder) encode(item ExportItem) error {
+	e.count++
+	if e.csv != nil {
+		return e.csv.Write([]string{item.ID, item.ShortURL, item.OriginalURL, formatTime(item.CreatedAt), formatTime(item.ExpiresAt)})
+	}
+
+	data, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	if e.count > 1 {
+		data = append([]byte(","), data...)
+	}
+	_, err = e.w.Write(data)
+	return err
+}
+
+func (e *exportEncoder) close() {
+	if e.csv != nil {
+		e.csv.Flush()
+		return
+	}
+	_, _ = io.WriteString(e.w, "]")
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,226 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"shortener/internal/app/handler"
+	"shortener/internal/app/service/store"
+	storemock "shortener/internal/app/service/store/mock"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExportHandler(t *testing.T) {
+	created := time.Date(2022, 5, 5, 10, 0, 0, 0, time.UTC)
+	expires := created.Add(time.Hour)
+	first := &store.UserDataPage{
+		Records:    []store.Record{{ID: "a", ShortURL: "http://short/a", OriginalURL: "https://example.org/a", CreatedAt: created}},
+		NextCursor: "next",
+	}
+	second := &store.UserDataPage{
+		Records: []store.Record{{ID: "b", ShortURL: "http://short/b", OriginalURL: "https://example.org/b?x=1,2", CreatedAt: created, ExpiresAt: &expires}},
+	}
+
+	type want struct {
+		code        int
+		contentType string
+		body        string
+	}
+	tests := []struct {
+		name    string
+		accept  string
+		prepare func(s *storemock.MockUserDataReader)
+		want    want
+	}{
+		{
+			"json",
+			"",
+			func(s *storemock.MockUserDataReader) {
+				s.EXPECT().ReadUserData(gomock.Any(), "test", store.UserDataQuery{Limit: store.MaxPageLimit}).Return(first, nil)
+				s.EXPECT().ReadUserData(gomock.Any(), "test", store.UserDataQuery{Limit: store.MaxPageLimit, Cursor: "next"}).Return(second, nil)
+			},
+			want{
+				code:        http.StatusOK,
+				contentType: "application/json",
+				body: `[{"id":"a","short_url":"http://short/a","original_url":"https://example.org/a","created_at":"2022-05-05T10:00:00Z"},` +
+					`{"id":"b","short_url":"http://short/b","original_url":"https://example.org/b?x=1,2","created_at":"2022-05-05T10:00:00Z","expires_at":"2022-05-05T11:00:00Z"}]`,
+			},
+		},
+		{
+			"csv",
+			"text/csv",
+			func(s *storemock.MockUserDataReader) {
+				s.EXPECT().ReadUserData(gomock.Any(), "test", gomock.Any()).Return(first, nil)
+				s.EXPECT().ReadUserData(gomock.Any(), "test", gomock.Any()).Return(second, nil)
+			},
+			want{
+				code:        http.StatusOK,
+				contentType: "text/csv",
+				body: "id,short_url,original_url,created_at,expires_at\n" +
+					"a,http://short/a,https://example.org/a,2022-05-05T10:00:00Z,\n" +
+					"b,http://short/b,\"https://example.org/b?x=1,2\",2022-05-05T10:00:00Z,2022-05-05T11:00:00Z\n",
+			},
+		},
+		{
+			"empty",
+			"application/json",
+			func(s *storemock.MockUserDataReader) {
+				s.EXPECT().ReadUserData(gomock.Any(), "test", gomock.Any()).Return(&store.UserDataPage{}, nil)
+			},
+			want{
+				code:        http.StatusOK,
+				contentType: "application/json",
+				body:        `[]`,
+			},
+		},
+		{
+			"store error",
+			"",
+			func(s *storemock.MockUserDataReader) {
+				s.EXPECT().ReadUserData(gomock.Any(), "test", gomock.Any()).Return(nil, errors.New("internal"))
+			},
+			want{
+				code: http.StatusInternalServerError,
+				body: `{"error":"internal"}`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s := storemock.NewMockUserDataReader(ctrl)
+			tt.prepare(s)
+
+			request := httptest.NewRequest("GET", "/api/user/urls/export", nil)
+			request.Header.Set("Accept", tt.accept)
+			request = request.WithContext(context.WithValue(request.Context(), handler.ContextKeyUID{}, "test"))
+			w := httptest.NewRecorder()
+			ExportHandler(s).ServeHTTP(w, request)
+
+			res := w.Result()
+			resBody, _ := ioutil.ReadAll(res.Body)
+			assert.Equal(t, tt.want.code, res.StatusCode)
+			if tt.want.contentType != "" {
+				assert.Equal(t, tt.want.contentType, res.Header.Get("Content-Type"))
+			}
+			assert.Equal(t, tt.want.body, string(resBody))
+			_ = res.Body.Close()
+		})
+	}
+}
+
+func TestImportHandler(t *testing.T) {
+	type want struct {
+		code int
+		body string
+	}
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		prepare     func(s *storemock.MockBatchWriter)
+		want        want
+	}{
+		{
+			"csv",
+			"text/csv; charset=utf-8",
+			"original_url,id,expires_at\nhttps://example.org/a,a,\nhttps://example.org/b,taken,\nhttps://example.org/c,,2022-05-05T10:00:00Z\n",
+			func(s *storemock.MockBatchWriter) {
+				expires := time.Date(2022, 5, 5, 10, 0, 0, 0, time.UTC)
+				gomock.InOrder(
+					s.EXPECT().BatchWrite(gomock.Any(), "test", []store.Record{
+						{ID: "a", OriginalURL: "https://example.org/a"},
+						{ID: "taken", OriginalURL: "https://example.org/b"},
+						{OriginalURL: "https://example.org/c", ExpiresAt: &expires},
+					}).Return([]store.Record{
+						{ID: "a", OriginalURL: "https://example.org/a", ShortURL: "http://short/a"},
+						{ID: "taken", OriginalURL: "https://example.org/b", Err: store.ErrAliasTaken},
+						{OriginalURL: "https://example.org/c", Err: store.ErrBadInput},
+					}, nil),
+					s.EXPECT().BatchWrite(gomock.Any(), "test", []store.Record{{OriginalURL: "https://example.org/b"}}).
+						Return([]store.Record{{ID: "gen", OriginalURL: "https://example.org/b", ShortURL: "http://short/gen"}}, nil),
+				)
+			},
+			want{
+				code: http.StatusMultiStatus,
+				body: `[{"line":1,"original_url":"https://example.org/a","short_url":"http://short/a"},` +
+					`{"line":2,"original_url":"https://example.org/b","short_url":"http://short/gen","alias_replaced":true},` +
+					`{"line":3,"original_url":"https://example.org/c","code":"bad_input","error":"bad input"}]`,
+			},
+		},
+		{
+			"json",
+			"application/json",
+			`[{"id":"a","short_url":"http://old/a","original_url":"https://example.org/a","created_at":"2022-05-05T10:00:00Z"}]`,
+			func(s *storemock.MockBatchWriter) {
+				s.EXPECT().BatchWrite(gomock.Any(), "test", []store.Record{{ID: "a", OriginalURL: "https://example.org/a"}}).
+					Return([]store.Record{{ID: "a", OriginalURL: "https://example.org/a", ShortURL: "http://short/a"}}, nil)
+			},
+			want{
+				code: http.StatusCreated,
+				body: `[{"line":1,"original_url":"https://example.org/a","short_url":"http://short/a"}]`,
+			},
+		},
+		{
+			"csv without url column",
+			"text/csv",
+			"id\na\n",
+			func(s *storemock.MockBatchWriter) {},
+			want{
+				code: http.StatusBadRequest,
+				body: `{"error":"csv header: original_url column is required"}`,
+			},
+		},
+		{
+			"csv bad expiration",
+			"text/csv",
+			"original_url,expires_at\nhttps://example.org/a,tomorrow\n",
+			func(s *storemock.MockBatchWriter) {},
+			want{
+				code: http.StatusBadRequest,
+				body: `{"error":"csv line 2: expires_at must be in RFC 3339 format"}`,
+			},
+		},
+		{
+			"store error",
+			"application/json",
+			`[{"original_url":"https://example.org/a"}]`,
+			func(s *storemock.MockBatchWriter) {
+				s.EXPECT().BatchWrite(gomock.Any(), "test", gomock.Any()).Return(nil, errors.New("internal"))
+			},
+			want{
+				code: http.StatusInternalServerError,
+				body: `{"error":"internal"}`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s := storemock.NewMockBatchWriter(ctrl)
+			tt.prepare(s)
+
+			request := httptest.NewRequest("POST", "/api/user/urls/import", strings.NewReader(tt.body))
+			request.Header.Set("Content-Type", tt.contentType)
+			request = request.WithContext(context.WithValue(request.Context(), handler.ContextKeyUID{}, "test"))
+			w := httptest.NewRecorder()
+			ImportHandler(s).ServeHTTP(w, request)
+
+			res := w.Result()
+			resBody, _ := ioutil.ReadAll(res.Body)
+			assert.Equal(t, tt.want.code, res.StatusCode)
+			assert.Equal(t, tt.want.body, string(resBody))
+			_ = res.Body.Close()
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"shortener/internal/app/handler"
+	"shortener/internal/app/service/store"
+	"strconv"
+)
+
+const (
+	defaultStatDays = 30
+	maxStatDays     = 365
+)
+
+type LinkStatResponse struct {
+	ID             string              `json:"id"`
+	ShortURL       string              `json:"short_url"`
+	TotalClicks    int                 `json:"total_clicks"`
+	UniqueVisitors int                 `json:"unique_visitors"`
+	Daily          []LinkStatDailyItem `json:"daily"`
+}
+
+type LinkStatDailyItem struct {
+	Date   string `json:"date"`
+	Clicks int    `json:"clicks"`
+}
+
+// LinkStatHandler returns clicks of the link owned by user, optional days param limits daily series (30 by default).
+//
+//	curl -X GET --cookie "uid=XXX" http://localhost:8080/api/user/urls/xxx/stats?days=7
+//	{"id":"xxx","short_url":"http://localhost:8080/xxx","total_clicks":3,"unique_visitors":2,"daily":[{"date":"2022-04-25","clicks":3}]}
+func LinkStatHandler(s store.LinkStatReader) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		days := defaultStatDays
+		if v := r.URL.Query().Get("days"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > maxStatDays {
+				writeError(w, fmt.Errorf("days must be in range 1..%d: %w", maxStatDays, store.ErrBadInput), http.StatusBadRequest)
+				return
+			}
+			days = n
+		}
+
+		uid := handler.ReadContextString(r.Context(), handler.ContextKeyUID{})
+		stat, err := s.ReadLinkStat(r.Context(), uid, chi.URLParam(r, "id"), days)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				writeError(w, err, http.StatusNotFound)
+			} else if errors.Is(err, store.ErrBadInput) {
+				writeError(w, err, http.StatusBadRequest)
+			} else {
+				writeError(w, err, http.StatusInternalServerError)
+			}
+			return
+		}
+
+		respObj := &LinkStatResponse{
+			ID:             stat.ID,
+			ShortURL:       stat.ShortURL,
+			TotalClicks:    stat.TotalClicks,
+			UniqueVisitors: stat.UniqueVisitors,
+			Daily:          make([]LinkStatDailyItem, len(stat.Daily)),
+		}
+		for i, d := range stat.Daily {
+			respObj.Daily[i] = LinkStatDailyItem{
+				Date:   d.Date.Format("2006-01-02"),
+				Clicks: d.Clicks,
+			}
+		}
+
+		writeResponse(w, respObj, http.StatusOK)
+	}
+}
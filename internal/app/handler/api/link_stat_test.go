@@ -0,0 +1,89 @@
+package api
+
+import (
+	"context"
+	"github.com/go-chi/chi/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"shortener/internal/app/handler"
+	"shortener/internal/app/service/store"
+	storemock "shortener/internal/app/service/store/mock"
+	"testing"
+	"time"
+)
+
+func TestLinkStatHandler(t *testing.T) {
+	type want struct {
+		code int
+		body string
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	day := time.Date(2022, 4, 25, 0, 0, 0, 0, time.UTC)
+	s := storemock.NewMockBackend(ctrl)
+	s.EXPECT().ReadLinkStat(gomock.Any(), "test", "abc", 2).Return(&store.LinkStat{
+		ID:             "abc",
+		ShortURL:       "http://localhost/abc",
+		TotalClicks:    3,
+		UniqueVisitors: 2,
+		Daily: []store.DailyClicks{
+			{Date: day.AddDate(0, 0, -1), Clicks: 1},
+			{Date: day, Clicks: 2},
+		},
+	}, nil)
+	s.EXPECT().ReadLinkStat(gomock.Any(), "test", "other", defaultStatDays).Return(nil, store.ErrNotFound)
+
+	tests := []struct {
+		name string
+		path string
+		want want
+	}{
+		{
+			"stat ok",
+			"/api/user/urls/abc/stats?days=2",
+			want{
+				code: http.StatusOK,
+				body: `{"id":"abc","short_url":"http://localhost/abc","total_clicks":3,"unique_visitors":2,` +
+					`"daily":[{"date":"2022-04-24","clicks":1},{"date":"2022-04-25","clicks":2}]}`,
+			},
+		},
+		{
+			"stat not owned",
+			"/api/user/urls/other/stats",
+			want{
+				code: http.StatusNotFound,
+				body: `{"error":"not found"}`,
+			},
+		},
+		{
+			"stat bad days",
+			"/api/user/urls/abc/stats?days=1000",
+			want{
+				code: http.StatusBadRequest,
+				body: `{"error":"days must be in range 1..365: bad input"}`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := chi.NewRouter()
+			r.Get("/api/user/urls/{id}/stats", LinkStatHandler(s))
+
+			request := httptest.NewRequest("GET", tt.path, nil)
+			request = request.WithContext(context.WithValue(request.Context(), handler.ContextKeyUID{}, "test"))
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, request)
+
+			res := w.Result()
+			resBody, _ := ioutil.ReadAll(res.Body)
+			assert.Equal(t, tt.want.code, res.StatusCode, "Body was: %s", resBody)
+			assert.Equal(t, tt.want.body, string(resBody))
+			_ = res.Body.Close()
+		})
+	}
+}
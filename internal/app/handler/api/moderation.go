@@ -0,0 +1,122 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"net/http"
+	"shortener/internal/app/service/store"
+	"strconv"
+	"time"
+)
+
+type AbuseReportResponse struct {
+	ID        int64     `json:"id"`
+	LinkID    string    `json:"link_id"`
+	Reason    string    `json:"reason"`
+	Comment   string    `json:"comment,omitempty"`
+	Reporter  string    `json:"reporter"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ReportsHandler lists the open abuse reports, the oldest first. Optional link_id param selects reports of one link,
+// after and limit params select the page.
+//
+//	curl -X GET http://localhost:8080/api/internal/reports?after=10&limit=50
+//	[{"id":11,"link_id":"xxx","reason":"spam","reporter":"10.0.0.1","created_at":"2022-05-09T10:00:00Z"}]
+func ReportsHandler(s store.Moderator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		q := store.ReportQuery{LinkID: r.URL.Query().Get("link_id")}
+		var err error
+		if v := r.URL.Query().Get("after"); v != "" {
+			if q.After, err = strconv.ParseInt(v, 10, 64); err != nil {
+				writeError(w, fmt.Errorf("after must be a number: %w", store.ErrBadInput), http.StatusBadRequest)
+				return
+			}
+		}
+		if v := r.URL.Query().Get("limit"); v != "" {
+			if q.Limit, err = strconv.Atoi(v); err != nil {
+				writeError(w, fmt.Errorf("limit must be a number: %w", store.ErrBadInput), http.StatusBadRequest)
+				return
+			}
+		}
+
+		reports, err := s.ReadReports(r.Context(), q)
+		if err != nil {
+			if errors.Is(err, store.ErrBadInput) {
+				writeError(w, err, http.StatusBadRequest)
+			} else {
+				writeError(w, err, http.StatusInternalServerError)
+			}
+			return
+		}
+
+		respObj := make([]AbuseReportResponse, len(reports))
+		for i, report := range reports {
+			respObj[i] = AbuseReportResponse{
+				ID:        report.ID,
+				LinkID:    report.LinkID,
+				Reason:    string(report.Reason),
+				Comment:   report.Comment,
+				Reporter:  report.Reporter,
+				CreatedAt: report.CreatedAt,
+			}
+		}
+
+		writeResponse(w, respObj, http.StatusOK)
+	}
+}
+
+// DisableURLHandler disables or enables the link, open reports of the link are resolved.
+// Disabled link is unavailable for legal reasons.
+//
+//	curl -X POST http://localhost:8080/api/internal/urls/xxx/disable
+//	curl -X POST http://localhost:8080/api/internal/urls/xxx/enable
+func DisableURLHandler(s store.Moderator, disabled bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if err := s.SetDisabled(r.Context(), chi.URLParam(r, "id"), disabled); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				writeError(w, err, http.StatusNotFound)
+			} else if errors.Is(err, store.ErrBadInput) {
+				writeError(w, err, http.StatusBadRequest)
+			} else {
+				writeError(w, err, http.StatusInternalServerError)
+			}
+			return
+		}
+
+		writeResponse(w, nil, http.StatusNoContent)
+	}
+}
+
+// BanUserHandler bans or unbans the user, all links of the banned user are disabled including the ones written later.
+//
+//	curl -X PUT http://localhost:8080/api/internal/users/XXX/ban
+//	curl -X DELETE http://localhost:8080/api/internal/users/XXX/ban
+func BanUserHandler(s store.Moderator, banned bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		uid := chi.URLParam(r, "uid")
+		if _, err := uuid.Parse(uid); err != nil {
+			writeError(w, fmt.Errorf("invalid uid: %w", store.ErrBadInput), http.StatusBadRequest)
+			return
+		}
+
+		if err := s.SetBanned(r.Context(), uid, banned); err != nil {
+			if errors.Is(err, store.ErrBadInput) {
+				writeError(w, err, http.StatusBadRequest)
+			} else {
+				writeError(w, err, http.StatusInternalServerError)
+			}
+			return
+		}
+
+		writeResponse(w, nil, http.StatusNoContent)
+	}
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"shortener/internal/app/service/store"
+	storemock "shortener/internal/app/service/store/mock"
+	"testing"
+	"time"
+)
+
+func TestModerationHandlers(t *testing.T) {
+	type want struct {
+		code int
+		body string
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	const uid = "8a1ab1d4-7a48-4bd0-9e07-1b3f4f1c2a11"
+	created := time.Date(2022, 5, 9, 10, 0, 0, 0, time.UTC)
+	s := storemock.NewMockModerator(ctrl)
+	s.EXPECT().ReadReports(gomock.Any(), store.ReportQuery{LinkID: "abc", After: 10, Limit: 2}).Return([]store.AbuseReport{
+		{ID: 11, LinkID: "abc", Reason: store.ReasonSpam, Reporter: "10.0.0.1", CreatedAt: created},
+	}, nil)
+	s.EXPECT().SetDisabled(gomock.Any(), "abc", true).Return(nil)
+	s.EXPECT().SetDisabled(gomock.Any(), "abc", false).Return(nil)
+	s.EXPECT().SetDisabled(gomock.Any(), "missing", true).Return(store.ErrNotFound)
+	s.EXPECT().SetBanned(gomock.Any(), uid, true).Return(nil)
+	s.EXPECT().SetBanned(gomock.Any(), uid, false).Return(nil)
+
+	r := chi.NewRouter()
+	r.Get("/api/internal/reports", ReportsHandler(s))
+	r.Post("/api/internal/urls/{id}/disable", DisableURLHandler(s, true))
+	r.Post("/api/internal/urls/{id}/enable", DisableURLHandler(s, false))
+	r.Put("/api/internal/users/{uid}/ban", BanUserHandler(s, true))
+	r.Delete("/api/internal/users/{uid}/ban", BanUserHandler(s, false))
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		want   want
+	}{
+		{
+			"reports ok",
+			"GET",
+			"/api/internal/reports?link_id=abc&after=10&limit=2",
+			want{
+				code: http.StatusOK,
+				body: `[{"id":11,"link_id":"abc","reason":"spam","reporter":"10.0.0.1","created_at":"2022-05-09T10:00:00Z"}]`,
+			},
+		},
+		{
+			"reports bad after",
+			"GET",
+			"/api/internal/reports?after=x",
+			want{
+				code: http.StatusBadRequest,
+				body: `{"error":"after must be a number: bad input"}`,
+			},
+		},
+		{
+			"disable ok",
+			"POST",
+			"/api/internal/urls/abc/disable",
+			want{code: http.StatusNoContent},
+		},
+		{
+			"enable ok",
+			"POST",
+			"/api/internal/urls/abc/enable",
+			want{code: http.StatusNoContent},
+		},
+		{
+			"disable missing",
+			"POST",
+			"/api/internal/urls/missing/disable",
+			want{
+				code: http.StatusNotFound,
+				body: `{"error":"not found"}`,
+			},
+		},
+		{
+			"ban ok",
+			"PUT",
+			"/api/internal/users/" + uid + "/ban",
+			want{code: http.StatusNoContent},
+		},
+		{
+			"unban ok",
+			"DELETE",
+			"/api/internal/users/" + uid + "/ban",
+			want{code: http.StatusNoContent},
+		},
+		{
+			"ban invalid uid",
+			"PUT",
+			"/api/internal/users/xxx/ban",
+			want{
+				code: http.StatusBadRequest,
+				body: `{"error":"invalid uid: bad input"}`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(tt.method, tt.path, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, request)
+
+			res := w.Result()
+			resBody, _ := ioutil.ReadAll(res.Body)
+			assert.Equal(t, tt.want.code, res.StatusCode, "Body was: %s", resBody)
+			assert.Equal(t, tt.want.body, string(resBody))
+			_ = res.Body.Close()
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"shortener/internal/app/handler"
+	"shortener/internal/app/service/store"
+	"time"
+)
+
+type OperationResponse struct {
+	ID          string                  `json:"id"`
+	Status      string                  `json:"status"`
+	CreatedAt   time.Time               `json:"created_at"`
+	CompletedAt *time.Time              `json:"completed_at,omitempty"`
+	Error       string                  `json:"error,omitempty"`
+	Items       []OperationResponseItem `json:"items"`
+}
+
+type OperationResponseItem struct {
+	ID      string `json:"id"`
+	Outcome string `json:"outcome"`
+}
+
+// OperationHandler returns state of the user operation with per id outcomes.
+//
+//	curl -X GET --cookie "uid=XXX" http://localhost:8080/api/user/operations/1
+//	{"id":"1","status":"completed","created_at":"2022-05-01T10:00:00Z","completed_at":"2022-05-01T10:00:01Z",
+//	 "items":[{"id":"xxx","outcome":"deleted"},{"id":"xxy","outcome":"not_found"}]}
+func OperationHandler(s store.OperationReader) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		uid := handler.ReadContextString(r.Context(), handler.ContextKeyUID{})
+		op, err := s.ReadOperation(r.Context(), uid, chi.URLParam(r, "id"))
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				writeError(w, err, http.StatusNotFound)
+			} else {
+				writeError(w, err, http.StatusInternalServerError)
+			}
+			return
+		}
+
+		respObj := &OperationResponse{
+			ID:          op.ID,
+			Status:      string(op.Status),
+			CreatedAt:   op.CreatedAt,
+			CompletedAt: op.CompletedAt,
+			Error:       op.Error,
+			Items:       make([]OperationResponseItem, len(op.Items)),
+		}
+		for i, item := range op.Items {
+			respObj.Items[i] = OperationResponseItem{
+				ID:      item.ID,
+				Outcome: string(item.Outcome),
+			}
+		}
+
+		writeResponse(w, respObj, http.StatusOK)
+	}
+}
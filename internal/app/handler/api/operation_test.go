@@ -0,0 +1,106 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"shortener/internal/app/handler"
+	"shortener/internal/app/service/store"
+	storemock "shortener/internal/app/service/store/mock"
+	"testing"
+	"time"
+)
+
+func TestOperationHandler(t *testing.T) {
+	type want struct {
+		code int
+		body string
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	created := time.Date(2022, 5, 1, 10, 0, 0, 0, time.UTC)
+	completed := created.Add(time.Second)
+	s := storemock.NewMockOperationReader(ctrl)
+	s.EXPECT().ReadOperation(gomock.Any(), "test", "1").Return(&store.Operation{
+		ID:          "1",
+		Status:      store.OperationCompleted,
+		CreatedAt:   created,
+		CompletedAt: &completed,
+		Items: []store.OperationItem{
+			{ID: "abc", Outcome: store.OutcomeDeleted},
+			{ID: "abd", Outcome: store.OutcomeNotFound},
+		},
+	}, nil)
+	s.EXPECT().ReadOperation(gomock.Any(), "test", "2").Return(&store.Operation{
+		ID:        "2",
+		Status:    store.OperationPending,
+		CreatedAt: created,
+		Items:     []store.OperationItem{{ID: "abc", Outcome: store.OutcomePending}},
+	}, nil)
+	s.EXPECT().ReadOperation(gomock.Any(), "test", "3").Return(nil, store.ErrNotFound)
+	s.EXPECT().ReadOperation(gomock.Any(), "test", "4").Return(nil, errors.New("internal"))
+
+	tests := []struct {
+		name string
+		path string
+		want want
+	}{
+		{
+			"completed",
+			"/api/user/operations/1",
+			want{
+				code: http.StatusOK,
+				body: `{"id":"1","status":"completed","created_at":"2022-05-01T10:00:00Z","completed_at":"2022-05-01T10:00:01Z",` +
+					`"items":[{"id":"abc","outcome":"deleted"},{"id":"abd","outcome":"not_found"}]}`,
+			},
+		},
+		{
+			"pending",
+			"/api/user/operations/2",
+			want{
+				code: http.StatusOK,
+				body: `{"id":"2","status":"pending","created_at":"2022-05-01T10:00:00Z","items":[{"id":"abc","outcome":"pending"}]}`,
+			},
+		},
+		{
+			"not found",
+			"/api/user/operations/3",
+			want{
+				code: http.StatusNotFound,
+				body: `{"error":"not found"}`,
+			},
+		},
+		{
+			"internal error",
+			"/api/user/operations/4",
+			want{
+				code: http.StatusInternalServerError,
+				body: `{"error":"internal"}`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := chi.NewRouter()
+			r.Get("/api/user/operations/{id}", OperationHandler(s))
+
+			request := httptest.NewRequest("GET", tt.path, nil)
+			request = request.WithContext(context.WithValue(request.Context(), handler.ContextKeyUID{}, "test"))
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, request)
+
+			res := w.Result()
+			resBody, _ := ioutil.ReadAll(res.Body)
+			assert.Equal(t, tt.want.code, res.StatusCode, "Body was: %s", resBody)
+			assert.Equal(t, tt.want.body, string(resBody))
+			_ = res.Body.Close()
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"net"
+	"net/http"
+	"shortener/internal/app/handler"
+	"shortener/internal/app/service/store"
+	"time"
+)
+
+type ReportRequest struct {
+	Reason  string `json:"reason"`
+	Comment string `json:"comment"`
+}
+
+type ReportResponse struct {
+	ID        int64     `json:"id"`
+	LinkID    string    `json:"link_id"`
+	Reason    string    `json:"reason"`
+	Comment   string    `json:"comment,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ReportHandler saves the abuse report of the link, reason is one of phishing, malware, spam, illegal or other.
+// The reporter is the remote address, X-Real-IP is honoured only for the requests sent by the trustedProxies.
+//
+//	curl -X POST -H "Content-Type: application/json" -d '{"reason":"phishing","comment":"asks for a password"}' http://localhost:8080/api/report/xxx
+//	{"id":1,"link_id":"xxx","reason":"phishing","comment":"asks for a password","created_at":"2022-05-09T10:00:00Z"}
+func ReportHandler(s store.Moderator, trustedProxies []*net.IPNet) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		reqObj := &ReportRequest{}
+		if err := readBody(r, reqObj); err != nil {
+			writeError(w, err, http.StatusBadRequest)
+			return
+		}
+
+		report, err := s.Report(r.Context(), store.AbuseReport{
+			LinkID:   chi.URLParam(r, "id"),
+			Reason:   store.ReportReason(reqObj.Reason),
+			Comment:  reqObj.Comment,
+			Reporter: handler.PeerIP(r, trustedProxies),
+		})
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				writeError(w, err, http.StatusNotFound)
+			} else if errors.Is(err, store.ErrBadInput) {
+				writeError(w, err, http.StatusBadRequest)
+			} else {
+				writeError(w, err, http.StatusInternalServerError)
+			}
+			return
+		}
+
+		writeResponse(w, &ReportResponse{
+			ID:        report.ID,
+			LinkID:    report.LinkID,
+			Reason:    string(report.Reason),
+			Comment:   report.Comment,
+			CreatedAt: report.CreatedAt,
+		}, http.StatusCreated)
+	}
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"shortener/internal/app/service/store"
+	storemock "shortener/internal/app/service/store/mock"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReportHandler(t *testing.T) {
+	type want struct {
+		code int
+		body string
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	created := time.Date(2022, 5, 9, 10, 0, 0, 0, time.UTC)
+	s := storemock.NewMockModerator(ctrl)
+	s.EXPECT().Report(gomock.Any(), store.AbuseReport{LinkID: "abc", Reason: store.ReasonPhishing, Comment: "password", Reporter: "10.0.0.1"}).
+		Return(&store.AbuseReport{ID: 1, LinkID: "abc", Reason: store.ReasonPhishing, Comment: "password", Reporter: "10.0.0.1", CreatedAt: created}, nil)
+	s.EXPECT().Report(gomock.Any(), store.AbuseReport{LinkID: "missing", Reason: store.ReasonSpam, Reporter: "10.0.0.1"}).
+		Return(nil, store.ErrNotFound)
+	s.EXPECT().Report(gomock.Any(), store.AbuseReport{LinkID: "abc", Reason: "boring", Reporter: "10.0.0.1"}).
+		Return(nil, store.ErrBadInput)
+	s.EXPECT().Report(gomock.Any(), store.AbuseReport{LinkID: "abc", Reason: store.ReasonSpam, Reporter: "198.51.100.1"}).
+		Return(&store.AbuseReport{ID: 2, LinkID: "abc", Reason: store.ReasonSpam, Reporter: "198.51.100.1", CreatedAt: created}, nil)
+	// httptest requests are sent from 192.0.2.1
+	_, proxies, _ := net.ParseCIDR("192.0.2.0/24")
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		path       string
+		body       string
+		want       want
+	}{
+		{
+			"report ok",
+			"192.0.2.1:1234",
+			"/api/report/abc",
+			`{"reason":"phishing","comment":"password"}`,
+			want{
+				code: http.StatusCreated,
+				body: `{"id":1,"link_id":"abc","reason":"phishing","comment":"password","created_at":"2022-05-09T10:00:00Z"}`,
+			},
+		},
+		{
+			"report missing",
+			"192.0.2.1:1234",
+			"/api/report/missing",
+			`{"reason":"spam"}`,
+			want{
+				code: http.StatusNotFound,
+				body: `{"error":"not found"}`,
+			},
+		},
+		{
+			"report bad reason",
+			"192.0.2.1:1234",
+			"/api/report/abc",
+			`{"reason":"boring"}`,
+			want{
+				code: http.StatusBadRequest,
+				body: `{"error":"bad input"}`,
+			},
+		},
+		{
+			"report bad body",
+			"192.0.2.1:1234",
+			"/api/report/abc",
+			`{`,
+			want{
+				code: http.StatusBadRequest,
+				body: `{"error":"json decode: unexpected end of JSON input"}`,
+			},
+		},
+		{
+			"report from untrusted address ignores X-Real-IP",
+			"198.51.100.1:1234",
+			"/api/report/abc",
+			`{"reason":"spam"}`,
+			want{
+				code: http.StatusCreated,
+				body: `{"id":2,"link_id":"abc","reason":"spam","created_at":"2022-05-09T10:00:00Z"}`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := chi.NewRouter()
+			r.Post("/api/report/{id}", ReportHandler(s, []*net.IPNet{proxies}))
+
+			request := httptest.NewRequest("POST", tt.path, strings.NewReader(tt.body))
+			request.RemoteAddr = tt.remoteAddr
+			request.Header.Set("X-Real-IP", "10.0.0.1")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, request)
+
+			res := w.Result()
+			resBody, _ := ioutil.ReadAll(res.Body)
+			assert.Equal(t, tt.want.code, res.StatusCode, "Body was: %s", resBody)
+			assert.Equal(t, tt.want.body, string(resBody))
+			_ = res.Body.Close()
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"shortener/internal/app/handler"
+	"shortener/internal/app/service/store"
+)
+
+// RestoreHandler restores recently removed urls, outcome is returned for every id.
+//
+//	curl -X POST -H "Content-Type: application/json" --cookie "uid=XXX" -d '["xxx", "xxy"]' http://localhost:8080/api/user/urls/restore
+//	[{"id":"xxx","outcome":"restored"},{"id":"xxy","outcome":"conflict"}]
+func RestoreHandler(s store.Restorer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		req := make([]string, 0)
+		if err := readBody(r, &req); err != nil {
+			writeError(w, err, http.StatusBadRequest)
+			return
+		}
+
+		uid := handler.ReadContextString(r.Context(), handler.ContextKeyUID{})
+		items, err := s.Restore(r.Context(), uid, req...)
+		if err != nil {
+			if errors.Is(err, store.ErrBadInput) {
+				writeError(w, err, http.StatusBadRequest)
+			} else {
+				writeError(w, err, http.StatusInternalServerError)
+			}
+			return
+		}
+
+		respObj := make([]OperationResponseItem, len(items))
+		for i, item := range items {
+			respObj[i] = OperationResponseItem{
+				ID:      item.ID,
+				Outcome: string(item.Outcome),
+			}
+		}
+
+		writeResponse(w, respObj, http.StatusOK)
+	}
+}
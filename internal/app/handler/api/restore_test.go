@@ -0,0 +1,87 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"shortener/internal/app/handler"
+	"shortener/internal/app/service/store"
+	storemock "shortener/internal/app/service/store/mock"
+	"strings"
+	"testing"
+)
+
+func TestRestoreHandler(t *testing.T) {
+	type want struct {
+		code int
+		body string
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s := storemock.NewMockRestorer(ctrl)
+	s.EXPECT().Restore(gomock.Any(), "test", "abc", "abd").Return([]store.OperationItem{
+		{ID: "abc", Outcome: store.OutcomeRestored},
+		{ID: "abd", Outcome: store.OutcomeConflict},
+	}, nil)
+	s.EXPECT().Restore(gomock.Any(), "test").Return(nil, store.ErrEmptyIDs)
+	s.EXPECT().Restore(gomock.Any(), "test", "err").Return(nil, errors.New("internal"))
+
+	tests := []struct {
+		name string
+		body string
+		want want
+	}{
+		{
+			"restore ok",
+			`["abc","abd"]`,
+			want{
+				code: http.StatusOK,
+				body: `[{"id":"abc","outcome":"restored"},{"id":"abd","outcome":"conflict"}]`,
+			},
+		},
+		{
+			"restore empty",
+			`[]`,
+			want{
+				code: http.StatusBadRequest,
+				body: `{"error":"empty ids: bad input"}`,
+			},
+		},
+		{
+			"restore malformed",
+			`{`,
+			want{
+				code: http.StatusBadRequest,
+				body: `{"error":"json decode: unexpected end of JSON input"}`,
+			},
+		},
+		{
+			"restore 500",
+			`["err"]`,
+			want{
+				code: http.StatusInternalServerError,
+				body: `{"error":"internal"}`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, "/api/user/urls/restore", strings.NewReader(tt.body))
+			request = request.WithContext(context.WithValue(request.Context(), handler.ContextKeyUID{}, "test"))
+			w := httptest.NewRecorder()
+			RestoreHandler(s).ServeHTTP(w, request)
+
+			res := w.Result()
+			resBody, _ := ioutil.ReadAll(res.Body)
+			assert.Equal(t, tt.want.code, res.StatusCode, "Body was: %s", resBody)
+			assert.Equal(t, tt.want.body, string(resBody))
+			_ = res.Body.Close()
+		})
+	}
+}
@@ -17,7 +17,7 @@ func StatHandler(s store.StatProvider) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 
-		res, err := s.Stat()
+		res, err := s.Stat(r.Context())
 		if err != nil {
 			writeError(w, err, http.StatusInternalServerError)
 			return
@@ -1,35 +1,68 @@
 package api
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
 	"shortener/internal/app/handler"
 	"shortener/internal/app/service/store"
+	"strconv"
+	"time"
 )
 
 type UserDataResponse []UserDataItem
 
 type UserDataItem struct {
-	ShortURL    string `json:"short_url"`
-	OriginalURL string `json:"original_url"`
+	ShortURL    string     `json:"short_url"`
+	OriginalURL string     `json:"original_url"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
 }
 
-// UserDataHandler returns multiple urls owned by user.
+// UserDataHandler returns a page of urls owned by user.
+// Optional query params: limit (100 by default), cursor, created_from and created_to in RFC 3339 format,
+// url_contains and sort (created_at, -created_at, original_url, -original_url).
+// Link header with rel="next" points at the next page if there is one.
 //
-//	curl -X GET -H "Content-Type: application/json" --cookie "uid=XXX" http://localhost:8080/api/user/urls
+//	curl -X GET -H "Content-Type: application/json" --cookie "uid=XXX" "http://localhost:8080/api/user/urls?limit=2&sort=-created_at"
+//	Link: </api/user/urls?cursor=XXX&limit=2&sort=-created_at>; rel="next"
 func UserDataHandler(s store.UserDataReader) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 
-		rows := s.ReadUserData(handler.ReadContextString(r.Context(), handler.ContextKeyUID{}))
-		respObj := make(UserDataResponse, len(rows))
+		q, err := parseUserDataQuery(r.URL.Query())
+		if err != nil {
+			writeError(w, err, http.StatusBadRequest)
+			return
+		}
 
-		for i, row := range rows {
+		page, err := s.ReadUserData(r.Context(), handler.ReadContextString(r.Context(), handler.ContextKeyUID{}), q)
+		if err != nil {
+			if errors.Is(err, store.ErrBadInput) {
+				writeError(w, err, http.StatusBadRequest)
+			} else {
+				writeError(w, err, http.StatusInternalServerError)
+			}
+			return
+		}
+
+		respObj := make(UserDataResponse, len(page.Records))
+		for i, row := range page.Records {
 			respObj[i] = UserDataItem{
 				ShortURL:    row.ShortURL,
 				OriginalURL: row.OriginalURL,
+				CreatedAt:   row.CreatedAt,
+				ExpiresAt:   row.ExpiresAt,
 			}
 		}
 
+		if page.NextCursor != "" {
+			next := r.URL.Query()
+			next.Set("cursor", page.NextCursor)
+			w.Header().Set("Link", fmt.Sprintf(`<%s?%s>; rel="next"`, r.URL.Path, next.Encode()))
+		}
+
 		statusCode := http.StatusOK
 		if len(respObj) == 0 {
 			statusCode = http.StatusNoContent
@@ -38,3 +71,34 @@ func UserDataHandler(s store.UserDataReader) http.HandlerFunc {
 		writeResponse(w, respObj, statusCode)
 	}
 }
+
+// parseUserDataQuery reads listing params, unset params are left zero
+func parseUserDataQuery(values url.Values) (store.UserDataQuery, error) {
+	q := store.UserDataQuery{
+		Cursor:      values.Get("cursor"),
+		URLContains: values.Get("url_contains"),
+		Sort:        store.UserDataSort(values.Get("sort")),
+	}
+
+	if v := values.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return q, fmt.Errorf("limit must be positive integer: %w", store.ErrBadInput)
+		}
+		q.Limit = n
+	}
+
+	for name, dst := range map[string]**time.Time{"created_from": &q.CreatedFrom, "created_to": &q.CreatedTo} {
+		v := values.Get(name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return q, fmt.Errorf("%s must be in RFC 3339 format: %w", name, store.ErrBadInput)
+		}
+		*dst = &t
+	}
+
+	return q, nil
+}
@@ -2,6 +2,8 @@ package api
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"io/ioutil"
@@ -11,6 +13,7 @@ import (
 	"shortener/internal/app/service/store"
 	storemock "shortener/internal/app/service/store/mock"
 	"testing"
+	"time"
 )
 
 func TestUserDataHandler(t *testing.T) {
@@ -39,7 +42,7 @@ func TestUserDataHandler(t *testing.T) {
 			},
 			want{
 				code: http.StatusOK,
-				body: `[{"short_url":"http://short","original_url":"http://long"}]`,
+				body: `[{"short_url":"http://short","original_url":"http://long","created_at":"0001-01-01T00:00:00Z"}]`,
 			},
 		},
 		{
@@ -58,7 +61,8 @@ func TestUserDataHandler(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			s := storemock.NewMockStore(ctrl)
-			s.EXPECT().ReadUserData(tt.args.user).Return(tt.args.storeData)
+			s.EXPECT().ReadUserData(gomock.Any(), tt.args.user, store.UserDataQuery{}).
+				Return(&store.UserDataPage{Records: tt.args.storeData}, nil)
 
 			request := httptest.NewRequest("GET", "/api/user/urls", nil)
 			request = request.WithContext(context.WithValue(request.Context(), handler.ContextKeyUID{}, tt.args.user))
@@ -92,3 +96,94 @@ func TestUserDataHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestUserDataHandler_Pagination(t *testing.T) {
+	type want struct {
+		code int
+		link string
+		body string
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	from := time.Date(2022, 5, 1, 0, 0, 0, 0, time.UTC)
+	created := from.Add(time.Hour)
+	s := storemock.NewMockUserDataReader(ctrl)
+	s.EXPECT().ReadUserData(gomock.Any(), "test", store.UserDataQuery{
+		Limit:       1,
+		CreatedFrom: &from,
+		URLContains: "example",
+		Sort:        store.SortCreatedDesc,
+	}).Return(&store.UserDataPage{
+		Records:    []store.Record{{ShortURL: "http://short", OriginalURL: "http://example.org", CreatedAt: created}},
+		NextCursor: "next",
+	}, nil)
+	s.EXPECT().ReadUserData(gomock.Any(), "test", store.UserDataQuery{Cursor: "bad"}).
+		Return(nil, fmt.Errorf("malformed cursor: %w", store.ErrBadInput))
+	s.EXPECT().ReadUserData(gomock.Any(), "test", store.UserDataQuery{Cursor: "err"}).
+		Return(nil, errors.New("internal"))
+
+	tests := []struct {
+		name  string
+		query string
+		want  want
+	}{
+		{
+			"page with next",
+			"?limit=1&created_from=2022-05-01T00:00:00Z&url_contains=example&sort=-created_at",
+			want{
+				code: http.StatusOK,
+				link: `</api/user/urls?created_from=2022-05-01T00%3A00%3A00Z&cursor=next&limit=1&sort=-created_at&url_contains=example>; rel="next"`,
+				body: `[{"short_url":"http://short","original_url":"http://example.org","created_at":"2022-05-01T01:00:00Z"}]`,
+			},
+		},
+		{
+			"bad limit",
+			"?limit=x",
+			want{
+				code: http.StatusBadRequest,
+				body: `{"error":"limit must be positive integer: bad input"}`,
+			},
+		},
+		{
+			"bad created_to",
+			"?created_to=yesterday",
+			want{
+				code: http.StatusBadRequest,
+				body: `{"error":"created_to must be in RFC 3339 format: bad input"}`,
+			},
+		},
+		{
+			"bad cursor",
+			"?cursor=bad",
+			want{
+				code: http.StatusBadRequest,
+				body: `{"error":"malformed cursor: bad input"}`,
+			},
+		},
+		{
+			"store error",
+			"?cursor=err",
+			want{
+				code: http.StatusInternalServerError,
+				body: `{"error":"internal"}`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest("GET", "/api/user/urls"+tt.query, nil)
+			request = request.WithContext(context.WithValue(request.Context(), handler.ContextKeyUID{}, "test"))
+			w := httptest.NewRecorder()
+			UserDataHandler(s).ServeHTTP(w, request)
+
+			res := w.Result()
+			resBody, _ := ioutil.ReadAll(res.Body)
+			assert.Equal(t, tt.want.code, res.StatusCode, "Body was: %s", resBody)
+			assert.Equal(t, tt.want.link, res.Header.Get("Link"))
+			assert.Equal(t, tt.want.body, string(resBody))
+			_ = res.Body.Close()
+		})
+	}
+}
@@ -5,10 +5,16 @@ import (
 	"net/http"
 	"shortener/internal/app/handler"
 	"shortener/internal/app/service/store"
+	"time"
 )
 
 type WriteHandlerRequest struct {
-	URL string `json:"url"`
+	URL   string `json:"url"`
+	Alias string `json:"alias,omitempty"`
+	// ExpiresAt is an absolute expiration time in RFC 3339 format
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// TTL is an expiration time in seconds from now
+	TTL int64 `json:"ttl,omitempty"`
 }
 
 type WriteHandlerResponse struct {
@@ -16,9 +22,12 @@ type WriteHandlerResponse struct {
 }
 
 // WriteHandler stores original url and returns the short version.
+// Optional alias is used as a custom short id. Optional expires_at or ttl (in seconds) limit the link lifetime.
 //
 //	curl -X POST -H "Content-Type: application/json" -d '{"url":"https://example.org"}' http://localhost:8080/api/shorten
 //	{"result":"http://localhost:8080/xxx"}
+//	curl -X POST -H "Content-Type: application/json" -d '{"url":"https://example.org/docs","alias":"docs"}' http://localhost:8080/api/shorten
+//	{"result":"http://localhost:8080/docs"}
 func WriteHandler(s store.Writer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		reqObj := &WriteHandlerRequest{}
@@ -28,16 +37,38 @@ func WriteHandler(s store.Writer) http.HandlerFunc {
 			return
 		}
 
+		expiresAt, err := store.ResolveExpiration(reqObj.ExpiresAt, time.Duration(reqObj.TTL)*time.Second, time.Now())
+		if err != nil {
+			writeError(w, err, http.StatusBadRequest)
+			return
+		}
+
+		var opts []store.WriteOption
+		if expiresAt != nil {
+			opts = append(opts, store.WithExpiresAt(expiresAt))
+		}
+
 		uid := handler.ReadContextString(r.Context(), handler.ContextKeyUID{})
-		shortURL, err := s.WriteURL(reqObj.URL, uid)
+		var shortURL string
+		if reqObj.Alias != "" {
+			shortURL, err = s.WriteAlias(r.Context(), reqObj.URL, reqObj.Alias, uid, opts...)
+		} else {
+			shortURL, err = s.WriteURL(r.Context(), reqObj.URL, uid, opts...)
+		}
 		if err != nil {
+			if errors.Is(err, store.ErrAliasTaken) {
+				writeError(w, err, http.StatusConflict)
+				return
+			}
 			var errConflict *store.ConflictError
 			if errors.As(err, &errConflict) {
 				respObj := &WriteHandlerResponse{Result: errConflict.ExistingURL}
 				writeResponse(w, respObj, http.StatusConflict)
 				return
 			}
-			if errors.Is(err, store.ErrBadInput) {
+			if errors.Is(err, store.ErrForbiddenTarget) {
+				writeError(w, err, http.StatusUnprocessableEntity)
+			} else if errors.Is(err, store.ErrBadInput) {
 				writeError(w, err, http.StatusBadRequest)
 			} else {
 				writeError(w, err, http.StatusInternalServerError)
@@ -29,9 +29,13 @@ func TestWriteHandler(t *testing.T) {
 	defer ctrl.Finish()
 
 	s := storemock.NewMockStore(ctrl)
-	s.EXPECT().WriteURL("https://example.org", "test").Return("http://localhost/bar", nil)
-	//s.EXPECT().WriteURL("", "test").Return("", store.ErrBadInput)
-	s.EXPECT().WriteURL("bad", "test").Return("", store.ErrBadInput)
+	s.EXPECT().WriteURL(gomock.Any(), "https://example.org", "test").Return("http://localhost/bar", nil)
+	//s.EXPECT().WriteURL(gomock.Any(), "", "test").Return("", store.ErrBadInput)
+	s.EXPECT().WriteURL(gomock.Any(), "bad", "test").Return("", store.ErrBadInput)
+	s.EXPECT().WriteURL(gomock.Any(), "http://localhost:8080/xxx", "test").Return("", store.ErrForbiddenTarget)
+	s.EXPECT().WriteAlias(gomock.Any(), "https://example.org/docs", "docs", "test").Return("http://localhost/docs", nil)
+	s.EXPECT().WriteAlias(gomock.Any(), "https://example.org/taken", "taken", "test").Return("", store.ErrAliasTaken)
+	s.EXPECT().WriteURL(gomock.Any(), "https://example.org/ttl", "test", gomock.Any()).Return("http://localhost/ttl", nil)
 
 	tests := []struct {
 		name string
@@ -74,6 +78,66 @@ func TestWriteHandler(t *testing.T) {
 				body: "{\"error\":\"bad input\"}",
 			},
 		},
+		{
+			"write forbidden",
+			args{
+				store:       s,
+				contentType: "application/json",
+				body:        "{\"url\":\"http://localhost:8080/xxx\"}",
+			},
+			want{
+				code: http.StatusUnprocessableEntity,
+				body: "{\"error\":\"forbidden target\"}",
+			},
+		},
+		{
+			"write alias",
+			args{
+				store:       s,
+				contentType: "application/json",
+				body:        "{\"url\":\"https://example.org/docs\",\"alias\":\"docs\"}",
+			},
+			want{
+				code: http.StatusCreated,
+				body: "{\"result\":\"http://localhost/docs\"}",
+			},
+		},
+		{
+			"write alias taken",
+			args{
+				store:       s,
+				contentType: "application/json",
+				body:        "{\"url\":\"https://example.org/taken\",\"alias\":\"taken\"}",
+			},
+			want{
+				code: http.StatusConflict,
+				body: "{\"error\":\"alias taken\"}",
+			},
+		},
+		{
+			"write with ttl",
+			args{
+				store:       s,
+				contentType: "application/json",
+				body:        "{\"url\":\"https://example.org/ttl\",\"ttl\":3600}",
+			},
+			want{
+				code: http.StatusCreated,
+				body: "{\"result\":\"http://localhost/ttl\"}",
+			},
+		},
+		{
+			"write expired",
+			args{
+				store:       s,
+				contentType: "application/json",
+				body:        "{\"url\":\"https://example.org/past\",\"expires_at\":\"2020-01-01T00:00:00Z\"}",
+			},
+			want{
+				code: http.StatusBadRequest,
+				body: "{\"error\":\"bad input: expiration time is in the past\"}",
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
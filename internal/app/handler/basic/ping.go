@@ -13,7 +13,7 @@ import (
 func PingHandler(s store.HealthChecker) http.HandlerFunc {
 	log := logger.Global().Component("Handler::Ping")
 	return func(w http.ResponseWriter, r *http.Request) {
-		if err := s.HealthCheck(); err != nil {
+		if err := s.HealthCheck(r.Context()); err != nil {
 			log.Error().Err(err).Msg("DB ping failure")
 			w.WriteHeader(http.StatusInternalServerError)
 			return
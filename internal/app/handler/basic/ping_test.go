@@ -25,8 +25,8 @@ func TestPingHandler(t *testing.T) {
 	defer ctrl.Finish()
 
 	s := storemock.NewMockHealthChecker(ctrl)
-	s.EXPECT().HealthCheck().Return(nil)
-	s.EXPECT().HealthCheck().Return(errors.New("health check error"))
+	s.EXPECT().HealthCheck(gomock.Any()).Return(nil)
+	s.EXPECT().HealthCheck(gomock.Any()).Return(errors.New("health check error"))
 
 	tests := []struct {
 		name string
@@ -2,26 +2,77 @@ package basic
 
 import (
 	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"html/template"
+	"net"
 	"net/http"
+	"shortener/internal/app/handler"
+	"shortener/internal/app/service/clicks"
 	"shortener/internal/app/service/store"
 	"strings"
+	"time"
 )
 
-// ReadHandler allows you to read short url.
+// quarantinePage warns about the quarantined link instead of the redirect
+var quarantinePage = template.Must(template.New("quarantine").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="robots" content="noindex, nofollow">
+<title>Warning: suspicious link</title>
+</head>
+<body>
+<h1>Warning: suspicious link</h1>
+<p>The link leads to a site listed as {{.Threat}}. The site may harm your device or steal your personal data.</p>
+<p>Destination: <code>{{.OriginalURL}}</code></p>
+<p><a href="{{.OriginalURL}}" rel="noopener noreferrer nofollow">Continue at your own risk</a></p>
+</body>
+</html>
+`))
+
+// ReadHandler allows you to read short url. Every redirect is tracked as a click from the remote address,
+// X-Real-IP is honoured only for the requests sent by the trustedProxies.
+// Quarantined links show the warning page instead of the redirect, disabled links are unavailable for legal reasons.
 //
 //	curl -v http://localhost:8080/xxx
-func ReadHandler(s store.Reader) http.HandlerFunc {
+func ReadHandler(s store.Reader, t clicks.Tracker, trustedProxies []*net.IPNet) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id := strings.TrimPrefix(r.URL.Path, "/")
-		u, err := s.ReadURL(id)
+		u, err := s.ReadURL(r.Context(), id)
 		if err != nil {
-			if errors.Is(err, store.ErrDeleted) {
+			if errors.Is(err, store.ErrDeleted) || errors.Is(err, store.ErrExpired) {
 				http.Error(w, err.Error(), http.StatusGone)
 				return
 			}
+			if errors.Is(err, store.ErrDisabled) {
+				http.Error(w, err.Error(), http.StatusUnavailableForLegalReasons)
+				return
+			}
+			var errQuarantine *store.QuarantineError
+			if errors.As(err, &errQuarantine) {
+				writeQuarantinePage(w, errQuarantine)
+				return
+			}
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
+		t.Track(store.ClickEvent{
+			ID:        id,
+			Time:      time.Now(),
+			Referer:   r.Referer(),
+			UserAgent: r.UserAgent(),
+			IP:        handler.PeerIP(r, trustedProxies),
+			RequestID: middleware.GetReqID(r.Context()),
+		})
 		http.Redirect(w, r, u, http.StatusTemporaryRedirect)
 	}
 }
+
+// writeQuarantinePage renders the warning, the page is not cached so the released link redirects at once
+func writeQuarantinePage(w http.ResponseWriter, e *store.QuarantineError) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.WriteHeader(http.StatusOK)
+	_ = quarantinePage.Execute(w, e)
+}
@@ -4,13 +4,24 @@ import (
 	"errors"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"shortener/internal/app/service/store"
 	storemock "shortener/internal/app/service/store/mock"
+	"strings"
 	"testing"
 )
 
+type trackerStub struct {
+	events []store.ClickEvent
+}
+
+func (t *trackerStub) Track(e store.ClickEvent) {
+	t.events = append(t.events, e)
+}
+
 func TestReadHandler(t *testing.T) {
 	type args struct {
 		store store.Store
@@ -19,16 +30,21 @@ func TestReadHandler(t *testing.T) {
 	type want struct {
 		code        int
 		redirectURL string
+		tracked     bool
 	}
 
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
+	// httptest requests are sent from 192.0.2.1
+	_, proxies, _ := net.ParseCIDR("192.0.2.0/24")
 	s := storemock.NewMockStore(ctrl)
-	s.EXPECT().ReadURL("test1").Return("https://example.org", nil)
-	s.EXPECT().ReadURL("").Return("", errors.New("empty id"))
-	s.EXPECT().ReadURL("missing").Return("", errors.New("missing id"))
-	s.EXPECT().ReadURL("deleted").Return("", store.ErrDeleted)
+	s.EXPECT().ReadURL(gomock.Any(), "test1").Return("https://example.org", nil)
+	s.EXPECT().ReadURL(gomock.Any(), "").Return("", errors.New("empty id"))
+	s.EXPECT().ReadURL(gomock.Any(), "missing").Return("", errors.New("missing id"))
+	s.EXPECT().ReadURL(gomock.Any(), "deleted").Return("", store.ErrDeleted)
+	s.EXPECT().ReadURL(gomock.Any(), "expired").Return("", store.ErrExpired)
+	s.EXPECT().ReadURL(gomock.Any(), "disabled").Return("", store.ErrDisabled)
 
 	tests := []struct {
 		name string
@@ -44,6 +60,7 @@ func TestReadHandler(t *testing.T) {
 			want{
 				code:        http.StatusTemporaryRedirect,
 				redirectURL: "https://example.org",
+				tracked:     true,
 			},
 		},
 		{
@@ -76,14 +93,37 @@ func TestReadHandler(t *testing.T) {
 				code: http.StatusGone,
 			},
 		},
+		{
+			"read expired",
+			args{
+				store: s,
+				path:  "/expired",
+			},
+			want{
+				code: http.StatusGone,
+			},
+		},
+		{
+			"read disabled",
+			args{
+				store: s,
+				path:  "/disabled",
+			},
+			want{
+				code: http.StatusUnavailableForLegalReasons,
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			request := httptest.NewRequest("GET", tt.args.path, nil)
+			request.Header.Set("Referer", "https://referer.test")
+			request.Header.Set("X-Real-IP", "10.0.0.1")
+			tracker := &trackerStub{}
 			// создаём новый Recorder
 			w := httptest.NewRecorder()
 			// определяем хендлер
-			h := ReadHandler(s)
+			h := ReadHandler(s, tracker, []*net.IPNet{proxies})
 			// запускаем сервер
 			h.ServeHTTP(w, request)
 			res := w.Result()
@@ -105,7 +145,62 @@ func TestReadHandler(t *testing.T) {
 					res.Header.Get("Location"),
 				)
 			}
+			if tt.want.tracked {
+				require.Len(t, tracker.events, 1)
+				assert.Equal(t, strings.TrimPrefix(tt.args.path, "/"), tracker.events[0].ID)
+				assert.Equal(t, "https://referer.test", tracker.events[0].Referer)
+				assert.Equal(t, "10.0.0.1", tracker.events[0].IP)
+			} else {
+				assert.Empty(t, tracker.events, "failed redirect must not be tracked")
+			}
 			_ = res.Body.Close()
 		})
 	}
 }
+
+func TestReadHandler_UntrustedRealIP(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s := storemock.NewMockStore(ctrl)
+	s.EXPECT().ReadURL(gomock.Any(), "test1").Return("https://example.org", nil)
+	tracker := &trackerStub{}
+
+	request := httptest.NewRequest("GET", "/test1", nil)
+	request.Header.Set("X-Real-IP", "10.0.0.1")
+	w := httptest.NewRecorder()
+	ReadHandler(s, tracker, nil).ServeHTTP(w, request)
+	res := w.Result()
+	_ = res.Body.Close()
+
+	assert.Equal(t, http.StatusTemporaryRedirect, res.StatusCode)
+	require.Len(t, tracker.events, 1)
+	assert.Equal(t, "192.0.2.1", tracker.events[0].IP, "X-Real-IP of the client must be ignored")
+}
+
+func TestReadHandler_Quarantine(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s := storemock.NewMockStore(ctrl)
+	s.EXPECT().ReadURL(gomock.Any(), "bad").Return("", &store.QuarantineError{
+		OriginalURL: "https://evil.example.org/?q=<script>",
+		Threat:      "phishing",
+	})
+	tracker := &trackerStub{}
+
+	w := httptest.NewRecorder()
+	ReadHandler(s, tracker, nil).ServeHTTP(w, httptest.NewRequest("GET", "/bad", nil))
+	res := w.Result()
+	defer func() {
+		_ = res.Body.Close()
+	}()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Empty(t, res.Header.Get("Location"))
+	assert.Equal(t, "no-store", res.Header.Get("Cache-Control"))
+	assert.Contains(t, w.Body.String(), "listed as phishing")
+	assert.Contains(t, w.Body.String(), `href="https://evil.example.org/?q=%3cscript%3e"`)
+	assert.NotContains(t, w.Body.String(), "<script>")
+	assert.Empty(t, tracker.events, "warning page must not be tracked")
+}
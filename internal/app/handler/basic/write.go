@@ -22,7 +22,7 @@ func WriteHandler(s store.Writer) http.HandlerFunc {
 		}
 		u := string(body)
 		uid := handler.ReadContextString(r.Context(), handler.ContextKeyUID{})
-		redirectURL, err := s.WriteURL(u, uid)
+		redirectURL, err := s.WriteURL(r.Context(), u, uid)
 		if err != nil {
 			var errConflict *store.ConflictError
 			if errors.As(err, &errConflict) {
@@ -30,6 +30,10 @@ func WriteHandler(s store.Writer) http.HandlerFunc {
 				_, _ = w.Write([]byte(errConflict.ExistingURL))
 				return
 			}
+			if errors.Is(err, store.ErrForbiddenTarget) {
+				http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+				return
+			}
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
@@ -29,10 +29,11 @@ func TestWriteHandler(t *testing.T) {
 	defer ctrl.Finish()
 
 	s := storemock.NewMockStore(ctrl)
-	s.EXPECT().WriteURL("https://example.org", "test").Return("http://localhost/bar", nil)
-	s.EXPECT().WriteURL("", "test").Return("", errors.New("bad url"))
-	s.EXPECT().WriteURL("bad", "test").Return("", errors.New("bad url"))
-	s.EXPECT().WriteURL("https://example.org/conflict", "test").Return("", &store.ConflictError{
+	s.EXPECT().WriteURL(gomock.Any(), "https://example.org", "test").Return("http://localhost/bar", nil)
+	s.EXPECT().WriteURL(gomock.Any(), "", "test").Return("", errors.New("bad url"))
+	s.EXPECT().WriteURL(gomock.Any(), "bad", "test").Return("", errors.New("bad url"))
+	s.EXPECT().WriteURL(gomock.Any(), "javascript://example.org/%0Aalert(1)", "test").Return("", store.ErrForbiddenTarget)
+	s.EXPECT().WriteURL(gomock.Any(), "https://example.org/conflict", "test").Return("", &store.ConflictError{
 		ExistingURL: "https://example.org/non-conflict",
 	})
 
@@ -74,6 +75,17 @@ func TestWriteHandler(t *testing.T) {
 				body: "bad url\n",
 			},
 		},
+		{
+			"write forbidden",
+			args{
+				store: s,
+				body:  "javascript://example.org/%0Aalert(1)",
+			},
+			want{
+				code: http.StatusUnprocessableEntity,
+				body: "forbidden target\n",
+			},
+		},
 		{
 			"conflict",
 			args{
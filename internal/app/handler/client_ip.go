@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"net"
+	"net/http"
+)
+
+// ClientIP returns X-Real-IP set by the proxy or the remote address host
+//
+// Deprecated: X-Real-IP can be set by any client, use PeerIP with the trusted proxies.
+func ClientIP(r *http.Request) string {
+	if ip := r.Header.Get("X-Real-IP"); ip != "" {
+		return ip
+	}
+	return remoteHost(r)
+}
+
+// PeerIP returns the remote address host, X-Real-IP is used only if the request is sent by one of the trusted proxies
+func PeerIP(r *http.Request, trustedProxies []*net.IPNet) string {
+	host := remoteHost(r)
+	peer := net.ParseIP(host)
+	if peer == nil {
+		return host
+	}
+	for _, n := range trustedProxies {
+		if !n.Contains(peer) {
+			continue
+		}
+		if ip := net.ParseIP(r.Header.Get("X-Real-IP")); ip != nil {
+			return ip.String()
+		}
+		break
+	}
+	return host
+}
+
+// remoteHost returns host of the remote address
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -40,3 +40,13 @@ func (w gzipWriter) Write(b []byte) (int, error) {
 	// Writer будет отвечать за gzip-сжатие, поэтому пишем в него
 	return w.Writer.Write(b)
 }
+
+// Flush compressed data, so the streaming handlers deliver it to the client
+func (w gzipWriter) Flush() {
+	if gz, ok := w.Writer.(*gzip.Writer); ok {
+		_ = gz.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
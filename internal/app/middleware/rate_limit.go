@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"shortener/internal/app/handler"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// rateWindow counts requests of one client
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// RateLimit allows every client at most limit requests per fixed window. Clients are told apart by the remote
+// address, X-Real-IP is honoured only for the requests sent by the trustedProxies.
+// Requests over the limit are rejected with 429 and the time left until the next window in Retry-After.
+func RateLimit(limit int, window time.Duration, trustedProxies []*net.IPNet) func(next http.Handler) http.Handler {
+	var (
+		mu        sync.Mutex
+		clients   = make(map[string]*rateWindow)
+		lastSweep time.Time
+	)
+
+	// allow counts the request and returns zero or the time to wait for the next window
+	allow := func(ip string, now time.Time) time.Duration {
+		mu.Lock()
+		defer mu.Unlock()
+
+		// windows of the gone clients are dropped once per window
+		if now.Sub(lastSweep) >= window {
+			for k, w := range clients {
+				if now.Sub(w.start) >= window {
+					delete(clients, k)
+				}
+			}
+			lastSweep = now
+		}
+
+		w, ok := clients[ip]
+		if !ok || now.Sub(w.start) >= window {
+			w = &rateWindow{start: now}
+			clients[ip] = w
+		}
+		if w.count >= limit {
+			return w.start.Add(window).Sub(now)
+		}
+		w.count++
+		return 0
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if wait := allow(handler.PeerIP(r, trustedProxies), time.Now()); wait > 0 {
+				seconds := int((wait + time.Second - 1) / time.Second)
+				w.Header().Set("Retry-After", strconv.Itoa(seconds))
+				http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
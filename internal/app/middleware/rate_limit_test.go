@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	_, proxies, _ := net.ParseCIDR("10.10.0.0/16")
+	h := RateLimit(2, time.Minute, []*net.IPNet{proxies})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	send := func(remoteAddr string, realIP string) int {
+		request := httptest.NewRequest(http.MethodPost, "/api/report/xxx", nil)
+		request.RemoteAddr = remoteAddr
+		if realIP != "" {
+			request.Header.Set("X-Real-IP", realIP)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, request)
+		res := w.Result()
+		_ = res.Body.Close()
+		if res.StatusCode == http.StatusTooManyRequests {
+			assert.Equal(t, "60", res.Header.Get("Retry-After"))
+		}
+		return res.StatusCode
+	}
+
+	assert.Equal(t, http.StatusCreated, send("192.0.2.1:1234", ""))
+	assert.Equal(t, http.StatusCreated, send("192.0.2.1:1235", ""))
+	assert.Equal(t, http.StatusTooManyRequests, send("192.0.2.1:1236", ""))
+	assert.Equal(t, http.StatusCreated, send("192.0.2.2:1234", ""), "clients must be limited separately")
+
+	t.Run("untrusted X-Real-IP", func(t *testing.T) {
+		for i := 0; i < 5; i++ {
+			code := send("192.0.2.3:1234", "203.0.113."+strconv.Itoa(i))
+			if i < 2 {
+				assert.Equal(t, http.StatusCreated, code)
+			} else {
+				assert.Equal(t, http.StatusTooManyRequests, code, "rotating X-Real-IP must not reset the count")
+			}
+		}
+	})
+
+	t.Run("trusted proxy", func(t *testing.T) {
+		assert.Equal(t, http.StatusCreated, send("10.10.0.1:1234", "203.0.113.1"))
+		assert.Equal(t, http.StatusCreated, send("10.10.0.1:1234", "203.0.113.1"))
+		assert.Equal(t, http.StatusTooManyRequests, send("10.10.0.2:1234", "203.0.113.1"))
+		assert.Equal(t, http.StatusCreated, send("10.10.0.1:1234", "203.0.113.2"), "clients behind the proxy must be limited separately")
+	})
+}
@@ -0,0 +1,285 @@
+package clicks
+
+import (
+	"context"
+	"fmt"
+	"github.com/Rican7/retry/backoff"
+	"github.com/Rican7/retry/strategy"
+	"runtime"
+	"shortener/internal/app/logger"
+	"shortener/internal/app/service/store"
+	"shortener/pkg/workerpool"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Overflow policies applied when the buffer is full
+const (
+	// OverflowDrop drops the event immediately, redirect latency is never affected
+	OverflowDrop = "drop"
+	// OverflowBlock waits up to the block timeout for the free buffer space and drops the event after it
+	OverflowBlock = "block"
+)
+
+// Metrics of the tracker, counters are accumulated since the start
+type Metrics struct {
+	Tracked uint64 `json:"tracked"`
+	Written uint64 `json:"written"`
+	Dropped uint64 `json:"dropped"`
+	Failed  uint64 `json:"failed"`
+}
+
+// BufferedTracker collects click events in the bounded buffer and writes them to the recorder in bulk
+// once the batch is full or the flush interval passes. Batches are written by the worker pool.
+//
+// Memory is bounded by the buffer size plus one batch per worker. Events not fitting into the buffer are
+// handled by the overflow policy and counted as dropped. Stop flushes all the buffered events.
+type BufferedTracker struct {
+	recorder      store.ClickRecorder
+	log           logger.Logger
+	wp            *workerpool.Pool
+	workers       int
+	bufferSize    int
+	batchSize     int
+	flushInterval time.Duration
+	timeout       time.Duration
+	overflow      string
+	blockTimeout  time.Duration
+	retries       uint
+
+	// mu guards events channel from being closed while events are sent
+	mu      sync.RWMutex
+	events  chan store.ClickEvent
+	stopped chan struct{}
+
+	tracked uint64
+	written uint64
+	dropped uint64
+	failed  uint64
+}
+
+// Tracker interface implementation
+var _ Tracker = (*BufferedTracker)(nil)
+
+// NewBufferedTracker constructor
+func NewBufferedTracker(r store.ClickRecorder, opts ...Option) *BufferedTracker {
+	const (
+		defaultBufferSize    = 10000
+		defaultBatchSize     = 500
+		defaultFlushInterval = time.Second
+		defaultBlockTimeout  = 50 * time.Millisecond
+		defaultRetries       = 3
+	)
+
+	t := &BufferedTracker{
+		recorder:      r,
+		log:           logger.Global().Component("ClickTracker"),
+		wp:            workerpool.New(),
+		workers:       runtime.GOMAXPROCS(0),
+		bufferSize:    defaultBufferSize,
+		batchSize:     defaultBatchSize,
+		flushInterval: defaultFlushInterval,
+		overflow:      OverflowDrop,
+		blockTimeout:  defaultBlockTimeout,
+		retries:       defaultRetries,
+	}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	return t
+}
+
+type Option func(*BufferedTracker)
+
+// WithBufferSize limits number of the buffered events
+func WithBufferSize(n int) Option {
+	return func(t *BufferedTracker) {
+		t.bufferSize = n
+	}
+}
+
+// WithBatchSize sets number of events flushed at once
+func WithBatchSize(n int) Option {
+	return func(t *BufferedTracker) {
+		t.batchSize = n
+	}
+}
+
+// WithFlushInterval sets max time the event waits in the buffer
+func WithFlushInterval(d time.Duration) Option {
+	return func(t *BufferedTracker) {
+		t.flushInterval = d
+	}
+}
+
+// WithTimeout limits single batch write attempt, zero means no timeout
+func WithTimeout(d time.Duration) Option {
+	return func(t *BufferedTracker) {
+		t.timeout = d
+	}
+}
+
+// WithOverflowPolicy sets OverflowDrop or OverflowBlock policy, block timeout is used by the latter
+func WithOverflowPolicy(policy string, blockTimeout time.Duration) Option {
+	return func(t *BufferedTracker) {
+		t.overflow = policy
+		t.blockTimeout = blockTimeout
+	}
+}
+
+// WithWorkers sets number of the concurrent batch writers
+func WithWorkers(n int) Option {
+	return func(t *BufferedTracker) {
+		t.workers = n
+	}
+}
+
+// Start buffering and workers
+func (t *BufferedTracker) Start() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.events != nil {
+		return ErrAlreadyStarted
+	}
+
+	switch t.overflow {
+	case OverflowDrop, OverflowBlock:
+	default:
+		return fmt.Errorf("unknown overflow policy %q", t.overflow)
+	}
+	if t.bufferSize <= 0 || t.batchSize <= 0 || t.flushInterval <= 0 || t.workers <= 0 {
+		return fmt.Errorf("buffer size, batch size, flush interval and workers must be positive")
+	}
+
+	t.wp.Start(t.workers)
+	t.events = make(chan store.ClickEvent, t.bufferSize)
+	t.stopped = make(chan struct{})
+	go t.collect(t.events, t.stopped)
+
+	return nil
+}
+
+// Stop accepting events, flush the buffered ones and stop workers
+func (t *BufferedTracker) Stop() error {
+	t.mu.Lock()
+	if t.events == nil {
+		t.mu.Unlock()
+		return ErrNotStarted
+	}
+	close(t.events)
+	t.events = nil
+	stopped := t.stopped
+	t.mu.Unlock()
+
+	// collector drains the buffer and hands the last batch to the workers
+	<-stopped
+	t.wp.Stop()
+
+	m := t.Metrics()
+	t.log.Info().
+		Uint64("tracked", m.Tracked).
+		Uint64("written", m.Written).
+		Uint64("dropped", m.Dropped).
+		Uint64("failed", m.Failed).
+		Msg("Click tracker stopped")
+
+	return nil
+}
+
+// Track puts the event into the buffer, events tracked when the tracker is not started are dropped
+func (t *BufferedTracker) Track(e store.ClickEvent) {
+	atomic.AddUint64(&t.tracked, 1)
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if t.events == nil {
+		atomic.AddUint64(&t.dropped, 1)
+		return
+	}
+
+	select {
+	case t.events <- e:
+		return
+	default:
+	}
+
+	if t.overflow == OverflowBlock {
+		timer := time.NewTimer(t.blockTimeout)
+		defer timer.Stop()
+		select {
+		case t.events <- e:
+			return
+		case <-timer.C:
+		}
+	}
+
+	atomic.AddUint64(&t.dropped, 1)
+}
+
+// Metrics returns the tracker counters
+func (t *BufferedTracker) Metrics() Metrics {
+	return Metrics{
+		Tracked: atomic.LoadUint64(&t.tracked),
+		Written: atomic.LoadUint64(&t.written),
+		Dropped: atomic.LoadUint64(&t.dropped),
+		Failed:  atomic.LoadUint64(&t.failed),
+	}
+}
+
+// collect events into batches until the events channel is closed
+func (t *BufferedTracker) collect(events <-chan store.ClickEvent, stopped chan<- struct{}) {
+	defer close(stopped)
+
+	ticker := time.NewTicker(t.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]store.ClickEvent, 0, t.batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		// blocks while all the workers are busy, so the buffer fills up and overflow policy applies
+		t.wp.Run(t.writeJob(batch))
+		batch = make([]store.ClickEvent, 0, t.batchSize)
+	}
+
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, e)
+			if len(batch) >= t.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// writeJob writes the batch with retries, failed batch is counted and dropped
+func (t *BufferedTracker) writeJob(batch []store.ClickEvent) workerpool.Job {
+	job := func(ctx context.Context) error {
+		return t.recorder.RecordClicks(ctx, batch...)
+	}
+	if t.timeout > 0 {
+		job = workerpool.AddTimeout(job, t.timeout)
+	}
+	job = workerpool.AddRetry(job, strategy.Limit(t.retries), strategy.Backoff(backoff.Linear(100*time.Millisecond)))
+
+	return workerpool.AddPostRun(job, func(err error) {
+		if err != nil {
+			atomic.AddUint64(&t.failed, uint64(len(batch)))
+			return
+		}
+		atomic.AddUint64(&t.written, uint64(len(batch)))
+	})
+}
@@ -0,0 +1,140 @@
+package clicks
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"shortener/internal/app/service/store"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recorderStub struct {
+	mu      sync.Mutex
+	batches [][]store.ClickEvent
+	// block holds writes until closed
+	block chan struct{}
+	err   error
+}
+
+func (r *recorderStub) RecordClicks(_ context.Context, events ...store.ClickEvent) error {
+	if r.block != nil {
+		<-r.block
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	r.batches = append(r.batches, events)
+	return nil
+}
+
+func (r *recorderStub) count() (batches int, events int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, b := range r.batches {
+		events += len(b)
+	}
+	return len(r.batches), events
+}
+
+func TestBufferedTracker_BatchSize(t *testing.T) {
+	r := &recorderStub{}
+	tr := NewBufferedTracker(r, WithBatchSize(10), WithFlushInterval(time.Hour))
+
+	assert.ErrorIs(t, tr.Stop(), ErrNotStarted)
+	require.NoError(t, tr.Start())
+	assert.ErrorIs(t, tr.Start(), ErrAlreadyStarted)
+
+	for i := 0; i < 25; i++ {
+		tr.Track(store.ClickEvent{ID: "test"})
+	}
+
+	assert.Eventually(t, func() bool {
+		batches, _ := r.count()
+		return batches == 2
+	}, time.Second, 10*time.Millisecond, "full batches must be flushed without waiting for the interval")
+
+	// the rest is flushed on stop
+	require.NoError(t, tr.Stop())
+	batches, events := r.count()
+	assert.Equal(t, 3, batches)
+	assert.Equal(t, 25, events)
+	assert.Equal(t, Metrics{Tracked: 25, Written: 25}, tr.Metrics())
+
+	tr.Track(store.ClickEvent{ID: "late"})
+	assert.Equal(t, uint64(1), tr.Metrics().Dropped, "events tracked after stop must be dropped")
+}
+
+func TestBufferedTracker_FlushInterval(t *testing.T) {
+	r := &recorderStub{}
+	tr := NewBufferedTracker(r, WithBatchSize(100), WithFlushInterval(20*time.Millisecond))
+	require.NoError(t, tr.Start())
+	defer func() {
+		_ = tr.Stop()
+	}()
+
+	tr.Track(store.ClickEvent{ID: "test"})
+
+	assert.Eventually(t, func() bool {
+		_, events := r.count()
+		return events == 1
+	}, time.Second, 10*time.Millisecond, "partial batch must be flushed by the interval")
+}
+
+func TestBufferedTracker_Overflow(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy string
+	}{
+		{name: "drop", policy: OverflowDrop},
+		{name: "block", policy: OverflowBlock},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &recorderStub{block: make(chan struct{})}
+			tr := NewBufferedTracker(r,
+				WithWorkers(1),
+				WithBufferSize(5),
+				WithBatchSize(1),
+				WithFlushInterval(time.Hour),
+				WithOverflowPolicy(tt.policy, 10*time.Millisecond),
+			)
+			require.NoError(t, tr.Start())
+
+			// single worker is blocked, so memory is bounded by one batch per worker,
+			// one batch waiting for the worker and the buffer
+			for i := 0; i < 20; i++ {
+				tr.Track(store.ClickEvent{ID: "test"})
+			}
+
+			close(r.block)
+			require.NoError(t, tr.Stop())
+
+			m := tr.Metrics()
+			_, events := r.count()
+			assert.Equal(t, uint64(20), m.Tracked)
+			assert.Equal(t, uint64(events), m.Written)
+			assert.Equal(t, m.Tracked, m.Written+m.Dropped)
+			assert.LessOrEqual(t, events, 7)
+			assert.Greater(t, m.Dropped, uint64(0))
+		})
+	}
+}
+
+func TestBufferedTracker_Failed(t *testing.T) {
+	r := &recorderStub{err: errors.New("db is down")}
+	tr := NewBufferedTracker(r, WithBatchSize(2), WithFlushInterval(time.Hour))
+	tr.retries = 1
+	require.NoError(t, tr.Start())
+
+	for i := 0; i < 3; i++ {
+		tr.Track(store.ClickEvent{ID: "test"})
+	}
+	require.NoError(t, tr.Stop())
+
+	assert.Equal(t, Metrics{Tracked: 3, Failed: 3}, tr.Metrics())
+}
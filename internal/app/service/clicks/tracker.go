@@ -0,0 +1,19 @@
+/*
+Package clicks provides tracking of the short link redirects.
+*/
+package clicks
+
+import (
+	"errors"
+	"shortener/internal/app/service/store"
+)
+
+var (
+	ErrNotStarted     = errors.New("not started")
+	ErrAlreadyStarted = errors.New("already started")
+)
+
+// Tracker records redirects without blocking the caller
+type Tracker interface {
+	Track(e store.ClickEvent)
+}
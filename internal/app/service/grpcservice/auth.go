@@ -12,12 +12,33 @@ const mdKeyUID = "uid"
 
 func UID() grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-		uid := metautils.ExtractIncoming(ctx).Get(mdKeyUID)
-		if uid == "" {
-			uid = uuid.New().String()
-		}
-		ctx = user.WriteUID(ctx, uid)
-		resp, err := handler(ctx, req)
+		resp, err := handler(withUID(ctx), req)
 		return resp, err
 	}
 }
+
+// StreamUID is UID interceptor of the streaming calls
+func StreamUID() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &uidStream{ServerStream: ss, ctx: withUID(ss.Context())})
+	}
+}
+
+// withUID writes uid from the metadata into the context, new uid is generated if it is missing
+func withUID(ctx context.Context) context.Context {
+	uid := metautils.ExtractIncoming(ctx).Get(mdKeyUID)
+	if uid == "" {
+		uid = uuid.New().String()
+	}
+	return user.WriteUID(ctx, uid)
+}
+
+// uidStream replaces the stream context with the one containing uid
+type uidStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *uidStream) Context() context.Context {
+	return s.ctx
+}
@@ -3,21 +3,26 @@ package grpcservice
 import (
 	"context"
 	"errors"
+	"fmt"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"io"
 	pb "shortener/api/proto"
 	"shortener/internal/app/service/store"
 	"shortener/internal/pkg/user"
+	"time"
 )
 
 type ShortenerService struct {
 	pb.UnimplementedShortenerServer
 
-	store store.Store
+	store store.Backend
 }
 
-func NewShortenerService(st store.Store) *ShortenerService {
+func NewShortenerService(st store.Backend) *ShortenerService {
 	s := &ShortenerService{
 		store: st,
 	}
@@ -34,60 +39,110 @@ func (s *ShortenerService) Init() ServiceInit {
 func (s *ShortenerService) Shorten(ctx context.Context, request *pb.ShortenRequest) (*pb.ShortenResponse, error) {
 	resp := &pb.ShortenResponse{}
 
+	expiresAt, err := expiration(request.GetExpiresAt(), request.GetTtl(), time.Now())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	var opts []store.WriteOption
+	if expiresAt != nil {
+		opts = append(opts, store.WithExpiresAt(expiresAt))
+	}
+
 	uid := user.ReadUID(ctx)
-	shortURL, err := s.store.WriteURL(request.GetOriginalUrl(), uid)
+	var shortURL string
+	if request.GetAlias() != "" {
+		shortURL, err = s.store.WriteAlias(ctx, request.GetOriginalUrl(), request.GetAlias(), uid, opts...)
+	} else {
+		shortURL, err = s.store.WriteURL(ctx, request.GetOriginalUrl(), uid, opts...)
+	}
 	if err != nil {
+		if errors.Is(err, store.ErrAliasTaken) {
+			return nil, status.Error(codes.AlreadyExists, err.Error())
+		}
 		var errConflict *store.ConflictError
 		if errors.As(err, &errConflict) {
 			resp.ShortUrl = errConflict.ExistingURL
 			return resp, status.Error(codes.Internal, err.Error())
 		}
-		return nil, err
+		if errors.Is(err, store.ErrForbiddenTarget) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		if errors.Is(err, store.ErrBadInput) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, internalError(err)
 	}
 
 	resp.ShortUrl = shortURL
 	return resp, nil
 }
 
+// BatchShorten stores items independently, failed ones are reported with the error code.
+// Atomic batch fails on the first failed item.
 func (s *ShortenerService) BatchShorten(ctx context.Context, request *pb.BatchShortenRequest) (*pb.BatchShortenResponse, error) {
 	uid := user.ReadUID(ctx)
 
+	var opts []store.WriteOption
+	if request.GetAtomic() {
+		opts = append(opts, store.WithAtomic())
+	}
+
+	now := time.Now()
 	storeReq := make([]store.Record, len(request.Items))
 	for i, rec := range request.Items {
 		storeReq[i] = store.Record{
 			CorrelationID: rec.GetCorrelationId(),
 			OriginalURL:   rec.GetOriginalUrl(),
+			ID:            rec.GetAlias(),
 		}
+		storeReq[i].ExpiresAt, storeReq[i].Err = expiration(rec.GetExpiresAt(), rec.GetTtl(), now)
 	}
 
-	storeRes, err := s.store.BatchWrite(uid, storeReq)
+	storeRes, err := s.store.BatchWrite(ctx, uid, storeReq, opts...)
 	if err != nil {
-		if errors.Is(err, store.ErrBadInput) {
+		var errConflict *store.ConflictError
+		if errors.Is(err, store.ErrAliasTaken) || errors.As(err, &errConflict) {
+			return nil, status.Error(codes.AlreadyExists, err.Error())
+		} else if errors.Is(err, store.ErrForbiddenTarget) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		} else if errors.Is(err, store.ErrBadInput) {
 			return nil, status.Error(codes.InvalidArgument, err.Error())
 		} else {
-			return nil, status.Error(codes.Internal, err.Error())
+			return nil, internalError(err)
 		}
 	}
 
 	resp := &pb.BatchShortenResponse{
-		Items: make([]*pb.BatchShortenResponseItem, len(request.Items)),
+		Items: make([]*pb.BatchShortenResponseItem, len(storeRes)),
 	}
 	for i, rec := range storeRes {
 		resp.Items[i] = &pb.BatchShortenResponseItem{
 			CorrelationId: rec.CorrelationID,
 			ShortUrl:      rec.ShortURL,
 		}
+		if rec.Err != nil {
+			resp.Items[i].Code = store.ItemErrorCode(rec.Err)
+			resp.Items[i].Error = rec.Err.Error()
+		}
 	}
 
 	return resp, nil
 }
 
 func (s *ShortenerService) Expand(ctx context.Context, request *pb.ExpandRequest) (*pb.ExpandResponse, error) {
-	u, err := s.store.ReadURL(request.GetId())
+	u, err := s.store.ReadURL(ctx, request.GetId())
 	if err != nil {
-		if errors.Is(err, store.ErrDeleted) {
+		if errors.Is(err, store.ErrDeleted) || errors.Is(err, store.ErrExpired) {
 			return nil, status.Error(codes.NotFound, err.Error())
 		}
+		if errors.Is(err, store.ErrDisabled) {
+			return nil, status.Error(codes.PermissionDenied, "link is disabled")
+		}
+		var errQuarantine *store.QuarantineError
+		if errors.As(err, &errQuarantine) {
+			return nil, status.Errorf(codes.FailedPrecondition, "link is quarantined as %s", errQuarantine.Threat)
+		}
 		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
 	return &pb.ExpandResponse{
@@ -98,30 +153,332 @@ func (s *ShortenerService) Expand(ctx context.Context, request *pb.ExpandRequest
 func (s *ShortenerService) BatchRemove(ctx context.Context, request *pb.BatchRemoveRequest) (*pb.BatchRemoveResponse, error) {
 	uid := user.ReadUID(ctx)
 
-	if err := s.store.BatchRemove(uid, request.GetIds()...); err != nil {
+	opID, err := s.store.BatchRemove(ctx, uid, request.GetIds()...)
+	if err != nil {
 		if errors.Is(err, store.ErrBadInput) {
 			return nil, status.Error(codes.InvalidArgument, err.Error())
 		}
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, internalError(err)
+	}
+
+	return &pb.BatchRemoveResponse{OperationId: opID}, nil
+}
+
+func (s *ShortenerService) Restore(ctx context.Context, request *pb.RestoreRequest) (*pb.RestoreResponse, error) {
+	uid := user.ReadUID(ctx)
+
+	items, err := s.store.Restore(ctx, uid, request.GetIds()...)
+	if err != nil {
+		if errors.Is(err, store.ErrBadInput) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, internalError(err)
 	}
 
-	return &pb.BatchRemoveResponse{}, nil
+	resp := &pb.RestoreResponse{
+		Items: make([]*pb.RestoreResponseItem, len(items)),
+	}
+	for i, item := range items {
+		resp.Items[i] = &pb.RestoreResponseItem{
+			Id:      item.ID,
+			Outcome: string(item.Outcome),
+		}
+	}
+
+	return resp, nil
 }
 
 func (s *ShortenerService) UserData(ctx context.Context, request *pb.UserDataRequest) (*pb.UserDataResponse, error) {
+	q := store.UserDataQuery{
+		Limit:       int(request.GetLimit()),
+		Cursor:      request.GetCursor(),
+		URLContains: request.GetUrlContains(),
+		Sort:        store.UserDataSort(request.GetSort()),
+	}
+	if request.CreatedFrom != nil {
+		t := request.GetCreatedFrom().AsTime()
+		q.CreatedFrom = &t
+	}
+	if request.CreatedTo != nil {
+		t := request.GetCreatedTo().AsTime()
+		q.CreatedTo = &t
+	}
+
 	uid := user.ReadUID(ctx)
-	rows := s.store.ReadUserData(uid)
+	page, err := s.store.ReadUserData(ctx, uid, q)
+	if err != nil {
+		if errors.Is(err, store.ErrBadInput) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
 
 	resp := &pb.UserDataResponse{
-		Items: make([]*pb.UserDataResponseItem, len(rows)),
+		Items:      make([]*pb.UserDataResponseItem, len(page.Records)),
+		NextCursor: page.NextCursor,
 	}
 
-	for i, row := range rows {
+	for i, row := range page.Records {
 		resp.Items[i] = &pb.UserDataResponseItem{
 			ShortUrl:    row.ShortURL,
 			OriginalUrl: row.OriginalURL,
+			CreatedAt:   timestamppb.New(row.CreatedAt),
+		}
+	}
+
+	return resp, nil
+}
+
+func (s *ShortenerService) LinkStats(ctx context.Context, request *pb.LinkStatsRequest) (*pb.LinkStatsResponse, error) {
+	const (
+		defaultDays = 30
+		maxDays     = 365
+	)
+
+	days := int(request.GetDays())
+	if days == 0 {
+		days = defaultDays
+	}
+	if days < 1 || days > maxDays {
+		return nil, status.Errorf(codes.InvalidArgument, "days must be in range 1..%d", maxDays)
+	}
+
+	uid := user.ReadUID(ctx)
+	stat, err := s.store.ReadLinkStat(ctx, uid, request.GetId(), days)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		if errors.Is(err, store.ErrBadInput) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, internalError(err)
+	}
+
+	resp := &pb.LinkStatsResponse{
+		Id:             stat.ID,
+		ShortUrl:       stat.ShortURL,
+		TotalClicks:    int64(stat.TotalClicks),
+		UniqueVisitors: int64(stat.UniqueVisitors),
+		Daily:          make([]*pb.LinkStatsDailyItem, len(stat.Daily)),
+	}
+	for i, d := range stat.Daily {
+		resp.Daily[i] = &pb.LinkStatsDailyItem{
+			Date:   d.Date.Format("2006-01-02"),
+			Clicks: int64(d.Clicks),
+		}
+	}
+
+	return resp, nil
+}
+
+func (s *ShortenerService) GetOperation(ctx context.Context, request *pb.GetOperationRequest) (*pb.GetOperationResponse, error) {
+	uid := user.ReadUID(ctx)
+	op, err := s.store.ReadOperation(ctx, uid, request.GetId())
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, internalError(err)
+	}
+
+	resp := &pb.GetOperationResponse{
+		Id:        op.ID,
+		Status:    string(op.Status),
+		CreatedAt: timestamppb.New(op.CreatedAt),
+		Error:     op.Error,
+		Items:     make([]*pb.GetOperationResponseItem, len(op.Items)),
+	}
+	if op.CompletedAt != nil {
+		resp.CompletedAt = timestamppb.New(*op.CompletedAt)
+	}
+	for i, item := range op.Items {
+		resp.Items[i] = &pb.GetOperationResponseItem{
+			Id:      item.ID,
+			Outcome: string(item.Outcome),
 		}
 	}
 
 	return resp, nil
 }
+
+func (s *ShortenerService) UpdateURL(ctx context.Context, request *pb.UpdateURLRequest) (*pb.URLVersion, error) {
+	uid := user.ReadUID(ctx)
+	v, err := s.store.UpdateURL(ctx, uid, request.GetId(), request.GetOriginalUrl())
+	if err != nil {
+		return nil, editError(err)
+	}
+	return urlVersion(*v), nil
+}
+
+func (s *ShortenerService) ListURLVersions(ctx context.Context, request *pb.ListURLVersionsRequest) (*pb.ListURLVersionsResponse, error) {
+	uid := user.ReadUID(ctx)
+	history, err := s.store.ReadHistory(ctx, uid, request.GetId())
+	if err != nil {
+		return nil, editError(err)
+	}
+
+	resp := &pb.ListURLVersionsResponse{
+		Versions: make([]*pb.URLVersion, len(history)),
+	}
+	for i, v := range history {
+		resp.Versions[i] = urlVersion(v)
+	}
+	return resp, nil
+}
+
+func (s *ShortenerService) RollbackURL(ctx context.Context, request *pb.RollbackURLRequest) (*pb.URLVersion, error) {
+	uid := user.ReadUID(ctx)
+	v, err := s.store.RollbackURL(ctx, uid, request.GetId(), int(request.GetVersion()))
+	if err != nil {
+		return nil, editError(err)
+	}
+	return urlVersion(*v), nil
+}
+
+func urlVersion(v store.URLVersion) *pb.URLVersion {
+	return &pb.URLVersion{
+		Version:     int32(v.Version),
+		OriginalUrl: v.OriginalURL,
+		CreatedAt:   timestamppb.New(v.CreatedAt),
+	}
+}
+
+// editError maps store errors of the link editing to the status codes
+func editError(err error) error {
+	var errConflict *store.ConflictError
+	switch {
+	case errors.As(err, &errConflict):
+		return status.Errorf(codes.AlreadyExists, "url is shortened as %s", errConflict.ExistingURL)
+	case errors.Is(err, store.ErrNotFound), errors.Is(err, store.ErrDeleted), errors.Is(err, store.ErrExpired):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, store.ErrForbiddenTarget):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, store.ErrBadInput):
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return internalError(err)
+	}
+}
+
+// expiration resolves requested expiration time or ttl, unset fields mean no expiration
+func expiration(ts *timestamppb.Timestamp, ttl *durationpb.Duration, now time.Time) (*time.Time, error) {
+	var expiresAt *time.Time
+	if ts != nil {
+		if err := ts.CheckValid(); err != nil {
+			return nil, fmt.Errorf("%w: %v", store.ErrBadInput, err)
+		}
+		t := ts.AsTime()
+		expiresAt = &t
+	}
+
+	var d time.Duration
+	if ttl != nil {
+		if err := ttl.CheckValid(); err != nil {
+			return nil, fmt.Errorf("%w: %v", store.ErrBadInput, err)
+		}
+		d = ttl.AsDuration()
+	}
+
+	return store.ResolveExpiration(expiresAt, d, now)
+}
+
+// internalError converts store error into grpc status error, context errors keep their own codes
+func internalError(err error) error {
+	if st := status.FromContextError(err); st.Code() != codes.Unknown {
+		return st.Err()
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// ExportURLs streams all active links of the user
+func (s *ShortenerService) ExportURLs(request *pb.ExportURLsRequest, stream pb.Shortener_ExportURLsServer) error {
+	ctx := stream.Context()
+	uid := user.ReadUID(ctx)
+
+	q := store.UserDataQuery{Limit: store.MaxPageLimit}
+	for {
+		page, err := s.store.ReadUserData(ctx, uid, q)
+		if err != nil {
+			return internalError(err)
+		}
+		for _, rec := range page.Records {
+			item := &pb.ExportedURL{
+				Id:          rec.ID,
+				ShortUrl:    rec.ShortURL,
+				OriginalUrl: rec.OriginalURL,
+				CreatedAt:   timestamppb.New(rec.CreatedAt),
+			}
+			if rec.ExpiresAt != nil {
+				item.ExpiresAt = timestamppb.New(*rec.ExpiresAt)
+			}
+			if err := stream.Send(item); err != nil {
+				return err
+			}
+		}
+		if page.NextCursor == "" {
+			return nil
+		}
+		q.Cursor = page.NextCursor
+	}
+}
+
+// ImportURLs stores the streamed links in chunks keeping their ids as custom aliases where they are free
+func (s *ShortenerService) ImportURLs(stream pb.Shortener_ImportURLsServer) error {
+	const chunkSize = 500
+
+	ctx := stream.Context()
+	uid := user.ReadUID(ctx)
+
+	resp := &pb.ImportURLsResponse{}
+	chunk := make([]store.Record, 0, chunkSize)
+	flush := func() error {
+		if len(chunk) == 0 {
+			return nil
+		}
+		aliases := make([]string, len(chunk))
+		for i := range chunk {
+			aliases[i] = chunk[i].ID
+		}
+		written, err := store.Import(ctx, s.store, uid, chunk)
+		if err != nil {
+			return internalError(err)
+		}
+		for i, rec := range written {
+			item := &pb.ImportURLResult{OriginalUrl: rec.OriginalURL, ShortUrl: rec.ShortURL}
+			if rec.Err != nil {
+				item.Code = store.ItemErrorCode(rec.Err)
+				item.Error = rec.Err.Error()
+			} else {
+				item.AliasReplaced = aliases[i] != "" && rec.ID != aliases[i]
+			}
+			resp.Items = append(resp.Items, item)
+		}
+		chunk = chunk[:0]
+		return nil
+	}
+
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		rec := store.Record{ID: req.GetId(), OriginalURL: req.GetOriginalUrl()}
+		rec.ExpiresAt, rec.Err = expiration(req.GetExpiresAt(), nil, time.Now())
+		chunk = append(chunk, rec)
+		if len(chunk) == chunkSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+
+	return stream.SendAndClose(resp)
+}
@@ -0,0 +1,377 @@
+/*
+Package policy decides which destinations may be shortened.
+*/
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang.org/x/net/idna"
+	"net"
+	"net/url"
+	"os"
+	"shortener/internal/app/logger"
+	"shortener/internal/app/service/store"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrAlreadyStarted = errors.New("policy watcher already started")
+	ErrNotStarted     = errors.New("policy watcher not started")
+)
+
+// DefaultSchemes allowed if no schemes are configured
+var DefaultSchemes = []string{"http", "https"}
+
+// Rules are the domain lists of the policy file.
+// Patterns are host names matched exactly, "*.example.org" matches all subdomains of example.org and "*" matches any host.
+type Rules struct {
+	// Allow lists the only hosts which may be shortened, empty list allows all hosts
+	Allow []string `json:"allow"`
+	// Deny lists hosts which may not be shortened, deny wins over allow
+	Deny []string `json:"deny"`
+}
+
+// Policy checks destinations of the new links. Rejected are urls with schemes not in the allow-list,
+// hosts denied by the rules file, hosts of the service itself creating redirect loops and
+// loopback, private or link-local ip literals.
+//
+// Rules file is reloaded by Reload or periodically once the watcher is started, invalid file keeps the previous rules.
+type Policy struct {
+	schemes        map[string]struct{}
+	selfHosts      map[string]struct{}
+	file           string
+	reloadInterval time.Duration
+	log            logger.Logger
+
+	mu      sync.RWMutex
+	rules   Rules
+	modTime time.Time
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// Option is a functional parameter of the Policy
+type Option func(*Policy)
+
+// New creates the policy and loads the rules file if it is set
+func New(opts ...Option) (*Policy, error) {
+	p := &Policy{
+		schemes:   make(map[string]struct{}),
+		selfHosts: make(map[string]struct{}),
+		log:       logger.Global().Component("Policy"),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if len(p.schemes) == 0 {
+		WithSchemes(DefaultSchemes...)(p)
+	}
+
+	if p.file != "" {
+		if err := p.Reload(); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// WithSchemes sets the allowed url schemes
+func WithSchemes(schemes ...string) Option {
+	return func(p *Policy) {
+		for _, s := range schemes {
+			if s = strings.ToLower(strings.TrimSpace(s)); s != "" {
+				p.schemes[s] = struct{}{}
+			}
+		}
+	}
+}
+
+// WithSelfURL rejects links to the host of the url, it is the base url of the short links
+func WithSelfURL(raw string) Option {
+	return func(p *Policy) {
+		if u, err := url.Parse(raw); err == nil && u.Hostname() != "" {
+			p.selfHosts[normalizeHost(u.Hostname())] = struct{}{}
+		}
+	}
+}
+
+// WithRulesFile sets path of the json file with Rules
+func WithRulesFile(path string) Option {
+	return func(p *Policy) {
+		p.file = path
+	}
+}
+
+// WithReloadInterval sets how often the started watcher checks the rules file for changes
+func WithReloadInterval(d time.Duration) Option {
+	return func(p *Policy) {
+		p.reloadInterval = d
+	}
+}
+
+// Check returns ErrForbiddenTarget if the url may not be shortened and ErrBadInput if it is not valid url
+func (p *Policy) Check(raw string) error {
+	if err := store.ValidateURL(raw); err != nil {
+		return err
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("%w: invalid url %q", store.ErrBadInput, raw)
+	}
+
+	scheme := strings.ToLower(u.Scheme)
+	if _, ok := p.schemes[scheme]; !ok {
+		return fmt.Errorf("%w: scheme %q is not allowed", store.ErrForbiddenTarget, scheme)
+	}
+
+	host := normalizeHost(u.Hostname())
+	if _, ok := p.selfHosts[host]; ok {
+		return fmt.Errorf("%w: link to the shortener itself", store.ErrForbiddenTarget)
+	}
+	if isLocal(host) {
+		return fmt.Errorf("%w: host %q is local", store.ErrForbiddenTarget, host)
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if match(p.rules.Deny, host) {
+		return fmt.Errorf("%w: host %q is denied", store.ErrForbiddenTarget, host)
+	}
+	if len(p.rules.Allow) > 0 && !match(p.rules.Allow, host) {
+		return fmt.Errorf("%w: host %q is not allowed", store.ErrForbiddenTarget, host)
+	}
+	return nil
+}
+
+// Rules returns the active rules
+func (p *Policy) Rules() Rules {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.rules
+}
+
+// Reload reads the rules file, the active rules are kept if the file is invalid
+func (p *Policy) Reload() error {
+	if p.file == "" {
+		return nil
+	}
+
+	info, err := os.Stat(p.file)
+	if err != nil {
+		return fmt.Errorf("policy file: %w", err)
+	}
+	data, err := os.ReadFile(p.file)
+	if err != nil {
+		return fmt.Errorf("policy file: %w", err)
+	}
+	rules, err := parseRules(data)
+	if err != nil {
+		return fmt.Errorf("policy file %s: %w", p.file, err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.rules = rules
+	p.modTime = info.ModTime()
+	return nil
+}
+
+// Start watching the rules file, nothing is watched without the file or the reload interval
+func (p *Policy) Start() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.stop != nil {
+		return ErrAlreadyStarted
+	}
+	p.stop = make(chan struct{})
+	p.done = make(chan struct{})
+	if p.file == "" || p.reloadInterval <= 0 {
+		close(p.done)
+		return nil
+	}
+	go p.watch(p.stop, p.done)
+
+	return nil
+}
+
+// Stop watching the rules file
+func (p *Policy) Stop() error {
+	p.mu.Lock()
+	if p.stop == nil {
+		p.mu.Unlock()
+		return ErrNotStarted
+	}
+	close(p.stop)
+	done := p.done
+	p.stop, p.done = nil, nil
+	p.mu.Unlock()
+
+	<-done
+	return nil
+}
+
+// watch reloads the rules file once its modification time is changed
+func (p *Policy) watch(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(p.reloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(p.file)
+		if err != nil {
+			p.log.Warn().Err(err).Msg("Policy file check failure")
+			continue
+		}
+		p.mu.RLock()
+		changed := !info.ModTime().Equal(p.modTime)
+		p.mu.RUnlock()
+		if !changed {
+			continue
+		}
+
+		if err := p.Reload(); err != nil {
+			p.log.Error().Err(err).Msg("Policy reload failure, previous rules are kept")
+			continue
+		}
+		rules := p.Rules()
+		p.log.Info().Int("allow", len(rules.Allow)).Int("deny", len(rules.Deny)).Msg("Policy reloaded")
+	}
+}
+
+// parseRules decodes the rules file and normalizes the patterns
+func parseRules(data []byte) (Rules, error) {
+	var rules Rules
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return Rules{}, fmt.Errorf("decode: %w", err)
+	}
+
+	var err error
+	if rules.Allow, err = normalizePatterns(rules.Allow); err != nil {
+		return Rules{}, fmt.Errorf("allow: %w", err)
+	}
+	if rules.Deny, err = normalizePatterns(rules.Deny); err != nil {
+		return Rules{}, fmt.Errorf("deny: %w", err)
+	}
+	return rules, nil
+}
+
+func normalizePatterns(patterns []string) ([]string, error) {
+	res := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if pattern == "*" {
+			res = append(res, pattern)
+			continue
+		}
+		host := strings.TrimPrefix(pattern, "*.")
+		if host == "" || strings.Contains(host, "*") {
+			return nil, fmt.Errorf("invalid pattern %q, wildcard is allowed only as the first label", pattern)
+		}
+		res = append(res, pattern[:len(pattern)-len(host)]+normalizeHost(host))
+	}
+	return res, nil
+}
+
+// match reports if the host matches any of the patterns
+func match(patterns []string, host string) bool {
+	for _, pattern := range patterns {
+		if suffix := strings.TrimPrefix(pattern, "*"); suffix != pattern {
+			if suffix == "" || strings.HasSuffix(host, suffix) {
+				return true
+			}
+		} else if host == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeHost lower-cases the host, removes the trailing dot and converts it to punycode
+func normalizeHost(host string) string {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if ascii, err := idna.Lookup.ToASCII(host); err == nil {
+		host = ascii
+	}
+	return host
+}
+
+// isLocal reports if the host is localhost or ip literal of the loopback, private, link-local or unspecified address
+func isLocal(host string) bool {
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+
+	ip := parseIP(host)
+	if ip == nil {
+		return false
+	}
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast()
+}
+
+// parseIP parses ip literal including ipv6 with zone and ipv4 in the numeric forms accepted by browsers,
+// e.g. 2130706433 or 0x7f.1 for 127.0.0.1
+func parseIP(host string) net.IP {
+	if i := strings.IndexByte(host, '%'); i >= 0 {
+		host = host[:i]
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return ip
+	}
+
+	parts := strings.Split(host, ".")
+	if len(parts) > 4 {
+		return nil
+	}
+	nums := make([]uint64, len(parts))
+	for i, part := range parts {
+		base := 10
+		switch {
+		case len(part) > 2 && (part[:2] == "0x" || part[:2] == "0X"):
+			part, base = part[2:], 16
+		case len(part) > 1 && part[0] == '0':
+			part, base = part[1:], 8
+		}
+		n, err := strconv.ParseUint(part, base, 32)
+		if err != nil {
+			return nil
+		}
+		nums[i] = n
+	}
+
+	// all parts except the last one are single bytes, the last one fills the rest of the address
+	var addr uint64
+	for _, n := range nums[:len(nums)-1] {
+		if n > 0xff {
+			return nil
+		}
+		addr = addr<<8 | n
+	}
+	rest := uint(4-len(nums)+1) * 8
+	last := nums[len(nums)-1]
+	if last >= 1<<rest {
+		return nil
+	}
+	addr = addr<<rest | last
+
+	return net.IPv4(byte(addr>>24), byte(addr>>16), byte(addr>>8), byte(addr))
+}
@@ -0,0 +1,150 @@
+package policy
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"shortener/internal/app/service/store"
+	"testing"
+	"time"
+)
+
+func writeRules(t *testing.T, path string, data string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+}
+
+func TestPolicy_Check(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	writeRules(t, path, `{"allow": ["example.org", "*.Example.com", "xn--e1afmkfd.xn--p1ai", "evil.example.com"], "deny": ["evil.example.com"]}`)
+
+	p, err := New(WithSelfURL("http://Short.example.com:8080/"), WithRulesFile(path))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		url  string
+		want error
+	}{
+		{"allowed", "https://example.org/path", nil},
+		{"allowed upper case", "HTTPS://EXAMPLE.ORG/", nil},
+		{"allowed subdomain", "http://a.b.example.com/", nil},
+		{"allowed unicode", "https://пример.рф/", nil},
+		{"empty", "", store.ErrBadInput},
+		{"invalid", "example.org", store.ErrBadInput},
+		{"javascript", "javascript://example.org/%0Aalert(1)", store.ErrForbiddenTarget},
+		{"ftp", "ftp://example.org/file", store.ErrForbiddenTarget},
+		{"apex is not subdomain", "https://example.com/", store.ErrForbiddenTarget},
+		{"not allowed", "https://example.net/", store.ErrForbiddenTarget},
+		{"deny wins", "https://evil.example.com/", store.ErrForbiddenTarget},
+		{"self", "https://short.example.com./xxx", store.ErrForbiddenTarget},
+		{"localhost", "http://localhost:8080/", store.ErrForbiddenTarget},
+		{"loopback", "http://127.0.0.1/", store.ErrForbiddenTarget},
+		{"private", "http://10.1.2.3/", store.ErrForbiddenTarget},
+		{"link-local", "http://169.254.169.254/latest/meta-data", store.ErrForbiddenTarget},
+		{"ipv6 loopback", "http://[::1]:8080/", store.ErrForbiddenTarget},
+		{"ipv6 private", "http://[fd00::1]/", store.ErrForbiddenTarget},
+		{"mapped ipv4", "http://[::ffff:192.168.0.1]/", store.ErrForbiddenTarget},
+		{"decimal ipv4", "http://2130706433/", store.ErrForbiddenTarget},
+		{"hex ipv4", "http://0x7f.1/", store.ErrForbiddenTarget},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := p.Check(tt.url)
+			if tt.want == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicy_CheckDefaults(t *testing.T) {
+	p, err := New(WithSchemes(""))
+	require.NoError(t, err)
+
+	assert.NoError(t, p.Check("https://example.org/"))
+	assert.NoError(t, p.Check("http://8.8.8.8/"))
+	assert.ErrorIs(t, p.Check("mailto://user@example.org"), store.ErrForbiddenTarget)
+
+	p, err = New(WithSchemes("HTTPS", " ftp "))
+	require.NoError(t, err)
+	assert.NoError(t, p.Check("ftp://example.org/file"))
+	assert.ErrorIs(t, p.Check("http://example.org/"), store.ErrForbiddenTarget)
+}
+
+func TestPolicy_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+
+	_, err := New(WithRulesFile(path))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	writeRules(t, path, `{"deny": ["*"]}`)
+	p, err := New(WithRulesFile(path))
+	require.NoError(t, err)
+	assert.ErrorIs(t, p.Check("https://example.org/"), store.ErrForbiddenTarget)
+
+	writeRules(t, path, `{"deny": ["*.example.*"]}`)
+	assert.Error(t, p.Reload())
+	writeRules(t, path, `{"deny": `)
+	assert.Error(t, p.Reload())
+	assert.Equal(t, Rules{Allow: []string{}, Deny: []string{"*"}}, p.Rules(), "invalid file keeps the rules")
+
+	writeRules(t, path, `{"deny": ["*.example.net"]}`)
+	require.NoError(t, p.Reload())
+	assert.NoError(t, p.Check("https://example.org/"))
+	assert.ErrorIs(t, p.Check("https://www.example.net/"), store.ErrForbiddenTarget)
+}
+
+func TestPolicy_Watch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	writeRules(t, path, `{}`)
+
+	p, err := New(WithRulesFile(path), WithReloadInterval(10*time.Millisecond))
+	require.NoError(t, err)
+	require.NoError(t, p.Start())
+	assert.ErrorIs(t, p.Start(), ErrAlreadyStarted)
+
+	writeRules(t, path, `{"deny": ["example.org"]}`)
+	// modification time resolution of some file systems is coarse
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Second)))
+	assert.Eventually(t, func() bool {
+		return p.Check("https://example.org/") != nil
+	}, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, p.Stop())
+	assert.ErrorIs(t, p.Stop(), ErrNotStarted)
+}
+
+func TestParseIP(t *testing.T) {
+	tests := []struct {
+		host string
+		want string
+	}{
+		{"127.0.0.1", "127.0.0.1"},
+		{"2130706433", "127.0.0.1"},
+		{"0x7f000001", "127.0.0.1"},
+		{"0177.0.0.1", "127.0.0.1"},
+		{"127.1", "127.0.0.1"},
+		{"10.1.258", "10.1.1.2"},
+		{"fe80::1%eth0", "fe80::1"},
+		{"256.0.0.1", ""},
+		{"1.2.3.4.5", ""},
+		{"1..2", ""},
+		{"example.org", ""},
+		{"0x.1", ""},
+		{"4294967296", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			ip := parseIP(tt.host)
+			if tt.want == "" {
+				assert.Nil(t, ip)
+			} else {
+				assert.Equal(t, tt.want, ip.String())
+			}
+		})
+	}
+}
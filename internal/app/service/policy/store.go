@@ -0,0 +1,176 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"shortener/internal/app/service/store"
+	"shortener/internal/app/service/threat"
+)
+
+// Store checks destinations of all the writes by the policy before they reach the backend
+type Store struct {
+	store.Backend
+	policy  *Policy
+	threats threat.Checker
+}
+
+var _ store.Backend = (*Store)(nil)
+
+// StoreOption is a functional parameter of the Store
+type StoreOption func(*Store)
+
+// NewStore wraps the backend with the policy
+func NewStore(b store.Backend, p *Policy, opts ...StoreOption) *Store {
+	s := &Store{Backend: b, policy: p}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// WithThreatChecker rejects destinations listed as threats by the checker
+func WithThreatChecker(c threat.Checker) StoreOption {
+	return func(s *Store) {
+		s.threats = c
+	}
+}
+
+// WriteURL stores the url allowed by the policy
+func (s *Store) WriteURL(ctx context.Context, url string, uid string, opts ...store.WriteOption) (string, error) {
+	if err := s.check(ctx, url); err != nil {
+		return "", err
+	}
+	return s.Backend.WriteURL(ctx, url, uid, opts...)
+}
+
+// WriteAlias stores the url allowed by the policy
+func (s *Store) WriteAlias(ctx context.Context, url string, alias string, uid string, opts ...store.WriteOption) (string, error) {
+	if err := s.check(ctx, url); err != nil {
+		return "", err
+	}
+	return s.Backend.WriteAlias(ctx, url, alias, uid, opts...)
+}
+
+// BatchWrite fails records rejected by the policy, so the backend skips them or fails the atomic batch
+func (s *Store) BatchWrite(ctx context.Context, uid string, in []store.Record, opts ...store.WriteOption) ([]store.Record, error) {
+	checked := make([]store.Record, len(in))
+	copy(checked, in)
+	for i := range checked {
+		if checked[i].Err == nil {
+			checked[i].Err = s.check(ctx, checked[i].OriginalURL)
+		}
+	}
+	return s.Backend.BatchWrite(ctx, uid, checked, opts...)
+}
+
+// UpdateURL sets the new destination allowed by the policy
+func (s *Store) UpdateURL(ctx context.Context, uid string, id string, url string) (*store.URLVersion, error) {
+	if err := s.check(ctx, url); err != nil {
+		return nil, err
+	}
+	return s.Backend.UpdateURL(ctx, uid, id, url)
+}
+
+// RollbackURL sets the previous destination if it is still allowed by the policy
+func (s *Store) RollbackURL(ctx context.Context, uid string, id string, version int) (*store.URLVersion, error) {
+	history, err := s.Backend.ReadHistory(ctx, uid, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range history {
+		if v.Version != version {
+			continue
+		}
+		if err := s.check(ctx, v.OriginalURL); errors.Is(err, store.ErrForbiddenTarget) {
+			return nil, fmt.Errorf("version %d: %w", version, err)
+		}
+		break
+	}
+	return s.Backend.RollbackURL(ctx, uid, id, version)
+}
+
+// Restore reactivates the rows whose destinations are still allowed, the other rows get OutcomeForbidden
+func (s *Store) Restore(ctx context.Context, uid string, ids ...string) ([]store.OperationItem, error) {
+	items := make([]store.OperationItem, len(ids))
+	// positions of the ids passed to the backend
+	passed := make([]int, 0, len(ids))
+	for i, id := range ids {
+		items[i] = store.OperationItem{ID: id}
+		history, err := s.Backend.ReadHistory(ctx, uid, id)
+		if err != nil {
+			// missing and invalid ids get their outcomes from the backend
+			if errors.Is(err, store.ErrNotFound) || errors.Is(err, store.ErrBadInput) {
+				passed = append(passed, i)
+				continue
+			}
+			return nil, fmt.Errorf("id %q: %w", id, err)
+		}
+		// the current destination goes first
+		if len(history) > 0 {
+			err := s.check(ctx, history[0].OriginalURL)
+			if errors.Is(err, store.ErrForbiddenTarget) {
+				items[i].Outcome = store.OutcomeForbidden
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("id %q: %w", id, err)
+			}
+		}
+		passed = append(passed, i)
+	}
+	if len(passed) == len(ids) {
+		return s.Backend.Restore(ctx, uid, ids...)
+	}
+	if len(passed) == 0 {
+		return items, nil
+	}
+
+	allowed := make([]string, len(passed))
+	for j, i := range passed {
+		allowed[j] = ids[i]
+	}
+	restored, err := s.Backend.Restore(ctx, uid, allowed...)
+	if err != nil {
+		return nil, err
+	}
+	for j, i := range passed {
+		items[i] = restored[j]
+	}
+	return items, nil
+}
+
+// check the url by the policy and the threat lists
+func (s *Store) check(ctx context.Context, url string) error {
+	if err := s.policy.Check(url); err != nil {
+		return err
+	}
+	if s.threats == nil {
+		return nil
+	}
+	listed, err := s.threats.Check(ctx, url)
+	if err != nil {
+		return fmt.Errorf("threat check: %w", err)
+	}
+	if listed != "" {
+		return fmt.Errorf("%w: url is listed as %s", store.ErrForbiddenTarget, listed)
+	}
+	return nil
+}
+
+// Start the policy watcher and the backend
+func (s *Store) Start() error {
+	if err := s.policy.Start(); err != nil {
+		return fmt.Errorf("policy start: %w", err)
+	}
+	return s.Backend.Start()
+}
+
+// Stop the backend and the policy watcher
+func (s *Store) Stop() error {
+	err := s.Backend.Stop()
+	if perr := s.policy.Stop(); perr != nil && err == nil {
+		err = fmt.Errorf("policy stop: %w", perr)
+	}
+	return err
+}
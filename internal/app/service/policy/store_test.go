@@ -0,0 +1,156 @@
+package policy
+
+import (
+	"context"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"shortener/internal/app/service/store"
+	storemock "shortener/internal/app/service/store/mock"
+	"testing"
+)
+
+func TestStore_Write(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	p, err := New(WithSelfURL("http://localhost:8080"))
+	require.NoError(t, err)
+
+	b := storemock.NewMockBackend(ctrl)
+	b.EXPECT().WriteURL(gomock.Any(), "https://example.org", "uid").Return("http://localhost:8080/a", nil)
+	b.EXPECT().WriteAlias(gomock.Any(), "https://example.org/docs", "docs", "uid").Return("http://localhost:8080/docs", nil)
+	s := NewStore(b, p)
+
+	ctx := context.Background()
+	shortURL, err := s.WriteURL(ctx, "https://example.org", "uid")
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:8080/a", shortURL)
+
+	_, err = s.WriteURL(ctx, "http://localhost:8080/a", "uid")
+	assert.ErrorIs(t, err, store.ErrForbiddenTarget)
+
+	shortURL, err = s.WriteAlias(ctx, "https://example.org/docs", "docs", "uid")
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:8080/docs", shortURL)
+
+	_, err = s.WriteAlias(ctx, "file://example.org/etc/passwd", "passwd", "uid")
+	assert.ErrorIs(t, err, store.ErrForbiddenTarget)
+}
+
+func TestStore_BatchWrite(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	p, err := New()
+	require.NoError(t, err)
+
+	in := []store.Record{
+		{CorrelationID: "1", OriginalURL: "https://example.org"},
+		{CorrelationID: "2", OriginalURL: "http://192.168.0.1/admin"},
+		{CorrelationID: "3", OriginalURL: "http://127.0.0.1/", Err: store.ErrBadInput},
+	}
+
+	b := storemock.NewMockBackend(ctrl)
+	b.EXPECT().BatchWrite(gomock.Any(), "uid", gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ string, records []store.Record, _ ...store.WriteOption) ([]store.Record, error) {
+			require.Len(t, records, 3)
+			assert.NoError(t, records[0].Err)
+			assert.ErrorIs(t, records[1].Err, store.ErrForbiddenTarget)
+			assert.Equal(t, store.ErrBadInput, records[2].Err, "failed records are kept")
+			return records, nil
+		},
+	)
+
+	_, err = NewStore(b, p).BatchWrite(context.Background(), "uid", in, store.WithAtomic())
+	require.NoError(t, err)
+	assert.NoError(t, in[1].Err, "input is not changed")
+}
+
+func TestStore_Edit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	p, err := New()
+	require.NoError(t, err)
+
+	b := storemock.NewMockBackend(ctrl)
+	b.EXPECT().UpdateURL(gomock.Any(), "uid", "a", "https://example.org/new").Return(&store.URLVersion{Version: 3}, nil)
+	b.EXPECT().ReadHistory(gomock.Any(), "uid", "a").Return([]store.URLVersion{
+		{Version: 3, OriginalURL: "https://example.org/new"},
+		{Version: 2, OriginalURL: "https://example.org/old"},
+		{Version: 1, OriginalURL: "http://10.0.0.1/"},
+	}, nil).Times(2)
+	b.EXPECT().RollbackURL(gomock.Any(), "uid", "a", 2).Return(&store.URLVersion{Version: 4}, nil)
+	s := NewStore(b, p)
+
+	ctx := context.Background()
+	_, err = s.UpdateURL(ctx, "uid", "a", "https://example.org/new")
+	require.NoError(t, err)
+	_, err = s.UpdateURL(ctx, "uid", "a", "http://10.0.0.1/")
+	assert.ErrorIs(t, err, store.ErrForbiddenTarget)
+
+	v, err := s.RollbackURL(ctx, "uid", "a", 2)
+	require.NoError(t, err)
+	assert.Equal(t, 4, v.Version)
+	_, err = s.RollbackURL(ctx, "uid", "a", 1)
+	assert.ErrorIs(t, err, store.ErrForbiddenTarget)
+}
+
+// threatStub lists the urls of the map
+type threatStub map[string]string
+
+func (t threatStub) Check(_ context.Context, url string) (string, error) {
+	return t[url], nil
+}
+
+func TestStore_ThreatChecker(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	p, err := New()
+	require.NoError(t, err)
+
+	b := storemock.NewMockBackend(ctrl)
+	b.EXPECT().WriteURL(gomock.Any(), "https://example.org", "uid").Return("http://localhost:8080/a", nil)
+	s := NewStore(b, p, WithThreatChecker(threatStub{"https://evil.example.org/": "malware"}))
+
+	_, err = s.WriteURL(context.Background(), "https://example.org", "uid")
+	require.NoError(t, err)
+	_, err = s.WriteURL(context.Background(), "https://evil.example.org/", "uid")
+	assert.ErrorIs(t, err, store.ErrForbiddenTarget)
+	assert.Contains(t, err.Error(), "malware")
+}
+
+func TestStore_Restore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	p, err := New()
+	require.NoError(t, err)
+
+	b := storemock.NewMockBackend(ctrl)
+	b.EXPECT().ReadHistory(gomock.Any(), "uid", "a").Return([]store.URLVersion{{Version: 2, OriginalURL: "https://example.org/a"}}, nil)
+	b.EXPECT().ReadHistory(gomock.Any(), "uid", "b").Return([]store.URLVersion{{Version: 1, OriginalURL: "http://10.0.0.1/"}}, nil)
+	b.EXPECT().ReadHistory(gomock.Any(), "uid", "c").Return([]store.URLVersion{{Version: 1, OriginalURL: "https://evil.example.org/"}}, nil)
+	b.EXPECT().ReadHistory(gomock.Any(), "uid", "d").Return(nil, store.ErrNotFound)
+	b.EXPECT().Restore(gomock.Any(), "uid", "a", "d").Return([]store.OperationItem{
+		{ID: "a", Outcome: store.OutcomeRestored},
+		{ID: "d", Outcome: store.OutcomeNotFound},
+	}, nil)
+	s := NewStore(b, p, WithThreatChecker(threatStub{"https://evil.example.org/": "phishing"}))
+
+	items, err := s.Restore(context.Background(), "uid", "a", "b", "c", "d")
+	require.NoError(t, err)
+	assert.Equal(t, []store.OperationItem{
+		{ID: "a", Outcome: store.OutcomeRestored},
+		{ID: "b", Outcome: store.OutcomeForbidden},
+		{ID: "c", Outcome: store.OutcomeForbidden},
+		{ID: "d", Outcome: store.OutcomeNotFound},
+	}, items)
+
+	b.EXPECT().ReadHistory(gomock.Any(), "uid", "b").Return([]store.URLVersion{{Version: 1, OriginalURL: "http://10.0.0.1/"}}, nil)
+	items, err = s.Restore(context.Background(), "uid", "b")
+	require.NoError(t, err)
+	assert.Equal(t, []store.OperationItem{{ID: "b", Outcome: store.OutcomeForbidden}}, items, "backend is not called without allowed ids")
+}
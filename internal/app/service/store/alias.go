@@ -0,0 +1,43 @@
+package store
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const aliasMaxLength = 64
+
+var (
+	aliasRe = regexp.MustCompile(`^[0-9A-Za-z_-]+$`)
+
+	// reservedAliases are used by the application routes
+	reservedAliases = map[string]struct{}{
+		"api":   {},
+		"ping":  {},
+		"debug": {},
+	}
+)
+
+// ValidateAlias checks if input is acceptable as a custom short id
+func ValidateAlias(alias string) error {
+	if alias == "" {
+		return fmt.Errorf("%w: empty alias", ErrBadInput)
+	}
+	if len(alias) > aliasMaxLength {
+		return fmt.Errorf("%w: alias is longer than %d chars", ErrBadInput, aliasMaxLength)
+	}
+	if !aliasRe.MatchString(alias) {
+		return fmt.Errorf("%w: invalid alias %q", ErrBadInput, alias)
+	}
+	if Reserved(alias) {
+		return fmt.Errorf("%w: %q", ErrReservedAlias, alias)
+	}
+	return nil
+}
+
+// Reserved reports if the short id is shadowed by the application routes, generated ids must skip such ids too
+func Reserved(id string) bool {
+	_, ok := reservedAliases[strings.ToLower(id)]
+	return ok
+}
@@ -0,0 +1,61 @@
+package store
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestReserved(t *testing.T) {
+	for _, id := range []string{"api", "PING", "Debug"} {
+		if !Reserved(id) {
+			t.Errorf("Reserved(%q) = false, want true", id)
+		}
+	}
+	for _, id := range []string{"pinh", "apis", ""} {
+		if Reserved(id) {
+			t.Errorf("Reserved(%q) = true, want false", id)
+		}
+	}
+}
+
+func Test_ValidateAlias(t *testing.T) {
+	tests := []struct {
+		name    string
+		alias   string
+		wantErr error
+	}{
+		{
+			name:    "empty alias",
+			alias:   "",
+			wantErr: ErrBadInput,
+		},
+		{
+			name:    "too long alias",
+			alias:   strings.Repeat("a", aliasMaxLength+1),
+			wantErr: ErrBadInput,
+		},
+		{
+			name:    "invalid chars",
+			alias:   "spring/sale",
+			wantErr: ErrBadInput,
+		},
+		{
+			name:    "reserved alias",
+			alias:   "Api",
+			wantErr: ErrReservedAlias,
+		},
+		{
+			name:    "proper alias",
+			alias:   "spring-sale_2022",
+			wantErr: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ValidateAlias(tt.alias); !errors.Is(got, tt.wantErr) {
+				t.Errorf("ValidateAlias() = %v, wantErr %v", got, tt.wantErr)
+			}
+		})
+	}
+}
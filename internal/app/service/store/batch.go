@@ -0,0 +1,100 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Codes of the batch record failures reported to the clients
+const (
+	ItemErrBadInput   = "bad_input"
+	ItemErrConflict   = "conflict"
+	ItemErrAliasTaken = "alias_taken"
+	ItemErrForbidden  = "forbidden_target"
+	ItemErrInternal   = "internal"
+)
+
+// ItemErrorCode classifies the record failure, empty code is returned for nil error
+func ItemErrorCode(err error) string {
+	var errConflict *ConflictError
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &errConflict):
+		return ItemErrConflict
+	case errors.Is(err, ErrAliasTaken):
+		return ItemErrAliasTaken
+	case errors.Is(err, ErrForbiddenTarget):
+		return ItemErrForbidden
+	case errors.Is(err, ErrBadInput):
+		return ItemErrBadInput
+	default:
+		return ItemErrInternal
+	}
+}
+
+// PrepareBatch validates the batch records, invalid records get Err set. Records already having Err are kept failed.
+// Returned slice maps every record to the index of the first valid record with the same url,
+// so stores write only the records with first[i] == i and nil Err and share the result with the repeats.
+// Repeats are written as separate records if the links are not deduplicated.
+func PrepareBatch(in []Record, now time.Time, dedup Dedup) []int {
+	first := make([]int, len(in))
+	seen := make(map[string]int, len(in))
+	for i := range in {
+		first[i] = i
+		if in[i].Err == nil {
+			in[i].Err = validateRecord(in[i], now)
+		}
+		if in[i].Err != nil {
+			continue
+		}
+		// batch records share the owner, so the url identifies the repeat in any scope
+		key, ok := dedup.Key("", in[i].OriginalURL)
+		if !ok {
+			continue
+		}
+		if j, ok := seen[key]; ok {
+			first[i] = j
+			continue
+		}
+		seen[key] = i
+	}
+	return first
+}
+
+// FinishBatch copies the results of the written records to their repeats
+func FinishBatch(in []Record, first []int) {
+	for i, j := range first {
+		if i == j {
+			continue
+		}
+		in[i].ID = in[j].ID
+		in[i].ShortURL = in[j].ShortURL
+		in[i].Err = in[j].Err
+	}
+}
+
+// BatchError returns the failure of the first failed record, nil if all the records are written
+func BatchError(in []Record) error {
+	for i := range in {
+		if in[i].Err != nil {
+			return fmt.Errorf("batch item %d: %w", i, in[i].Err)
+		}
+	}
+	return nil
+}
+
+// validateRecord checks url, custom alias and expiration of the new record
+func validateRecord(rec Record, now time.Time) error {
+	if err := ValidateURL(rec.OriginalURL); err != nil {
+		return err
+	}
+	if err := ValidateExpiration(rec.ExpiresAt, now); err != nil {
+		return err
+	}
+	if rec.ID != "" {
+		return ValidateAlias(rec.ID)
+	}
+	return nil
+}
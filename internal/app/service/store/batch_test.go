@@ -0,0 +1,60 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestPrepareBatch(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Minute)
+	in := []Record{
+		{OriginalURL: "https://example.org/a"},
+		{OriginalURL: "bad"},
+		{OriginalURL: "https://example.org/a", ID: "bad/alias"},
+		{OriginalURL: "https://example.org/b", ExpiresAt: &past},
+		{OriginalURL: "https://example.org/a"},
+		{OriginalURL: "https://example.org/c", Err: ErrAliasTaken},
+	}
+
+	first := PrepareBatch(in, now, Dedup{})
+	assert.Equal(t, []int{0, 1, 2, 3, 0, 5}, first)
+	assert.NoError(t, in[0].Err)
+	assert.ErrorIs(t, in[1].Err, ErrBadInput)
+	assert.ErrorIs(t, in[2].Err, ErrBadInput, "invalid repeat must not share the first result")
+	assert.ErrorIs(t, in[3].Err, ErrBadInput)
+	assert.NoError(t, in[4].Err)
+	assert.ErrorIs(t, in[5].Err, ErrAliasTaken, "preset error must be kept")
+
+	assert.ErrorIs(t, BatchError(in), ErrBadInput)
+	assert.EqualError(t, BatchError(in[1:2]), `batch item 0: bad input: invalid url "bad"`)
+	assert.NoError(t, BatchError(in[:1]))
+
+	in[0].ID, in[0].ShortURL = "a", "http://localhost/a"
+	FinishBatch(in, first)
+	assert.Equal(t, "a", in[4].ID)
+	assert.Equal(t, "http://localhost/a", in[4].ShortURL)
+	assert.Empty(t, in[2].ShortURL)
+}
+
+func TestPrepareBatch_DedupNone(t *testing.T) {
+	in := []Record{
+		{OriginalURL: "https://example.org/a"},
+		{OriginalURL: "https://example.org/a"},
+	}
+
+	first := PrepareBatch(in, time.Now(), Dedup{Scope: DedupNone})
+	assert.Equal(t, []int{0, 1}, first, "repeats must be written separately")
+}
+
+func TestItemErrorCode(t *testing.T) {
+	assert.Empty(t, ItemErrorCode(nil))
+	assert.Equal(t, ItemErrConflict, ItemErrorCode(&ConflictError{ExistingURL: "http://localhost/a"}))
+	assert.Equal(t, ItemErrAliasTaken, ItemErrorCode(ErrAliasTaken))
+	assert.Equal(t, ItemErrBadInput, ItemErrorCode(ErrReservedAlias))
+	assert.Equal(t, ItemErrForbidden, ItemErrorCode(fmt.Errorf("batch item 0: %w", ErrForbiddenTarget)))
+	assert.Equal(t, ItemErrInternal, ItemErrorCode(errors.New("db is down")))
+}
@@ -0,0 +1,212 @@
+package store
+
+import (
+	"golang.org/x/net/idna"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// canonicalVersion of the normalization rules, it must be changed with the rules, so the stored dedup keys are rebuilt
+const canonicalVersion = "1"
+
+// defaultPorts of the schemes removed from the canonical host
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// Canonicalizer converts urls into the canonical form used to detect duplicates, the original url is kept for redirect.
+// Scheme and host are lower-cased, the default port is removed, international host is converted to punycode and
+// percent-encoding is normalized. Query parameters are optionally stripped and sorted.
+type Canonicalizer struct {
+	sortQuery bool
+	// strip lists names of the removed query parameters, names ending with * are prefixes
+	strip []string
+}
+
+// CanonicalOption is a functional parameter of the Canonicalizer
+type CanonicalOption func(*Canonicalizer)
+
+// NewCanonicalizer constructor
+func NewCanonicalizer(opts ...CanonicalOption) *Canonicalizer {
+	c := &Canonicalizer{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithSortedQuery sorts query parameters, so their order does not matter
+func WithSortedQuery() CanonicalOption {
+	return func(c *Canonicalizer) {
+		c.sortQuery = true
+	}
+}
+
+// WithStrippedParams removes query parameters by name, names ending with * remove all parameters with the prefix,
+// e.g. utm_* or fbclid
+func WithStrippedParams(names ...string) CanonicalOption {
+	return func(c *Canonicalizer) {
+		for _, name := range names {
+			if name = strings.TrimSpace(name); name != "" {
+				c.strip = append(c.strip, name)
+			}
+		}
+	}
+}
+
+// String describes the rules, urls canonicalized by the rules with the same description are equal
+func (c *Canonicalizer) String() string {
+	if c == nil {
+		return "none"
+	}
+	return "v" + canonicalVersion + ";sort=" + strconv.FormatBool(c.sortQuery) + ";strip=" + strings.Join(c.strip, ",")
+}
+
+// Canonicalize returns canonical form of the url, urls which could not be parsed are returned as is.
+// Nil Canonicalizer keeps all urls as is.
+func (c *Canonicalizer) Canonicalize(raw string) string {
+	if c == nil {
+		return raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return raw
+	}
+
+	var sb strings.Builder
+	scheme := strings.ToLower(u.Scheme)
+	sb.WriteString(scheme)
+	sb.WriteString("://")
+	if u.User != nil {
+		sb.WriteString(u.User.String())
+		sb.WriteByte('@')
+	}
+	sb.WriteString(canonicalHost(scheme, u.Hostname(), u.Port()))
+
+	path := normalizeEscapes(u.EscapedPath())
+	if path == "" {
+		path = "/"
+	}
+	sb.WriteString(path)
+
+	if query := c.canonicalQuery(u.RawQuery); query != "" {
+		sb.WriteByte('?')
+		sb.WriteString(query)
+	}
+	if u.Fragment != "" {
+		sb.WriteByte('#')
+		sb.WriteString(normalizeEscapes(u.EscapedFragment()))
+	}
+
+	return sb.String()
+}
+
+// canonicalQuery normalizes query parameters keeping their encoding, so the values are not changed
+func (c *Canonicalizer) canonicalQuery(raw string) string {
+	if raw == "" {
+		return ""
+	}
+
+	params := make([]string, 0, strings.Count(raw, "&")+1)
+	for _, param := range strings.Split(raw, "&") {
+		if param == "" {
+			continue
+		}
+		param = normalizeEscapes(param)
+		name := param
+		if i := strings.IndexByte(param, '='); i >= 0 {
+			name = param[:i]
+		}
+		if name, err := url.QueryUnescape(name); err == nil && c.stripped(name) {
+			continue
+		}
+		params = append(params, param)
+	}
+	if c.sortQuery {
+		sort.Strings(params)
+	}
+
+	return strings.Join(params, "&")
+}
+
+// stripped reports if the query parameter is removed
+func (c *Canonicalizer) stripped(name string) bool {
+	for _, s := range c.strip {
+		if prefix := strings.TrimSuffix(s, "*"); prefix != s {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if name == s {
+			return true
+		}
+	}
+	return false
+}
+
+// canonicalHost lower-cases the host, converts it to punycode and removes the default port of the scheme
+func canonicalHost(scheme string, host string, port string) string {
+	host = strings.ToLower(host)
+	if ascii, err := idna.Lookup.ToASCII(host); err == nil {
+		host = ascii
+	}
+	if port != "" && defaultPorts[scheme] != port {
+		return net.JoinHostPort(host, port)
+	}
+	if strings.Contains(host, ":") {
+		// ipv6 address
+		return "[" + host + "]"
+	}
+	return host
+}
+
+// normalizeEscapes decodes percent-encoded unreserved characters and upper-cases hex digits of the other escapes
+func normalizeEscapes(s string) string {
+	const hex = "0123456789ABCDEF"
+
+	if !strings.Contains(s, "%") {
+		return s
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' || i+2 >= len(s) || !isHex(s[i+1]) || !isHex(s[i+2]) {
+			sb.WriteByte(s[i])
+			continue
+		}
+		b := unhex(s[i+1])<<4 | unhex(s[i+2])
+		if isUnreserved(b) {
+			sb.WriteByte(b)
+		} else {
+			sb.WriteByte('%')
+			sb.WriteByte(hex[b>>4])
+			sb.WriteByte(hex[b&0xf])
+		}
+		i += 2
+	}
+	return sb.String()
+}
+
+// isUnreserved reports if the character is not required to be encoded by RFC 3986
+func isUnreserved(b byte) bool {
+	return 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z' || '0' <= b && b <= '9' || b == '-' || b == '.' || b == '_' || b == '~'
+}
+
+func isHex(b byte) bool {
+	return '0' <= b && b <= '9' || 'a' <= b && b <= 'f' || 'A' <= b && b <= 'F'
+}
+
+func unhex(b byte) byte {
+	switch {
+	case '0' <= b && b <= '9':
+		return b - '0'
+	case 'a' <= b && b <= 'f':
+		return b - 'a' + 10
+	default:
+		return b - 'A' + 10
+	}
+}
@@ -0,0 +1,55 @@
+package store
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCanonicalizer_Canonicalize(t *testing.T) {
+	tests := []struct {
+		name string
+		c    *Canonicalizer
+		url  string
+		want string
+	}{
+		{"nil keeps url", nil, "HTTP://Example.com/a", "HTTP://Example.com/a"},
+		{"case", NewCanonicalizer(), "HTTP://Example.COM/Path", "http://example.com/Path"},
+		{"default port", NewCanonicalizer(), "https://example.com:443/a", "https://example.com/a"},
+		{"other port", NewCanonicalizer(), "http://example.com:8080/a", "http://example.com:8080/a"},
+		{"empty path", NewCanonicalizer(), "https://example.com", "https://example.com/"},
+		{"idn", NewCanonicalizer(), "https://Пример.рф/a", "https://xn--e1afmkfd.xn--p1ai/a"},
+		{"ipv6", NewCanonicalizer(), "http://[::1]:80/a", "http://[::1]/a"},
+		{"ipv6 port", NewCanonicalizer(), "http://[::1]:8080/a", "http://[::1]:8080/a"},
+		{"unreserved escapes", NewCanonicalizer(), "https://example.com/%7Euser/%61", "https://example.com/~user/a"},
+		{"escape case", NewCanonicalizer(), "https://example.com/a%2fb?q=%e2%82%ac", "https://example.com/a%2Fb?q=%E2%82%AC"},
+		{"unicode path", NewCanonicalizer(), "https://example.com/привет", "https://example.com/%D0%BF%D1%80%D0%B8%D0%B2%D0%B5%D1%82"},
+		{"query order kept", NewCanonicalizer(), "https://example.com/?b=1&a=2", "https://example.com/?b=1&a=2"},
+		{"query sorted", NewCanonicalizer(WithSortedQuery()), "https://example.com/?b=1&a=2&", "https://example.com/?a=2&b=1"},
+		{
+			"tracking stripped",
+			NewCanonicalizer(WithStrippedParams("utm_*", " fbclid", "")),
+			"https://example.com/a?utm_source=x&id=1&fbclid=abc&utm_medium=y#top",
+			"https://example.com/a?id=1#top",
+		},
+		{"all params stripped", NewCanonicalizer(WithStrippedParams("fbclid")), "https://example.com/a?fbclid=abc", "https://example.com/a"},
+		{"not parsed", NewCanonicalizer(), "://bad", "://bad"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.c.Canonicalize(tt.url))
+		})
+	}
+}
+
+func TestDedup(t *testing.T) {
+	d := Dedup{Scope: DedupUser, Canonicalizer: NewCanonicalizer(WithSortedQuery())}
+
+	key, ok := d.Key("user1", "HTTP://Example.com/a?b=1&a=2")
+	assert.True(t, ok)
+	assert.Equal(t, "user1 http://example.com/a?a=2&b=1", key)
+	assert.Equal(t, "scope=user;canonical=v1;sort=true;strip=", d.String())
+
+	_, ok = Dedup{Scope: DedupNone}.Key("user1", "https://example.com")
+	assert.False(t, ok)
+	assert.Equal(t, "scope=global;canonical=none", Dedup{}.String())
+}
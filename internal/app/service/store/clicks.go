@@ -0,0 +1,52 @@
+package store
+
+import (
+	"time"
+)
+
+// ClickEvent is a single redirect of the short link
+type ClickEvent struct {
+	// ID is a short id of the link
+	ID        string
+	Time      time.Time
+	Referer   string
+	UserAgent string
+	IP        string
+	RequestID string
+}
+
+// LinkStat is the link analytics
+type LinkStat struct {
+	ID             string
+	ShortURL       string
+	TotalClicks    int
+	UniqueVisitors int
+	// Daily clicks for the requested days ending today, days without clicks are included
+	Daily []DailyClicks
+}
+
+// DailyClicks is a number of clicks during the UTC day
+type DailyClicks struct {
+	Date   time.Time
+	Clicks int
+}
+
+// Day truncates t to the beginning of its UTC day
+func Day(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+// DailySeries converts clicks counted by Day into the dense series of the days ending at now
+func DailySeries(counts map[time.Time]int, days int, now time.Time) []DailyClicks {
+	if days <= 0 {
+		return nil
+	}
+
+	series := make([]DailyClicks, days)
+	first := Day(now).AddDate(0, 0, 1-days)
+	for i := range series {
+		day := first.AddDate(0, 0, i)
+		series[i] = DailyClicks{Date: day, Clicks: counts[day]}
+	}
+	return series
+}
@@ -0,0 +1,28 @@
+package store
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestDailySeries(t *testing.T) {
+	now := time.Date(2022, 4, 25, 15, 30, 0, 0, time.FixedZone("MSK", 3*60*60))
+	today := time.Date(2022, 4, 25, 0, 0, 0, 0, time.UTC)
+	counts := map[time.Time]int{
+		today:                   2,
+		today.AddDate(0, 0, -2): 1,
+		today.AddDate(0, 0, -9): 5,
+	}
+
+	got := DailySeries(counts, 3, now)
+	require.Len(t, got, 3)
+	assert.Equal(t, []DailyClicks{
+		{Date: today.AddDate(0, 0, -2), Clicks: 1},
+		{Date: today.AddDate(0, 0, -1), Clicks: 0},
+		{Date: today, Clicks: 2},
+	}, got)
+
+	assert.Empty(t, DailySeries(counts, 0, now))
+}
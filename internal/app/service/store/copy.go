@@ -0,0 +1,54 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// DefaultCopyBatch is a number of rows moved by a single Dump and Load call
+const DefaultCopyBatch = 1000
+
+// CopyProgress is reported after every copied batch
+type CopyProgress struct {
+	// Last is the source key of the last copied row, the interrupted copy is resumed after it
+	Last uint64
+	// Read is a number of rows read from the source
+	Read int
+	// Written is a number of rows written to the target, rows already present in the target are skipped
+	Written int
+}
+
+// Copy moves rows with keys greater than after from src to dst in batches of the given size.
+// Nil dst makes a dry run, rows are read but not written. Progress is called after every batch if it is set.
+// On failure the progress of the last completed batch is returned together with the error.
+func Copy(ctx context.Context, src Dumper, dst Loader, after uint64, batch int, progress func(CopyProgress)) (CopyProgress, error) {
+	if batch <= 0 {
+		return CopyProgress{}, errors.New("copy batch must be positive")
+	}
+
+	p := CopyProgress{Last: after}
+	for {
+		rows, err := src.Dump(ctx, p.Last, batch)
+		if err != nil {
+			return p, fmt.Errorf("dump after %d: %w", p.Last, err)
+		}
+		if len(rows) == 0 {
+			return p, nil
+		}
+
+		written := 0
+		if dst != nil {
+			if written, err = dst.Load(ctx, rows); err != nil {
+				return p, fmt.Errorf("load after %d: %w", p.Last, err)
+			}
+		}
+
+		p.Last = rows[len(rows)-1].Key
+		p.Read += len(rows)
+		p.Written += written
+		if progress != nil {
+			progress(p)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package store_test
+
+import (
+	"context"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"shortener/internal/app/service/store"
+	storemock "shortener/internal/app/service/store/mock"
+	"testing"
+)
+
+func TestCopy(t *testing.T) {
+	first := []store.DumpRow{{Key: 6, ID: "a"}, {Key: 9, ID: "b"}}
+	second := []store.DumpRow{{Key: 10, ID: "c"}}
+
+	t.Run("copy", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		src := storemock.NewMockDumper(ctrl)
+		dst := storemock.NewMockLoader(ctrl)
+		gomock.InOrder(
+			src.EXPECT().Dump(gomock.Any(), uint64(5), 2).Return(first, nil),
+			dst.EXPECT().Load(gomock.Any(), first).Return(2, nil),
+			src.EXPECT().Dump(gomock.Any(), uint64(9), 2).Return(second, nil),
+			dst.EXPECT().Load(gomock.Any(), second).Return(0, nil),
+			src.EXPECT().Dump(gomock.Any(), uint64(10), 2).Return(nil, nil),
+		)
+
+		var reported []store.CopyProgress
+		p, err := store.Copy(context.Background(), src, dst, 5, 2, func(p store.CopyProgress) {
+			reported = append(reported, p)
+		})
+		require.NoError(t, err)
+		assert.Equal(t, store.CopyProgress{Last: 10, Read: 3, Written: 2}, p)
+		assert.Equal(t, []store.CopyProgress{{Last: 9, Read: 2, Written: 2}, p}, reported)
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		src := storemock.NewMockDumper(ctrl)
+		gomock.InOrder(
+			src.EXPECT().Dump(gomock.Any(), uint64(0), 2).Return(first, nil),
+			src.EXPECT().Dump(gomock.Any(), uint64(9), 2).Return(nil, nil),
+		)
+
+		p, err := store.Copy(context.Background(), src, nil, 0, 2, nil)
+		require.NoError(t, err)
+		assert.Equal(t, store.CopyProgress{Last: 9, Read: 2}, p)
+	})
+
+	t.Run("load failure", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		src := storemock.NewMockDumper(ctrl)
+		dst := storemock.NewMockLoader(ctrl)
+		failure := errors.New("db is down")
+		gomock.InOrder(
+			src.EXPECT().Dump(gomock.Any(), uint64(0), 2).Return(first, nil),
+			dst.EXPECT().Load(gomock.Any(), first).Return(2, nil),
+			src.EXPECT().Dump(gomock.Any(), uint64(9), 2).Return(second, nil),
+			dst.EXPECT().Load(gomock.Any(), second).Return(0, failure),
+		)
+
+		p, err := store.Copy(context.Background(), src, dst, 0, 2, nil)
+		assert.ErrorIs(t, err, failure)
+		assert.Equal(t, store.CopyProgress{Last: 9, Read: 2, Written: 2}, p, "copy must be resumable after the last completed batch")
+	})
+}
@@ -0,0 +1,51 @@
+package store
+
+// DedupScope defines among which links active original urls must be unique
+type DedupScope string
+
+const (
+	// DedupGlobal makes original urls unique across all users, the default scope
+	DedupGlobal DedupScope = "global"
+	// DedupUser makes original urls unique within the links of the user
+	DedupUser DedupScope = "user"
+	// DedupNone allows any number of links to the same url
+	DedupNone DedupScope = "none"
+)
+
+// Key returns the value active links must be unique by, false if the links are not deduplicated
+func (s DedupScope) Key(uid string, url string) (string, bool) {
+	switch s {
+	case DedupNone:
+		return "", false
+	case DedupUser:
+		return uid + " " + url, true
+	default:
+		return url, true
+	}
+}
+
+// Dedup defines how the duplicate links are detected
+type Dedup struct {
+	// Scope is DedupGlobal if empty
+	Scope DedupScope
+	// Canonicalizer converts urls before the comparison, nil compares urls as is
+	Canonicalizer *Canonicalizer
+}
+
+// Key returns the value active links must be unique by, the url is canonicalized.
+// False is returned if the links are not deduplicated.
+func (d Dedup) Key(uid string, url string) (string, bool) {
+	if d.Scope == DedupNone {
+		return "", false
+	}
+	return d.Scope.Key(uid, d.Canonicalizer.Canonicalize(url))
+}
+
+// String describes the rules, keys built by the rules with the same description are equal
+func (d Dedup) String() string {
+	scope := d.Scope
+	if scope == "" {
+		scope = DedupGlobal
+	}
+	return "scope=" + string(scope) + ";canonical=" + d.Canonicalizer.String()
+}
@@ -0,0 +1,26 @@
+package store
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDedupScope_Key(t *testing.T) {
+	tests := []struct {
+		scope DedupScope
+		key   string
+		ok    bool
+	}{
+		{"", "https://example.org", true},
+		{DedupGlobal, "https://example.org", true},
+		{DedupUser, "user1 https://example.org", true},
+		{DedupNone, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.scope), func(t *testing.T) {
+			key, ok := tt.scope.Key("user1", "https://example.org")
+			assert.Equal(t, tt.key, key)
+			assert.Equal(t, tt.ok, ok)
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+var ErrVersionNotFound = fmt.Errorf("version: %w", ErrNotFound)
+
+// URLVersion is a destination of the link, versions are numbered from 1 in order of the updates
+type URLVersion struct {
+	Version     int
+	OriginalURL string
+	// CreatedAt is the moment the destination was set
+	CreatedAt time.Time
+}
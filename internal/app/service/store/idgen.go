@@ -0,0 +1,181 @@
+package store
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var ErrIDExhausted = errors.New("unable to generate free short id")
+
+// MaxIDAttempts limits attempts to find a free short id before ErrIDExhausted is returned
+const MaxIDAttempts = 10
+
+// IDGenerator produces short ids for the new records.
+//
+// Stores pass the sequence number allocated for the new record. When the generated id is already taken
+// the store allocates the next sequence number and asks again, up to MaxIDAttempts times.
+// Ids are stored with the records, so switching the generator does not break existing links.
+type IDGenerator interface {
+	Generate(seq uint64) (string, error)
+}
+
+const base62Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// SequentialGenerator formats the sequence number in the base, ids are short but enumerable
+type SequentialGenerator struct {
+	base int
+}
+
+// NewSequentialGenerator constructor, base must be in range 2..36
+func NewSequentialGenerator(base int) *SequentialGenerator {
+	return &SequentialGenerator{base: base}
+}
+
+func (g *SequentialGenerator) Generate(seq uint64) (string, error) {
+	return strconv.FormatUint(seq, g.base), nil
+}
+
+// RandomGenerator produces random base62 ids of the fixed length, the sequence number is ignored
+type RandomGenerator struct {
+	length int
+	rand   io.Reader
+}
+
+// NewRandomGenerator constructor
+func NewRandomGenerator(length int) (*RandomGenerator, error) {
+	if length <= 0 || length > aliasMaxLength {
+		return nil, fmt.Errorf("random id length %d: %w", length, ErrBadInput)
+	}
+	return &RandomGenerator{length: length, rand: rand.Reader}, nil
+}
+
+func (g *RandomGenerator) Generate(uint64) (string, error) {
+	// 248 is the largest multiple of 62 fitting into a byte, greater bytes are rejected to keep distribution uniform
+	const limit = 248
+
+	var sb strings.Builder
+	sb.Grow(g.length)
+
+	buf := make([]byte, g.length)
+	for sb.Len() < g.length {
+		if _, err := io.ReadFull(g.rand, buf); err != nil {
+			return "", fmt.Errorf("random read: %w", err)
+		}
+		for _, b := range buf {
+			if b >= limit {
+				continue
+			}
+			sb.WriteByte(base62Alphabet[b%62])
+			if sb.Len() == g.length {
+				break
+			}
+		}
+	}
+
+	return sb.String(), nil
+}
+
+const (
+	// feistelHalfBits is a size of the permuted block half, so up to 2^40 sequence numbers are supported
+	feistelHalfBits = 20
+	feistelHalfMask = 1<<feistelHalfBits - 1
+	feistelRounds   = 4
+	// MaxPermutedSeq is the first sequence number not supported by PermutationGenerator
+	MaxPermutedSeq = 1 << (2 * feistelHalfBits)
+)
+
+// PermutationGenerator obfuscates the sequence number with the keyed Feistel network and formats it in base62.
+// The permutation is reversible and collision free, so ids are unique while the sequence is not revealed.
+type PermutationGenerator struct {
+	keys [feistelRounds]uint64
+}
+
+// NewPermutationGenerator constructor, round keys are derived from the secret
+func NewPermutationGenerator(secret string) *PermutationGenerator {
+	sum := sha256.Sum256([]byte(secret))
+
+	g := &PermutationGenerator{}
+	for i := range g.keys {
+		g.keys[i] = binary.LittleEndian.Uint64(sum[i*8:])
+	}
+	return g
+}
+
+func (g *PermutationGenerator) Generate(seq uint64) (string, error) {
+	if seq >= MaxPermutedSeq {
+		return "", fmt.Errorf("sequence %d: %w", seq, ErrIDExhausted)
+	}
+	return formatBase62(g.permute(seq)), nil
+}
+
+// Reverse returns the sequence number of the id generated by the same generator
+func (g *PermutationGenerator) Reverse(id string) (uint64, error) {
+	v, err := parseBase62(id)
+	if err != nil {
+		return 0, err
+	}
+	if v >= MaxPermutedSeq {
+		return 0, fmt.Errorf("id %q out of range: %w", id, ErrBadInput)
+	}
+	return g.unpermute(v), nil
+}
+
+func (g *PermutationGenerator) permute(v uint64) uint64 {
+	l, r := v>>feistelHalfBits, v&feistelHalfMask
+	for _, key := range g.keys {
+		l, r = r, l^g.round(r, key)
+	}
+	return l<<feistelHalfBits | r
+}
+
+func (g *PermutationGenerator) unpermute(v uint64) uint64 {
+	l, r := v>>feistelHalfBits, v&feistelHalfMask
+	for i := len(g.keys) - 1; i >= 0; i-- {
+		l, r = r^g.round(l, g.keys[i]), l
+	}
+	return l<<feistelHalfBits | r
+}
+
+// round function of the Feistel network, splitmix64 finalizer of the keyed half
+func (g *PermutationGenerator) round(half uint64, key uint64) uint64 {
+	z := half ^ key
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	z ^= z >> 31
+	return z & feistelHalfMask
+}
+
+func formatBase62(v uint64) string {
+	if v == 0 {
+		return base62Alphabet[:1]
+	}
+	var buf [11]byte
+	i := len(buf)
+	for v > 0 {
+		i--
+		buf[i] = base62Alphabet[v%62]
+		v /= 62
+	}
+	return string(buf[i:])
+}
+
+func parseBase62(s string) (uint64, error) {
+	if s == "" || len(s) > 11 {
+		return 0, fmt.Errorf("base62 id %q: %w", s, ErrBadInput)
+	}
+	var v uint64
+	for i := 0; i < len(s); i++ {
+		d := strings.IndexByte(base62Alphabet, s[i])
+		if d < 0 {
+			return 0, fmt.Errorf("base62 id %q: %w", s, ErrBadInput)
+		}
+		v = v*62 + uint64(d)
+	}
+	return v, nil
+}
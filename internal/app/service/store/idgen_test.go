@@ -0,0 +1,75 @@
+package store
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSequentialGenerator_Generate(t *testing.T) {
+	tests := []struct {
+		name string
+		base int
+		seq  uint64
+		want string
+	}{
+		{name: "base 10", base: 10, seq: 125, want: "125"},
+		{name: "base 36", base: 36, seq: 125, want: "3h"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewSequentialGenerator(tt.base).Generate(tt.seq)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRandomGenerator_Generate(t *testing.T) {
+	_, err := NewRandomGenerator(0)
+	assert.ErrorIs(t, err, ErrBadInput)
+
+	g, err := NewRandomGenerator(8)
+	require.NoError(t, err)
+
+	seen := make(map[string]struct{})
+	for i := 0; i < 1000; i++ {
+		id, err := g.Generate(1)
+		require.NoError(t, err)
+		require.Len(t, id, 8)
+		require.NoError(t, ValidateAlias(id), "generated id must be acceptable as alias")
+		seen[id] = struct{}{}
+	}
+	assert.Len(t, seen, 1000, "random ids must not repeat")
+}
+
+func TestPermutationGenerator(t *testing.T) {
+	g := NewPermutationGenerator("secret")
+
+	seen := make(map[string]struct{})
+	for seq := uint64(0); seq < 10000; seq++ {
+		id, err := g.Generate(seq)
+		require.NoError(t, err)
+		require.NoError(t, ValidateAlias(id))
+
+		_, dup := seen[id]
+		require.False(t, dup, "permutation must be collision free, seq %d", seq)
+		seen[id] = struct{}{}
+
+		got, err := g.Reverse(id)
+		require.NoError(t, err)
+		require.Equal(t, seq, got)
+	}
+
+	first, _ := g.Generate(1)
+	second, _ := g.Generate(2)
+	other, _ := NewPermutationGenerator("other").Generate(1)
+	assert.NotEqual(t, "2", second, "sequence must be obfuscated")
+	assert.NotEqual(t, first, other, "ids must depend on the secret")
+
+	_, err := g.Generate(MaxPermutedSeq)
+	assert.ErrorIs(t, err, ErrIDExhausted)
+
+	_, err = g.Reverse("not/base62")
+	assert.ErrorIs(t, err, ErrBadInput)
+}
@@ -0,0 +1,44 @@
+package store
+
+import (
+	"context"
+	"errors"
+)
+
+// Import writes records keeping their ids as custom aliases where they are free,
+// records with the taken aliases get generated ids. Results are returned in the input order.
+// Other failures are reported per record the same way as by BatchWriter.
+func Import(ctx context.Context, w BatchWriter, uid string, in []Record) ([]Record, error) {
+	out, err := w.BatchWrite(ctx, uid, in)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		retry   []Record
+		indexes []int
+	)
+	for i := range out {
+		if out[i].ID != "" && errors.Is(out[i].Err, ErrAliasTaken) {
+			retry = append(retry, Record{
+				CorrelationID: out[i].CorrelationID,
+				OriginalURL:   out[i].OriginalURL,
+				ExpiresAt:     out[i].ExpiresAt,
+			})
+			indexes = append(indexes, i)
+		}
+	}
+	if len(retry) == 0 {
+		return out, nil
+	}
+
+	retry, err = w.BatchWrite(ctx, uid, retry)
+	if err != nil {
+		return nil, err
+	}
+	for n, i := range indexes {
+		out[i] = retry[n]
+	}
+
+	return out, nil
+}
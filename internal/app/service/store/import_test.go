@@ -0,0 +1,38 @@
+package store_test
+
+import (
+	"context"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"shortener/internal/app/service/store"
+	storemock "shortener/internal/app/service/store/mock"
+	"testing"
+)
+
+func TestImport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	w := storemock.NewMockBatchWriter(ctrl)
+	gomock.InOrder(
+		w.EXPECT().BatchWrite(gomock.Any(), "user1", gomock.Len(3)).Return([]store.Record{
+			{ID: "free", OriginalURL: "https://example.org/a", ShortURL: "http://localhost/free"},
+			{ID: "taken", OriginalURL: "https://example.org/b", CorrelationID: "b", Err: store.ErrAliasTaken},
+			{OriginalURL: "bad", Err: store.ErrBadInput},
+		}, nil),
+		w.EXPECT().BatchWrite(gomock.Any(), "user1", []store.Record{{OriginalURL: "https://example.org/b", CorrelationID: "b"}}).
+			Return([]store.Record{{ID: "gen", OriginalURL: "https://example.org/b", CorrelationID: "b", ShortURL: "http://localhost/gen"}}, nil),
+	)
+
+	got, err := store.Import(context.Background(), w, "user1", []store.Record{
+		{ID: "free", OriginalURL: "https://example.org/a"},
+		{ID: "taken", OriginalURL: "https://example.org/b", CorrelationID: "b"},
+		{OriginalURL: "bad"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost/free", got[0].ShortURL)
+	assert.Equal(t, "http://localhost/gen", got[1].ShortURL, "taken alias must be replaced by the generated id")
+	assert.NoError(t, got[1].Err)
+	assert.ErrorIs(t, got[2].Err, store.ErrBadInput)
+}
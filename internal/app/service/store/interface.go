@@ -5,8 +5,10 @@ Package store provides interfaces for storing data.
 package store
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"time"
 )
 
 var (
@@ -14,7 +16,16 @@ var (
 	ErrEmptyInput = fmt.Errorf("empty url: %w", ErrBadInput)
 	ErrNotFound   = errors.New("not found")
 	ErrDeleted    = errors.New("deleted")
+	ErrExpired    = errors.New("expired")
+	ErrDisabled   = errors.New("disabled")
 	ErrConflict   = &ConflictError{}
+
+	ErrAliasTaken    = errors.New("alias taken")
+	ErrReservedAlias = fmt.Errorf("reserved alias: %w", ErrBadInput)
+	ErrEmptyIDs      = fmt.Errorf("empty ids: %w", ErrBadInput)
+
+	// ErrForbiddenTarget is returned if the destination is rejected by the policy
+	ErrForbiddenTarget = errors.New("forbidden target")
 )
 
 type ConflictError struct {
@@ -25,10 +36,44 @@ func (e ConflictError) Error() string {
 	return "conflict"
 }
 
+// QuarantineError is returned by ReadURL for the link pointing to the threat
+type QuarantineError struct {
+	OriginalURL string
+	Threat      string
+}
+
+func (e QuarantineError) Error() string {
+	return "quarantined: " + e.Threat
+}
+
 // HealthChecker allows you to perform store health check
 type HealthChecker interface {
 	// HealthCheck underlying storage and return error if it is not available
-	HealthCheck() error
+	HealthCheck(ctx context.Context) error
+}
+
+// Lifecycle allows you to start and stop store background activity
+type Lifecycle interface {
+	// Start store background activity
+	Start() error
+	// Stop store background activity and release resources
+	Stop() error
+}
+
+// Backend is a complete storage used by the application
+type Backend interface {
+	Store
+	StatProvider
+	HealthChecker
+	Lifecycle
+	ClickRecorder
+	LinkStatReader
+	OperationReader
+	Restorer
+	Editor
+	Dumper
+	Quarantiner
+	Moderator
 }
 
 // Store of the url data
@@ -42,28 +87,108 @@ type Store interface {
 
 // Reader allows you to read short urls.
 type Reader interface {
-	// ReadURL from storage using provided id.
-	ReadURL(id string) (string, error)
+	// ReadURL from storage using provided id. ErrExpired is returned if the link is expired,
+	// ErrDisabled if the link or its owner is blocked by the operator, QuarantineError if the link points to the threat.
+	ReadURL(ctx context.Context, id string) (string, error)
 }
 
 // UserDataReader allows you to read user short urls.
 type UserDataReader interface {
-	// ReadUserData from db
-	ReadUserData(uid string) []Record
+	// ReadUserData returns a page of the user active links selected by the query.
+	// ErrBadInput is returned if the query is invalid.
+	ReadUserData(ctx context.Context, uid string, q UserDataQuery) (*UserDataPage, error)
 }
 
 // Writer allows you to write urls into persistent storage.
 type Writer interface {
 	// WriteURL to storage, returns short Record.
-	WriteURL(url string, uid string) (string, error)
+	WriteURL(ctx context.Context, url string, uid string, opts ...WriteOption) (string, error)
+	// WriteAlias to storage using custom short id, returns short url.
+	// ErrAliasTaken is returned if the alias is already used.
+	WriteAlias(ctx context.Context, url string, alias string, uid string, opts ...WriteOption) (string, error)
 }
 
 type BatchWriter interface {
-	BatchWrite(uid string, in []Record) ([]Record, error)
+	// BatchWrite records to storage. Record ID is used as custom alias if set, expiration is taken from the record.
+	// Records are written independently: failed ones get Err set, conflicting ones also get ShortURL of the existing link.
+	// Repeated urls share the result of the first record, records with Err already set are skipped.
+	// Error is returned only if the batch is not processed at all.
+	// With WithAtomic option the first record failure is returned as error and nothing is written.
+	BatchWrite(ctx context.Context, uid string, in []Record, opts ...WriteOption) ([]Record, error)
 }
 
+// BatchRemover removes user rows. Removal may be applied asynchronously,
+// but it is persisted and guaranteed to happen once nil error is returned.
 type BatchRemover interface {
-	BatchRemove(uid string, ids ...string) error
+	// BatchRemove returns operation id to track the removal with OperationReader
+	BatchRemove(ctx context.Context, uid string, ids ...string) (string, error)
+}
+
+// Restorer allows you to undo removal of the user rows
+type Restorer interface {
+	// Restore user rows removed within the retention window, returns outcome per id.
+	// Row can not be restored if its original url was shortened again after the removal.
+	Restore(ctx context.Context, uid string, ids ...string) ([]OperationItem, error)
+}
+
+// Editor allows you to change destinations of the user links keeping their history
+type Editor interface {
+	// UpdateURL sets the new destination of the user link, the previous one is kept in the history.
+	// ErrNotFound is returned if the link does not exist or is owned by another user,
+	// ErrDeleted or ErrExpired if the link does not work, ConflictError if the url is shortened by another link.
+	UpdateURL(ctx context.Context, uid string, id string, url string) (*URLVersion, error)
+	// ReadHistory of the user link destinations, the current one goes first
+	ReadHistory(ctx context.Context, uid string, id string) ([]URLVersion, error)
+	// RollbackURL sets destination of the previous version as the new version of the user link.
+	// ErrVersionNotFound is returned if the link has no such version.
+	RollbackURL(ctx context.Context, uid string, id string, version int) (*URLVersion, error)
+}
+
+// OperationReader allows you to read asynchronous operations state
+type OperationReader interface {
+	// ReadOperation of the user. ErrNotFound is returned if the operation does not exist,
+	// is owned by another user or was already purged.
+	ReadOperation(ctx context.Context, uid string, id string) (*Operation, error)
+}
+
+// Dumper allows you to read all the rows including removed and expired ones, used by the offline migration
+type Dumper interface {
+	// Dump returns up to limit rows with keys greater than after, ordered by key
+	Dump(ctx context.Context, after uint64, limit int) ([]DumpRow, error)
+}
+
+// Loader allows you to write dumped rows keeping their short ids, used by the offline migration
+type Loader interface {
+	// Load writes rows atomically and returns the number of written rows.
+	// Rows with short ids already taken or with urls shortened by the active rows are skipped.
+	Load(ctx context.Context, rows []DumpRow) (int, error)
+}
+
+// Quarantiner allows you to mark links pointing to threats
+type Quarantiner interface {
+	// Quarantine sets threats of the links, empty threat releases the link. Items are skipped if the link
+	// destination differs from the checked url. Returns number of the changed links.
+	Quarantine(ctx context.Context, items ...QuarantineItem) (int, error)
+}
+
+// Moderator allows you to handle abuse reports and block links
+type Moderator interface {
+	// Report saves the abuse report of the link. ErrNotFound is returned if the link does not exist.
+	Report(ctx context.Context, r AbuseReport) (*AbuseReport, error)
+	// ReadReports returns a page of the open reports
+	ReadReports(ctx context.Context, q ReportQuery) ([]AbuseReport, error)
+	// SetDisabled disables or enables the link and resolves its open reports.
+	// ErrNotFound is returned if the link does not exist.
+	SetDisabled(ctx context.Context, id string, disabled bool) error
+	// SetBanned bans or unbans the user, all links of the banned user are disabled including the ones written later
+	SetBanned(ctx context.Context, uid string, banned bool) error
+}
+
+// QuarantineItem is a threat found for the link destination
+type QuarantineItem struct {
+	ID          string
+	OriginalURL string
+	Threat      string
 }
 
 type RecordID string
@@ -73,10 +198,49 @@ type Record struct {
 	ShortURL      string
 	OriginalURL   string
 	CorrelationID string
+	// CreatedAt is the moment the link was created, set by the user data listing
+	CreatedAt time.Time
+	// ExpiresAt is the moment the link stops working, nil means never
+	ExpiresAt *time.Time
+	// Err is set by BatchWrite if the record is not written
+	Err error
+}
+
+// DumpRow is a complete link row. Key is a store specific position of the row, it is not preserved by Loader.
+type DumpRow struct {
+	Key         uint64
+	ID          string
+	OriginalURL string
+	UID         string
+	CreatedAt   time.Time
+	// UpdatedAt is the moment the current destination was set, nil if it was never changed
+	UpdatedAt *time.Time
+	DeletedAt *time.Time
+	ExpiresAt *time.Time
+	// Version of the destination, history of the previous destinations is not dumped
+	Version int
+	// Threat the link is quarantined for, empty if the link is not quarantined
+	Threat string
+	// DisabledAt is the moment the link was disabled by the operator, nil if the link is enabled.
+	// Bans of the users are not dumped.
+	DisabledAt *time.Time
+}
+
+// ClickRecorder allows you to save redirect events
+type ClickRecorder interface {
+	// RecordClicks saves events in bulk, events of the unknown links are skipped
+	RecordClicks(ctx context.Context, events ...ClickEvent) error
+}
+
+// LinkStatReader allows you to read link analytics
+type LinkStatReader interface {
+	// ReadLinkStat of the user link with daily series for the last days.
+	// ErrNotFound is returned if the link does not exist or is owned by another user.
+	ReadLinkStat(ctx context.Context, uid string, id string, days int) (*LinkStat, error)
 }
 
 type StatProvider interface {
-	Stat() (*StatData, error)
+	Stat(ctx context.Context) (*StatData, error)
 }
 
 type StatData struct {
@@ -0,0 +1,150 @@
+package memorystore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"shortener/internal/app/service/store"
+	"time"
+)
+
+// store.BatchWriter interface implementation
+var _ store.BatchWriter = (*Store)(nil)
+var _ store.BatchRemover = (*Store)(nil)
+
+// BatchWrite writes valid records in one wal record. Failed records are skipped, unless the batch is atomic.
+func (s *Store) BatchWrite(ctx context.Context, uid string, in []store.Record, opts ...store.WriteOption) ([]store.Record, error) {
+	o := store.NewWriteOptions(opts...)
+	first := store.PrepareBatch(in, time.Now(), s.dedup)
+	if o.Atomic {
+		if err := store.BatchError(in); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// short ids of the batch, both custom and generated
+	taken := make(map[string]struct{})
+	// expired rows released by the batch
+	var released []walEntry
+	entries := make([]walEntry, 0, len(in))
+	written := make([]int, 0, len(in))
+	for i := range in {
+		if first[i] != i || in[i].Err != nil {
+			continue
+		}
+
+		e, r, err := s.newBatchRow(in[i], uid, taken)
+		if err != nil {
+			in[i].Err = err
+			var errConflict *store.ConflictError
+			if errors.As(err, &errConflict) {
+				in[i].ShortURL = errConflict.ExistingURL
+			}
+			if o.Atomic {
+				return nil, fmt.Errorf("batch item %d: %w", i, err)
+			}
+			continue
+		}
+		released = append(released, r...)
+		entries = append(entries, e)
+		written = append(written, i)
+		taken[e.Row.ID] = struct{}{}
+	}
+
+	if len(entries) > 0 {
+		if err := s.apply(append(released, entries...)...); err != nil {
+			return nil, err
+		}
+	}
+
+	for n, i := range written {
+		in[i].ID = entries[n].Row.ID
+		in[i].ShortURL = entries[n].Row.ShortURL
+	}
+	store.FinishBatch(in, first)
+
+	return in, nil
+}
+
+// newBatchRow prepares the row of the batch record, ids listed in taken are already used by the batch.
+// Must be called under the write lock.
+func (s *Store) newBatchRow(rec store.Record, uid string, taken map[string]struct{}) (walEntry, []walEntry, error) {
+	released, err := s.checkConflict(uid, rec.OriginalURL)
+	if err != nil {
+		return walEntry{}, nil, err
+	}
+
+	if rec.ID == "" {
+		e, err := s.newRow(rec, uid, taken)
+		return e, released, err
+	}
+
+	if err := s.checkAlias(rec.ID); err != nil {
+		return walEntry{}, nil, err
+	}
+	if _, ok := taken[rec.ID]; ok {
+		return walEntry{}, nil, fmt.Errorf("%w: %q", store.ErrAliasTaken, rec.ID)
+	}
+	return s.newAliasRow(rec, uid), released, nil
+}
+
+// BatchRemove soft deletes user rows synchronously, so the returned operation is already completed.
+// Invalid ids and rows owned by other users are skipped and reported in the operation outcomes.
+func (s *Store) BatchRemove(ctx context.Context, uid string, ids ...string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	if len(ids) == 0 {
+		return "", store.ErrEmptyIDs
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	op := newOperation(now, len(ids))
+	entries := make([]walEntry, 0, len(ids))
+	removed := make(map[uint64]struct{}, len(ids))
+	for i, id := range ids {
+		op.Items[i] = store.OperationItem{ID: id, Outcome: store.OutcomeDeleted}
+
+		if store.ValidateAlias(id) != nil {
+			op.Items[i].Outcome = store.OutcomeInvalid
+			continue
+		}
+		key, ok := s.idIndex[id]
+		if !ok || s.db[key].UID != uid {
+			op.Items[i].Outcome = store.OutcomeNotFound
+			continue
+		}
+		if _, ok := removed[key]; ok {
+			continue
+		}
+		row := s.db[key]
+		if row.DeletedAt != nil {
+			op.Items[i].Outcome = store.OutcomeAlreadyDeleted
+			continue
+		}
+		row.DeletedAt = &now
+		entries = append(entries, walEntry{Key: key, Row: row})
+		removed[key] = struct{}{}
+	}
+
+	if len(entries) > 0 {
+		if err := s.apply(entries...); err != nil {
+			return "", err
+		}
+	}
+
+	s.addOperation(uid, op)
+
+	return op.ID, nil
+}
@@ -0,0 +1,169 @@
+package memorystore
+
+import (
+	"context"
+	"errors"
+	"shortener/internal/app/service/store"
+	"testing"
+)
+
+func TestStore_BatchWrite(t *testing.T) {
+	tests := []struct {
+		name         string
+		in           []store.Record
+		want         []string
+		wantErr      error
+		wantConflict bool
+	}{
+		{
+			"write batch",
+			[]store.Record{
+				{CorrelationID: "a", OriginalURL: "https://example.org/a"},
+				{CorrelationID: "b", OriginalURL: "https://example.org/b"},
+			},
+			[]string{"http://localhost:8080/2", "http://localhost:8080/3"},
+			nil,
+			false,
+		},
+		{
+			"write invalid url",
+			[]store.Record{
+				{CorrelationID: "a", OriginalURL: "https://example.org/a"},
+				{CorrelationID: "b", OriginalURL: "bad"},
+			},
+			nil,
+			store.ErrBadInput,
+			false,
+		},
+		{
+			"write duplicate urls",
+			[]store.Record{
+				{CorrelationID: "a", OriginalURL: "https://example.org/a"},
+				{CorrelationID: "b", OriginalURL: "https://example.org/a"},
+			},
+			[]string{"http://localhost:8080/2", "http://localhost:8080/2"},
+			nil,
+			false,
+		},
+		{
+			"write conflicting url",
+			[]store.Record{
+				{CorrelationID: "a", OriginalURL: "https://example.org/a"},
+				{CorrelationID: "b", OriginalURL: "https://example.org"},
+			},
+			nil,
+			nil,
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewStore(WithBaseURL("http://localhost:8080"))
+			if _, err := s.WriteURL(context.Background(), "https://example.org", "other"); err != nil {
+				t.Fatalf("WriteURL() error = %v", err)
+			}
+
+			got, err := s.BatchWrite(context.Background(), "test", tt.in, store.WithAtomic())
+			if tt.wantErr != nil || tt.wantConflict {
+				var errConflict *store.ConflictError
+				if tt.wantConflict && !errors.As(err, &errConflict) {
+					t.Errorf("BatchWrite() error = %v, want conflict", err)
+				}
+				if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Errorf("BatchWrite() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				if page, _ := s.ReadUserData(context.Background(), "test", store.UserDataQuery{}); len(page.Records) != 0 {
+					t.Errorf("BatchWrite() must not write anything on error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("BatchWrite() error = %v", err)
+			}
+			for i, rec := range got {
+				if rec.ShortURL != tt.want[i] {
+					t.Errorf("BatchWrite() item %d got = %v, want %v", i, rec.ShortURL, tt.want[i])
+				}
+				if rec.CorrelationID != tt.in[i].CorrelationID {
+					t.Errorf("BatchWrite() item %d lost correlation id", i)
+				}
+			}
+		})
+	}
+}
+
+func TestStore_BatchWritePartial(t *testing.T) {
+	s := NewStore(WithBaseURL("http://localhost:8080"))
+	if _, err := s.WriteAlias(context.Background(), "https://example.org", "taken", "other"); err != nil {
+		t.Fatalf("WriteAlias() error = %v", err)
+	}
+
+	got, err := s.BatchWrite(context.Background(), "test", []store.Record{
+		{CorrelationID: "a", OriginalURL: "https://example.org/a"},
+		{CorrelationID: "b", OriginalURL: "bad"},
+		{CorrelationID: "c", OriginalURL: "https://example.org"},
+		{CorrelationID: "d", OriginalURL: "https://example.org/d", ID: "taken"},
+		{CorrelationID: "e", OriginalURL: "https://example.org/a"},
+	})
+	if err != nil {
+		t.Fatalf("BatchWrite() error = %v", err)
+	}
+
+	want := []struct {
+		shortURL string
+		err      error
+	}{
+		{"http://localhost:8080/2", nil},
+		{"", store.ErrBadInput},
+		{"http://localhost:8080/taken", store.ErrConflict},
+		{"", store.ErrAliasTaken},
+		{"http://localhost:8080/2", nil},
+	}
+	for i, w := range want {
+		if got[i].ShortURL != w.shortURL {
+			t.Errorf("BatchWrite() item %d short url = %v, want %v", i, got[i].ShortURL, w.shortURL)
+		}
+		var errConflict *store.ConflictError
+		switch {
+		case w.err == store.ErrConflict && !errors.As(got[i].Err, &errConflict):
+			t.Errorf("BatchWrite() item %d error = %v, want conflict", i, got[i].Err)
+		case w.err != store.ErrConflict && !errors.Is(got[i].Err, w.err):
+			t.Errorf("BatchWrite() item %d error = %v, want %v", i, got[i].Err, w.err)
+		}
+	}
+
+	if page, err := s.ReadUserData(context.Background(), "test", store.UserDataQuery{}); err != nil || len(page.Records) != 1 {
+		t.Errorf("ReadUserData() got %v, err %v, want 1 row", page, err)
+	}
+}
+
+func TestStore_BatchRemove(t *testing.T) {
+	s := NewStore(WithBaseURL("http://localhost:8080"))
+	owned, _ := s.BatchWrite(context.Background(), "test", []store.Record{
+		{OriginalURL: "https://example.org/a"},
+		{OriginalURL: "https://example.org/b"},
+	})
+	foreign, _ := s.BatchWrite(context.Background(), "other", []store.Record{
+		{OriginalURL: "https://example.org/c"},
+	})
+
+	if _, err := s.BatchRemove(context.Background(), "test", owned[0].ID, foreign[0].ID, "bad-id"); err != nil {
+		t.Fatalf("BatchRemove() error = %v", err)
+	}
+
+	if _, err := s.ReadURL(context.Background(), owned[0].ID); !errors.Is(err, store.ErrDeleted) {
+		t.Errorf("ReadURL() of removed row error = %v, want %v", err, store.ErrDeleted)
+	}
+	if _, err := s.ReadURL(context.Background(), owned[1].ID); err != nil {
+		t.Errorf("ReadURL() of kept row error = %v", err)
+	}
+	if _, err := s.ReadURL(context.Background(), foreign[0].ID); err != nil {
+		t.Errorf("ReadURL() of foreign row error = %v", err)
+	}
+	if page, err := s.ReadUserData(context.Background(), "test", store.UserDataQuery{}); err != nil || len(page.Records) != 1 {
+		t.Errorf("ReadUserData() got %v, err %v, want 1 row", page, err)
+	}
+	if _, err := s.WriteURL(context.Background(), "https://example.org/a", "test"); err != nil {
+		t.Errorf("WriteURL() of removed url error = %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package memorystore
+
+import (
+	"context"
+	"fmt"
+	"shortener/internal/app/service/store"
+	"time"
+)
+
+// store.ClickRecorder interface implementation
+var _ store.ClickRecorder = (*Store)(nil)
+var _ store.LinkStatReader = (*Store)(nil)
+
+// RecordClicks keeps events in memory only, they are not written to the snapshot
+func (s *Store) RecordClicks(ctx context.Context, events ...store.ClickEvent) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	s.clicksMu.Lock()
+	defer s.clicksMu.Unlock()
+
+	for _, e := range events {
+		key, ok := s.idIndex[e.ID]
+		if !ok {
+			continue
+		}
+		s.clicks[key] = append(s.clicks[key], e)
+	}
+
+	return nil
+}
+
+func (s *Store) ReadLinkStat(ctx context.Context, uid string, id string, days int) (*store.LinkStat, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if id == "" {
+		return nil, fmt.Errorf("empty id: %w", store.ErrBadInput)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	key, ok := s.idIndex[id]
+	if !ok || s.db[key].UID != uid {
+		return nil, store.ErrNotFound
+	}
+
+	s.clicksMu.Lock()
+	defer s.clicksMu.Unlock()
+
+	visitors := make(map[string]struct{})
+	counts := make(map[time.Time]int)
+	for _, e := range s.clicks[key] {
+		visitors[e.IP] = struct{}{}
+		counts[store.Day(e.Time)]++
+	}
+
+	return &store.LinkStat{
+		ID:             id,
+		ShortURL:       s.db[key].ShortURL,
+		TotalClicks:    len(s.clicks[key]),
+		UniqueVisitors: len(visitors),
+		Daily:          store.DailySeries(counts, days, time.Now()),
+	}, nil
+}
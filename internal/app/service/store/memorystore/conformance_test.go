@@ -0,0 +1,44 @@
+package memorystore
+
+import (
+	"shortener/internal/app/service/store"
+	"shortener/internal/app/service/store/storetest"
+	"testing"
+)
+
+func TestConformance(t *testing.T) {
+	random, err := store.NewRandomGenerator(8)
+	if err != nil {
+		t.Fatalf("NewRandomGenerator() error = %v", err)
+	}
+
+	generators := map[string]store.IDGenerator{
+		"Sequential":  store.NewSequentialGenerator(36),
+		"Random":      random,
+		"Permutation": store.NewPermutationGenerator("secret"),
+	}
+	for name, g := range generators {
+		t.Run(name, func(t *testing.T) {
+			storetest.Run(t, factory(g))
+		})
+	}
+
+	for _, scope := range []store.DedupScope{store.DedupGlobal, store.DedupUser, store.DedupNone} {
+		t.Run(string(scope), func(t *testing.T) {
+			storetest.RunDedupScope(t, scope, factory(store.NewSequentialGenerator(36), WithDedupScope(scope)))
+		})
+	}
+}
+
+func factory(g store.IDGenerator, opts ...StoreOption) storetest.Factory {
+	return func(t *testing.T) store.Store {
+		s := NewStore(append([]StoreOption{WithBaseURL("http://localhost:8080"), WithIDGenerator(g), WithCanonicalizer(store.NewCanonicalizer())}, opts...)...)
+		if err := s.Start(); err != nil {
+			t.Fatalf("Start() error = %v", err)
+		}
+		t.Cleanup(func() {
+			_ = s.Stop()
+		})
+		return s
+	}
+}
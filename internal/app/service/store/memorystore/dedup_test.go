@@ -0,0 +1,35 @@
+package memorystore
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path"
+	"shortener/internal/app/service/store"
+	"testing"
+)
+
+func TestStore_WriteURLCanonical(t *testing.T) {
+	ctx := context.Background()
+	s := NewStore(
+		WithBaseURL("http://localhost:8080"),
+		WithCanonicalizer(store.NewCanonicalizer(store.WithSortedQuery(), store.WithStrippedParams("utm_*"))),
+	)
+
+	const original = "HTTP://Example.com:80/a?b=1&a=2&utm_source=mail"
+	shortURL, err := s.WriteURL(ctx, original, "user1")
+	require.NoError(t, err)
+
+	_, err = s.WriteURL(ctx, "http://example.com/a?a=2&b=1", "user2")
+	var errConflict *store.ConflictError
+	require.True(t, errors.As(err, &errConflict), "expected conflict error, got %v", err)
+	assert.Equal(t, shortURL, errConflict.ExistingURL)
+
+	got, err := s.ReadURL(ctx, path.Base(shortURL))
+	require.NoError(t, err)
+	assert.Equal(t, original, got, "original url must be kept for redirect")
+
+	_, err = s.WriteURL(ctx, "http://example.com/a?a=3", "user2")
+	assert.NoError(t, err)
+}
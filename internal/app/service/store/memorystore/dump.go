@@ -0,0 +1,112 @@
+package memorystore
+
+import (
+	"context"
+	"shortener/internal/app/service/store"
+)
+
+var _ store.Dumper = (*Store)(nil)
+var _ store.Loader = (*Store)(nil)
+
+// Dump returns rows in the key order. Keys are allocated sequentially, so the gaps are left by the purged rows only.
+func (s *Store) Dump(ctx context.Context, after uint64, limit int) ([]store.DumpRow, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows := make([]store.DumpRow, 0, limit)
+	for key := after + 1; key <= s.counter && len(rows) < limit; key++ {
+		row, ok := s.db[key]
+		if !ok {
+			continue
+		}
+		rows = append(rows, store.DumpRow{
+			Key:         key,
+			ID:          row.ID,
+			OriginalURL: row.OriginalURL,
+			UID:         row.UID,
+			CreatedAt:   row.CreatedAt,
+			UpdatedAt:   row.UpdatedAt,
+			DeletedAt:   row.DeletedAt,
+			ExpiresAt:   row.ExpiresAt,
+			Version:     row.version(),
+			Threat:      row.Threat,
+			DisabledAt:  row.DisabledAt,
+		})
+	}
+	return rows, nil
+}
+
+// Load writes rows in one wal record, short urls are built with the store base url.
+// Rows keep the dumped keys if they are free, so the counter moves past the loaded sequential ids and the new rows
+// do not generate them again. Active urls are checked in the dedup scope of the store.
+func (s *Store) Load(ctx context.Context, rows []store.DumpRow) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := make([]walEntry, 0, len(rows))
+	// ids and dedup keys of the active urls of the loaded rows
+	ids := make(map[string]struct{}, len(rows))
+	urls := make(map[string]struct{}, len(rows))
+	keys := make(map[uint64]struct{}, len(rows))
+	for _, r := range rows {
+		if _, ok := s.idIndex[r.ID]; ok {
+			continue
+		}
+		if _, ok := ids[r.ID]; ok {
+			continue
+		}
+		if k, ok := s.dedup.Key(r.UID, r.OriginalURL); ok && r.DeletedAt == nil {
+			if _, ok := s.urlIndex[k]; ok {
+				continue
+			}
+			if _, ok := urls[k]; ok {
+				continue
+			}
+			urls[k] = struct{}{}
+		}
+		ids[r.ID] = struct{}{}
+
+		key := r.Key
+		if _, ok := s.db[key]; ok || key == 0 {
+			key = s.nextKey()
+		} else if _, ok := keys[key]; ok {
+			key = s.nextKey()
+		}
+		if key > s.counter {
+			s.counter = key
+		}
+		keys[key] = struct{}{}
+
+		entries = append(entries, walEntry{
+			Key: key,
+			Row: dbRow{
+				ID:          r.ID,
+				OriginalURL: r.OriginalURL,
+				ShortURL:    s.shortURL(r.ID),
+				UID:         r.UID,
+				CreatedAt:   r.CreatedAt,
+				DeletedAt:   r.DeletedAt,
+				ExpiresAt:   r.ExpiresAt,
+				Version:     r.Version,
+				UpdatedAt:   r.UpdatedAt,
+				Threat:      r.Threat,
+				DisabledAt:  r.DisabledAt,
+			},
+		})
+	}
+
+	if len(entries) > 0 {
+		if err := s.apply(entries...); err != nil {
+			return 0, err
+		}
+	}
+	return len(entries), nil
+}
@@ -0,0 +1,88 @@
+package memorystore
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"shortener/internal/app/service/store"
+	"testing"
+)
+
+func TestStore_DumpLoad(t *testing.T) {
+	ctx := context.Background()
+
+	src := NewStore(WithBaseURL("http://old"))
+	_, err := src.WriteAlias(ctx, "https://example.org/a", "alias", "user1")
+	require.NoError(t, err)
+	removed, err := src.WriteURL(ctx, "https://example.org/b", "user2")
+	require.NoError(t, err)
+	_, err = src.BatchRemove(ctx, "user2", removed[len("http://old/"):])
+	require.NoError(t, err)
+	_, err = src.WriteURL(ctx, "https://example.org/c", "user1")
+	require.NoError(t, err)
+
+	rows, err := src.Dump(ctx, 0, 2)
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, uint64(1), rows[0].Key)
+	assert.Equal(t, "alias", rows[0].ID)
+	assert.Equal(t, "user2", rows[1].UID)
+	assert.NotNil(t, rows[1].DeletedAt, "removed rows must be dumped")
+
+	rest, err := src.Dump(ctx, rows[1].Key, 2)
+	require.NoError(t, err)
+	require.Len(t, rest, 1)
+	assert.Equal(t, "https://example.org/c", rest[0].OriginalURL)
+
+	path := filepath.Join(t.TempDir(), "urls.gob")
+	dst := NewStore(WithBaseURL("http://new"), WithFilePath(path))
+	require.NoError(t, dst.Start())
+	// active url and short id taken in the target
+	_, err = dst.WriteAlias(ctx, "https://example.org/c", "other", "user3")
+	require.NoError(t, err)
+	_, err = dst.WriteAlias(ctx, "https://example.org/d", "alias", "user3")
+	require.NoError(t, err)
+
+	n, err := dst.Load(ctx, append(rows, rest...))
+	require.NoError(t, err)
+	assert.Equal(t, 1, n, "rows with taken short id and active url must be skipped")
+	require.NoError(t, dst.Stop())
+
+	restored := NewStore(WithBaseURL("http://new"), WithFilePath(path))
+	require.NoError(t, restored.Start())
+	defer func() {
+		_ = restored.Stop()
+	}()
+
+	_, err = restored.ReadURL(ctx, rows[1].ID)
+	assert.ErrorIs(t, err, store.ErrDeleted, "deletion state must be preserved")
+	loaded, err := restored.Dump(ctx, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, loaded, 3)
+	assert.Equal(t, rows[1].ID, loaded[2].ID)
+	assert.True(t, rows[1].CreatedAt.Equal(loaded[2].CreatedAt), "creation time must be preserved")
+	assert.Equal(t, "http://new/"+rows[1].ID, restored.db[loaded[2].Key].ShortURL)
+}
+
+func TestStore_LoadGappedKeys(t *testing.T) {
+	ctx := context.Background()
+	s := NewStore(WithBaseURL("http://localhost:8080"))
+
+	// rows 1-19 are purged in the source, so the loaded ids are generated from the keys 20-39
+	var rows []store.DumpRow
+	for key := uint64(20); key < 40; key++ {
+		id, err := s.idGen.Generate(key)
+		require.NoError(t, err)
+		rows = append(rows, store.DumpRow{Key: key, ID: id, OriginalURL: "https://example.org/" + id, UID: "user1"})
+	}
+	n, err := s.Load(ctx, rows)
+	require.NoError(t, err)
+	require.Equal(t, len(rows), n)
+
+	shortURL, err := s.WriteURL(ctx, "https://example.org/new", "user1")
+	require.NoError(t, err, "counter must move past the loaded ids")
+	id, err := s.idGen.Generate(40)
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:8080/"+id, shortURL)
+}
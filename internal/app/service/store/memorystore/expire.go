@@ -0,0 +1,29 @@
+package memorystore
+
+import (
+	"time"
+)
+
+// purgeExpired removes rows expired and operations completed more than grace period before now,
+// and rows removed more than retention period before now. Must be called under the write lock.
+func (s *Store) purgeExpired(now time.Time) error {
+	deadline := now.Add(-s.expiredGracePeriod)
+	retained := now.Add(-s.deletedRetention)
+
+	s.purgeOperations(deadline)
+
+	var entries []walEntry
+	for key, row := range s.db {
+		expired := row.ExpiresAt != nil && row.ExpiresAt.Before(deadline)
+		removed := row.DeletedAt != nil && row.DeletedAt.Before(retained)
+		if expired || removed {
+			entries = append(entries, walEntry{Key: key, Row: row, Purged: true})
+		}
+	}
+
+	if len(entries) == 0 {
+		return nil
+	}
+
+	return s.apply(entries...)
+}
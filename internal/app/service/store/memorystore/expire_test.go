@@ -0,0 +1,81 @@
+package memorystore
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"shortener/internal/app/service/store"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStore_PurgeExpired(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "urls.gob")
+
+	s := NewStore(WithBaseURL("http://localhost:8080"), WithFilePath(path), WithExpiredGracePeriod(time.Hour))
+	require.NoError(t, s.Start())
+
+	expiresAt := time.Now().Add(time.Minute)
+	expiring, err := s.WriteAlias(context.Background(), "https://example.org/a", "expiring", "test", store.WithExpiresAt(&expiresAt))
+	require.NoError(t, err)
+	kept, err := s.WriteURL(context.Background(), "https://example.org/b", "test")
+	require.NoError(t, err)
+
+	// within the grace period expired row is kept
+	s.mu.Lock()
+	require.NoError(t, s.purgeExpired(expiresAt.Add(time.Minute)))
+	s.mu.Unlock()
+	_, err = s.ReadURL(context.Background(), "expiring")
+	assert.NoError(t, err)
+
+	s.mu.Lock()
+	require.NoError(t, s.purgeExpired(expiresAt.Add(2*time.Hour)))
+	s.mu.Unlock()
+	_, err = s.ReadURL(context.Background(), "expiring")
+	assert.ErrorIs(t, err, store.ErrNotFound)
+	// no Stop call: purge must be restored from the write-ahead log
+
+	restored := NewStore(WithBaseURL("http://localhost:8080"), WithFilePath(path))
+	require.NoError(t, restored.Start())
+	defer func() {
+		_ = restored.Stop()
+	}()
+
+	_, err = restored.ReadURL(context.Background(), "expiring")
+	assert.ErrorIs(t, err, store.ErrNotFound)
+	_, err = restored.ReadURL(context.Background(), strings.TrimPrefix(kept, "http://localhost:8080/"))
+	assert.NoError(t, err)
+
+	// purged alias is free again
+	reused, err := restored.WriteAlias(context.Background(), "https://example.org/c", "expiring", "test")
+	require.NoError(t, err)
+	assert.Equal(t, expiring, reused)
+}
+
+func TestStore_PurgeDeleted(t *testing.T) {
+	s := NewStore(WithBaseURL("http://localhost:8080"), WithDeletedRetention(time.Hour))
+
+	_, err := s.WriteAlias(context.Background(), "https://example.org/a", "removed", "test")
+	require.NoError(t, err)
+	_, err = s.BatchRemove(context.Background(), "test", "removed")
+	require.NoError(t, err)
+
+	// within the retention period removed row is kept and can be restored
+	s.mu.Lock()
+	require.NoError(t, s.purgeExpired(time.Now().Add(time.Minute)))
+	s.mu.Unlock()
+	_, err = s.ReadURL(context.Background(), "removed")
+	assert.ErrorIs(t, err, store.ErrDeleted)
+
+	s.mu.Lock()
+	require.NoError(t, s.purgeExpired(time.Now().Add(2*time.Hour)))
+	s.mu.Unlock()
+	_, err = s.ReadURL(context.Background(), "removed")
+	assert.ErrorIs(t, err, store.ErrNotFound)
+
+	items, err := s.Restore(context.Background(), "test", "removed")
+	require.NoError(t, err)
+	assert.Equal(t, []store.OperationItem{{ID: "removed", Outcome: store.OutcomeNotFound}}, items)
+}
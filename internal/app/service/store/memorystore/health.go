@@ -0,0 +1,23 @@
+package memorystore
+
+import (
+	"context"
+	"shortener/internal/app/service/store"
+)
+
+// store.HealthChecker interface implementation
+var _ store.HealthChecker = (*Store)(nil)
+
+func (s *Store) HealthCheck(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.dbFlushTicker == nil {
+		return ErrNotStarted
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package memorystore
+
+import (
+	"context"
+	"fmt"
+	"shortener/internal/app/service/store"
+	"time"
+)
+
+var _ store.Editor = (*Store)(nil)
+
+func (s *Store) UpdateURL(ctx context.Context, uid string, id string, url string) (*store.URLVersion, error) {
+	if err := store.ValidateURL(url); err != nil {
+		return nil, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, row, err := s.activeRow(uid, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.setURL(key, row, url)
+}
+
+func (s *Store) ReadHistory(ctx context.Context, uid string, id string) ([]store.URLVersion, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, row, err := s.ownedRow(uid, id)
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]store.URLVersion, 0, len(row.History)+1)
+	history = append(history, row.current())
+	for i := len(row.History) - 1; i >= 0; i-- {
+		v := row.History[i]
+		history = append(history, store.URLVersion{Version: v.Version, OriginalURL: v.OriginalURL, CreatedAt: v.CreatedAt})
+	}
+	return history, nil
+}
+
+func (s *Store) RollbackURL(ctx context.Context, uid string, id string, version int) (*store.URLVersion, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, row, err := s.activeRow(uid, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if version == row.version() {
+		current := row.current()
+		return &current, nil
+	}
+	for _, v := range row.History {
+		if v.Version == version {
+			return s.setURL(key, row, v.OriginalURL)
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %d", store.ErrVersionNotFound, version)
+}
+
+// ownedRow returns row of the user, store.ErrNotFound is returned for the rows of other users.
+// Must be called under the lock.
+func (s *Store) ownedRow(uid string, id string) (uint64, dbRow, error) {
+	key, ok := s.idIndex[id]
+	if !ok || s.db[key].UID != uid {
+		return 0, dbRow{}, store.ErrNotFound
+	}
+	return key, s.db[key], nil
+}
+
+// activeRow returns working row of the user. Must be called under the lock.
+func (s *Store) activeRow(uid string, id string) (uint64, dbRow, error) {
+	key, row, err := s.ownedRow(uid, id)
+	if err != nil {
+		return 0, dbRow{}, err
+	}
+	if row.DeletedAt != nil {
+		return 0, dbRow{}, store.ErrDeleted
+	}
+	if store.Expired(row.ExpiresAt, time.Now()) {
+		return 0, dbRow{}, store.ErrExpired
+	}
+	return key, row, nil
+}
+
+// setURL archives the current destination of the row and sets the new one. Must be called under the write lock.
+func (s *Store) setURL(key uint64, row dbRow, url string) (*store.URLVersion, error) {
+	if row.OriginalURL == url {
+		current := row.current()
+		return &current, nil
+	}
+
+	// the new url may have the same canonical form as the current one, the row does not conflict with itself
+	var released []walEntry
+	if k, ok := s.dedup.Key(row.UID, url); !ok || !s.indexedAs(k, key) {
+		var err error
+		if released, err = s.checkConflict(row.UID, url); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	history := make([]urlVersion, len(row.History), len(row.History)+1)
+	copy(history, row.History)
+	row.History = append(history, urlVersion{Version: row.version(), OriginalURL: row.OriginalURL, CreatedAt: row.setAt()})
+	row.Version = row.version() + 1
+	row.OriginalURL = url
+	row.UpdatedAt = &now
+	// threat of the previous destination, the new one is checked separately
+	row.Threat = ""
+
+	if err := s.apply(append(released, walEntry{Key: key, Row: row})...); err != nil {
+		return nil, err
+	}
+
+	current := row.current()
+	return &current, nil
+}
+
+// version of the current destination
+func (r dbRow) version() int {
+	if r.Version == 0 {
+		return 1
+	}
+	return r.Version
+}
+
+// setAt returns the moment the current destination was set
+func (r dbRow) setAt() time.Time {
+	if r.UpdatedAt != nil {
+		return *r.UpdatedAt
+	}
+	return r.CreatedAt
+}
+
+func (r dbRow) current() store.URLVersion {
+	return store.URLVersion{Version: r.version(), OriginalURL: r.OriginalURL, CreatedAt: r.setAt()}
+}
@@ -0,0 +1,43 @@
+package memorystore
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"testing"
+)
+
+func TestStore_HistoryRecovery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "urls.gob")
+
+	s := NewStore(WithBaseURL("http://localhost:8080"), WithFilePath(path))
+	require.NoError(t, s.Start())
+
+	_, err := s.WriteAlias(context.Background(), "https://example.org/a", "edited", "test")
+	require.NoError(t, err)
+	_, err = s.UpdateURL(context.Background(), "test", "edited", "https://example.org/b")
+	require.NoError(t, err)
+	want, err := s.ReadHistory(context.Background(), "test", "edited")
+	require.NoError(t, err)
+	// no Stop call: history must be restored from the write-ahead log
+
+	restored := NewStore(WithBaseURL("http://localhost:8080"), WithFilePath(path))
+	require.NoError(t, restored.Start())
+	defer func() {
+		_ = restored.Stop()
+	}()
+
+	got, err := restored.ReadHistory(context.Background(), "test", "edited")
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	for i := range want {
+		assert.Equal(t, want[i].Version, got[i].Version)
+		assert.Equal(t, want[i].OriginalURL, got[i].OriginalURL)
+		assert.True(t, want[i].CreatedAt.Equal(got[i].CreatedAt))
+	}
+
+	// previous destination is released
+	_, err = restored.WriteURL(context.Background(), "https://example.org/a", "test")
+	assert.NoError(t, err)
+}
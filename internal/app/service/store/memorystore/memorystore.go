@@ -7,6 +7,7 @@ import (
 	"io"
 	"log"
 	"os"
+	"path/filepath"
 	"shortener/internal/app/service/store"
 	"sync"
 	"time"
@@ -15,27 +16,44 @@ import (
 var ErrAlreadyStarted = errors.New("already started")
 var ErrNotStarted = errors.New("not started")
 
+// store.Backend interface implementation
+var _ store.Backend = (*Store)(nil)
+
 type Store struct {
-	mu              sync.RWMutex
-	listenAddr      string
-	baseURL         string
-	counter         uint64
-	base            int
-	db              db
+	mu         sync.RWMutex
+	listenAddr string
+	baseURL    string
+	counter    uint64
+	idGen      store.IDGenerator
+	db         db
+	// urlIndex maps dedup keys of the active rows to their keys, it is empty if the links are not deduplicated
+	urlIndex        index
+	idIndex         index
 	dbFilePath      string
+	wal             *wal
 	dbFlushInterval time.Duration
 	dbFlushCh       chan struct{}
 	dbFlushTicker   *time.Ticker
-}
-
-func (s *Store) BatchWrite(uid string, in []store.Record) ([]store.Record, error) {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (s *Store) BatchRemove(uid string, ids ...string) error {
-	//TODO implement me
-	panic("implement me")
+	// expiredGracePeriod is kept for the expired rows before they are purged
+	expiredGracePeriod time.Duration
+	// deletedRetention is kept for the removed rows, they can be restored before they are purged
+	deletedRetention time.Duration
+	// dedup defines which active rows are duplicates
+	dedup store.Dedup
+
+	// clicks of the rows, kept in memory only
+	clicksMu sync.Mutex
+	clicks   map[uint64][]store.ClickEvent
+
+	// operations of the users, kept in memory only
+	opsMu      sync.Mutex
+	operations map[string]userOperation
+
+	// open abuse reports and banned users, kept in memory only
+	modMu         sync.Mutex
+	reportCounter int64
+	reports       map[int64]report
+	banned        map[string]struct{}
 }
 
 type db map[uint64]dbRow
@@ -44,17 +62,84 @@ type dbRow struct {
 	OriginalURL string
 	ShortURL    string
 	UID         string
+	CreatedAt   time.Time
+	DeletedAt   *time.Time
+	ExpiresAt   *time.Time
+	// Version of the destination, zero in the rows written before the destinations became editable means 1
+	Version int
+	// UpdatedAt is the moment the current destination was set, nil if it was never changed
+	UpdatedAt *time.Time
+	// History of the previous destinations
+	History []urlVersion
+	// Threat the current destination is quarantined for
+	Threat string
+	// DisabledAt is the moment the row was disabled by the operator
+	DisabledAt *time.Time
+}
+
+// urlVersion is a previous destination of the row
+type urlVersion struct {
+	Version     int
+	OriginalURL string
+	CreatedAt   time.Time
+}
+
+// index maps string values to the db keys
+type index map[string]uint64
+
+// buildURLIndex maps dedup keys of the active (not deleted) rows to their keys
+func (d db) buildURLIndex(dedup store.Dedup) index {
+	idx := make(index, len(d))
+	for key, row := range d {
+		if row.DeletedAt != nil {
+			continue
+		}
+		if k, ok := dedup.Key(row.UID, row.OriginalURL); ok {
+			idx[k] = key
+		}
+	}
+	return idx
+}
+
+// buildIDIndex maps short ids of the rows to their keys
+func (d db) buildIDIndex() index {
+	idx := make(index, len(d))
+	for key, row := range d {
+		idx[row.ID] = key
+	}
+	return idx
+}
+
+// maxID of the db rows
+func (d db) maxID() uint64 {
+	var max uint64
+	for id := range d {
+		if id > max {
+			max = id
+		}
+	}
+	return max
 }
 
 func NewStore(opts ...StoreOption) *Store {
 	const (
 		defaultBase          = 36
 		defaultFlushInterval = time.Second * 5
+		defaultGracePeriod   = time.Hour * 24
+		defaultRetention     = time.Hour * 24 * 30
 	)
 	s := &Store{
-		base:            defaultBase,
-		dbFlushInterval: defaultFlushInterval,
-		db:              make(db),
+		idGen:              store.NewSequentialGenerator(defaultBase),
+		dbFlushInterval:    defaultFlushInterval,
+		expiredGracePeriod: defaultGracePeriod,
+		deletedRetention:   defaultRetention,
+		db:                 make(db),
+		urlIndex:           make(index),
+		idIndex:            make(index),
+		clicks:             make(map[uint64][]store.ClickEvent),
+		operations:         make(map[string]userOperation),
+		reports:            make(map[int64]report),
+		banned:             make(map[string]struct{}),
 	}
 
 	for _, opt := range opts {
@@ -78,6 +163,13 @@ func WithBaseURL(v string) StoreOption {
 	}
 }
 
+// WithIDGenerator sets short id generation strategy, sequential base36 ids are generated by default
+func WithIDGenerator(g store.IDGenerator) StoreOption {
+	return func(s *Store) {
+		s.idGen = g
+	}
+}
+
 func WithFilePath(v string) StoreOption {
 	return func(s *Store) {
 		s.dbFilePath = v
@@ -90,7 +182,35 @@ func WithFlushInterval(v time.Duration) StoreOption {
 	}
 }
 
-// Start periodic db flushing
+// WithExpiredGracePeriod sets how long expired rows are kept before they are purged
+func WithExpiredGracePeriod(v time.Duration) StoreOption {
+	return func(s *Store) {
+		s.expiredGracePeriod = v
+	}
+}
+
+// WithDedupScope sets among which rows active urls must be unique, urls are unique across all users by default
+func WithDedupScope(v store.DedupScope) StoreOption {
+	return func(s *Store) {
+		s.dedup.Scope = v
+	}
+}
+
+// WithCanonicalizer sets conversion of the urls into the canonical form used to detect duplicates
+func WithCanonicalizer(c *store.Canonicalizer) StoreOption {
+	return func(s *Store) {
+		s.dedup.Canonicalizer = c
+	}
+}
+
+// WithDeletedRetention sets how long removed rows can be restored before they are purged
+func WithDeletedRetention(v time.Duration) StoreOption {
+	return func(s *Store) {
+		s.deletedRetention = v
+	}
+}
+
+// Start loads db from the snapshot and write-ahead log and starts periodic snapshotting and expired and removed rows purging
 func (s *Store) Start() error {
 	start := make(chan struct{})
 	defer close(start)
@@ -104,7 +224,7 @@ func (s *Store) Start() error {
 		return ErrAlreadyStarted
 	}
 
-	if err := s.readDB(false); err != nil {
+	if err := s.load(); err != nil {
 		return fmt.Errorf("serve error: %w", err)
 	}
 
@@ -112,25 +232,29 @@ func (s *Store) Start() error {
 	s.dbFlushCh = make(chan struct{})
 	s.dbFlushTicker = time.NewTicker(s.dbFlushInterval)
 
-	go func() {
+	go func(done <-chan struct{}, ticker *time.Ticker) {
 		<-start
 		for {
 			select {
-			case <-s.dbFlushCh:
+			case <-done:
 				return
-			case <-s.dbFlushTicker.C:
-				//log.Print("timer writing db")
-				s.mu.RLock()
-				_ = s.writeDB(false)
-				s.mu.RUnlock()
+			case <-ticker.C:
+				s.mu.Lock()
+				if err := s.purgeExpired(time.Now()); err != nil {
+					log.Printf("purge error: %v", err)
+				}
+				if err := s.snapshot(); err != nil {
+					log.Printf("snapshot error: %v", err)
+				}
+				s.mu.Unlock()
 			}
 		}
-	}()
+	}(s.dbFlushCh, s.dbFlushTicker)
 
 	return nil
 }
 
-// Stop periodic db flushing
+// Stop periodic snapshotting, write the final snapshot and close the write-ahead log
 func (s *Store) Stop() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -146,59 +270,185 @@ func (s *Store) Stop() error {
 
 	// stop and reset ticker
 	s.dbFlushTicker.Stop()
+	s.dbFlushTicker = nil
+
+	if s.wal == nil {
+		return nil
+	}
 
 	// write db to file
-	_ = s.writeDB(false)
+	if err := s.snapshot(); err != nil {
+		return fmt.Errorf("snapshot: %w", err)
+	}
+	if err := s.wal.close(); err != nil {
+		return fmt.Errorf("wal close: %w", err)
+	}
+	s.wal = nil
+
+	return nil
+}
+
+// walPath returns write-ahead log file path
+func (s *Store) walPath() string {
+	return s.dbFilePath + ".wal"
+}
+
+// load db from the snapshot, replay write-ahead log over it and open the log for appending.
+// Must be called under the write lock.
+func (s *Store) load() error {
+	// pure in-memory store
+	if s.dbFilePath == "" {
+		return nil
+	}
+
+	if err := s.readDB(); err != nil {
+		return err
+	}
+
+	replayed := 0
+	size, err := replayWAL(s.walPath(), func(entries []walEntry) {
+		for _, e := range entries {
+			if e.Purged {
+				delete(s.db, e.Key)
+				continue
+			}
+			s.db[e.Key] = e.Row
+		}
+		replayed++
+	})
+	if err != nil {
+		return fmt.Errorf("wal replay: %w", err)
+	}
+
+	s.counter = s.db.maxID()
+	s.urlIndex = s.db.buildURLIndex(s.dedup)
+	s.idIndex = s.db.buildIDIndex()
+	log.Printf("db records loaded: %d, wal records replayed: %d", len(s.db), replayed)
+
+	if s.wal, err = openWAL(s.walPath(), size); err != nil {
+		return err
+	}
+
+	// compact replayed records into the snapshot
+	return s.snapshot()
+}
+
+// apply mutations to db. Mutations are written to the write-ahead log first,
+// so they are not lost in case of crash. Must be called under the write lock.
+func (s *Store) apply(entries ...walEntry) error {
+	if s.wal != nil {
+		if err := s.wal.append(entries...); err != nil {
+			return fmt.Errorf("wal append: %w", err)
+		}
+	}
+
+	for _, e := range entries {
+		if prev, ok := s.db[e.Key]; ok {
+			if k, ok := s.dedup.Key(prev.UID, prev.OriginalURL); ok && s.urlIndex[k] == e.Key {
+				delete(s.urlIndex, k)
+			}
+		}
+		if e.Purged {
+			if s.idIndex[e.Row.ID] == e.Key {
+				delete(s.idIndex, e.Row.ID)
+			}
+			delete(s.db, e.Key)
+			s.clicksMu.Lock()
+			delete(s.clicks, e.Key)
+			s.clicksMu.Unlock()
+			s.dropReports(e.Key)
+			continue
+		}
+		if k, ok := s.dedup.Key(e.Row.UID, e.Row.OriginalURL); ok && e.Row.DeletedAt == nil {
+			s.urlIndex[k] = e.Key
+		}
+		s.idIndex[e.Row.ID] = e.Key
+		s.db[e.Key] = e.Row
+	}
+
 	return nil
 }
 
-// readDB from file
-func (s *Store) readDB(doLock bool) error {
-	file, err := os.OpenFile(s.dbFilePath, os.O_RDONLY|os.O_CREATE, 0777)
+// snapshot writes db to file and resets write-ahead log. Must be called under the lock.
+func (s *Store) snapshot() error {
+	// pure in-memory store or nothing changed since the last snapshot
+	if s.wal == nil || s.wal.empty() {
+		return nil
+	}
+
+	if err := s.writeDB(); err != nil {
+		return err
+	}
+
+	if err := s.wal.reset(); err != nil {
+		return fmt.Errorf("wal reset: %w", err)
+	}
+
+	return nil
+}
+
+// readDB from the snapshot file
+func (s *Store) readDB() error {
+	file, err := os.Open(s.dbFilePath)
 	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
 		return fmt.Errorf("error reading db at %q: %w", s.dbFilePath, err)
 	}
 	defer func() {
 		_ = file.Close()
 	}()
 
-	decoder := gob.NewDecoder(file)
-
-	if doLock {
-		s.mu.Lock()
-		defer s.mu.Unlock()
-	}
-
-	err = decoder.Decode(&s.db)
+	err = gob.NewDecoder(file).Decode(&s.db)
 	if err != nil && err != io.EOF {
 		return fmt.Errorf("decode error: %w", err)
 	}
-	s.counter = uint64(len(s.db))
-	log.Printf("db records loaded: %d", s.counter)
 
 	return nil
 }
 
-// writeDB db to file
-func (s *Store) writeDB(doLock bool) error {
-	file, err := os.OpenFile(s.dbFilePath, os.O_WRONLY|os.O_CREATE, 0777)
+// writeDB to the snapshot file atomically: db is written to the temp file which replaces the snapshot
+func (s *Store) writeDB() error {
+	dir := filepath.Dir(s.dbFilePath)
+
+	file, err := os.CreateTemp(dir, filepath.Base(s.dbFilePath)+".tmp*")
 	if err != nil {
 		return fmt.Errorf("error writing db at %q: %w", s.dbFilePath, err)
 	}
 	defer func() {
 		_ = file.Close()
+		_ = os.Remove(file.Name())
 	}()
 
-	encoder := gob.NewEncoder(file)
+	if err = gob.NewEncoder(file).Encode(&s.db); err != nil {
+		return fmt.Errorf("encode error: %w", err)
+	}
+	if err = file.Sync(); err != nil {
+		return fmt.Errorf("sync error: %w", err)
+	}
+	if err = file.Close(); err != nil {
+		return fmt.Errorf("close error: %w", err)
+	}
+	if err = os.Rename(file.Name(), s.dbFilePath); err != nil {
+		return fmt.Errorf("rename error: %w", err)
+	}
+
+	return syncDir(dir)
+}
 
-	if doLock {
-		s.mu.RLock()
-		defer s.mu.RUnlock()
+// syncDir makes directory entries changes (like rename) durable
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("dir open: %w", err)
 	}
+	defer func() {
+		_ = d.Close()
+	}()
 
-	err = encoder.Encode(&s.db)
-	if err != nil && err != io.EOF {
-		return fmt.Errorf("encode error: %w", err)
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("dir sync: %w", err)
 	}
 
 	return nil
@@ -1,15 +1,27 @@
 package memorystore
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"time"
 )
 
 func Example() {
+	dir, err := os.MkdirTemp("", "memorystore")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
 	s := NewStore(
 		WithBaseURL("http://localhost:8080"),
 		WithListenAddr("localhost:8080"),
-		WithFilePath("test_urls.data"),
+		WithFilePath(filepath.Join(dir, "test_urls.data")),
 		WithFlushInterval(time.Second),
 	)
 
@@ -18,7 +30,7 @@ func Example() {
 		return
 	}
 
-	if url, err := s.WriteURL("http://somelongurl.test/foo/bar", "user1"); err != nil {
+	if url, err := s.WriteURL(context.Background(), "http://somelongurl.test/foo/bar", "user1"); err != nil {
 		fmt.Println(err)
 		return
 	} else {
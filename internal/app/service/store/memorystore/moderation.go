@@ -0,0 +1,151 @@
+package memorystore
+
+import (
+	"context"
+	"fmt"
+	"shortener/internal/app/service/store"
+	"sort"
+	"time"
+)
+
+var _ store.Moderator = (*Store)(nil)
+
+// report is an open abuse report of the row
+type report struct {
+	key uint64
+	r   store.AbuseReport
+}
+
+// Report keeps the report in memory only, it is not written to the snapshot
+func (s *Store) Report(ctx context.Context, r store.AbuseReport) (*store.AbuseReport, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	key, ok := s.idIndex[r.LinkID]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+
+	s.modMu.Lock()
+	defer s.modMu.Unlock()
+
+	s.reportCounter++
+	r.ID = s.reportCounter
+	r.CreatedAt = time.Now()
+	s.reports[r.ID] = report{key: key, r: r}
+
+	return &r, nil
+}
+
+func (s *Store) ReadReports(ctx context.Context, q store.ReportQuery) ([]store.AbuseReport, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := q.Normalize(); err != nil {
+		return nil, err
+	}
+
+	s.modMu.Lock()
+	defer s.modMu.Unlock()
+
+	res := make([]store.AbuseReport, 0, len(s.reports))
+	for id, rep := range s.reports {
+		if id > q.After && (q.LinkID == "" || rep.r.LinkID == q.LinkID) {
+			res = append(res, rep.r)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].ID < res[j].ID
+	})
+	if len(res) > q.Limit {
+		res = res[:q.Limit]
+	}
+
+	return res, nil
+}
+
+// SetDisabled writes the row and drops its open reports. Time of the disabled row is kept if it is disabled again.
+func (s *Store) SetDisabled(ctx context.Context, id string, disabled bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if id == "" {
+		return fmt.Errorf("empty id: %w", store.ErrBadInput)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.idIndex[id]
+	if !ok {
+		return store.ErrNotFound
+	}
+
+	row := s.db[key]
+	if disabled != (row.DisabledAt != nil) {
+		row.DisabledAt = nil
+		if disabled {
+			now := time.Now()
+			row.DisabledAt = &now
+		}
+		if err := s.apply(walEntry{Key: key, Row: row}); err != nil {
+			return err
+		}
+	}
+	s.dropReports(key)
+
+	return nil
+}
+
+// SetBanned keeps bans in memory only, they are not written to the snapshot
+func (s *Store) SetBanned(ctx context.Context, uid string, banned bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if uid == "" {
+		return fmt.Errorf("empty uid: %w", store.ErrBadInput)
+	}
+
+	s.modMu.Lock()
+	defer s.modMu.Unlock()
+
+	if banned {
+		s.banned[uid] = struct{}{}
+	} else {
+		delete(s.banned, uid)
+	}
+
+	return nil
+}
+
+// disabled reports if the row is disabled or its owner is banned
+func (s *Store) disabled(row dbRow) bool {
+	if row.DisabledAt != nil {
+		return true
+	}
+
+	s.modMu.Lock()
+	defer s.modMu.Unlock()
+
+	_, ok := s.banned[row.UID]
+	return ok
+}
+
+// dropReports removes reports of the row
+func (s *Store) dropReports(key uint64) {
+	s.modMu.Lock()
+	defer s.modMu.Unlock()
+
+	for id, rep := range s.reports {
+		if rep.key == key {
+			delete(s.reports, id)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package memorystore
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"shortener/internal/app/service/store"
+	"time"
+)
+
+var _ store.OperationReader = (*Store)(nil)
+
+// userOperation is an operation with its owner
+type userOperation struct {
+	uid string
+	op  *store.Operation
+}
+
+// newOperation returns completed operation with the items to fill
+func newOperation(now time.Time, items int) *store.Operation {
+	return &store.Operation{
+		ID:          uuid.New().String(),
+		Status:      store.OperationCompleted,
+		CreatedAt:   now,
+		CompletedAt: &now,
+		Items:       make([]store.OperationItem, items),
+	}
+}
+
+func (s *Store) addOperation(uid string, op *store.Operation) {
+	s.opsMu.Lock()
+	defer s.opsMu.Unlock()
+
+	s.operations[op.ID] = userOperation{uid: uid, op: op}
+}
+
+func (s *Store) ReadOperation(ctx context.Context, uid string, id string) (*store.Operation, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.opsMu.Lock()
+	defer s.opsMu.Unlock()
+
+	uo, ok := s.operations[id]
+	if !ok || uo.uid != uid {
+		return nil, fmt.Errorf("operation %q: %w", id, store.ErrNotFound)
+	}
+
+	op := *uo.op
+	op.Items = append([]store.OperationItem(nil), uo.op.Items...)
+	return &op, nil
+}
+
+// purgeOperations removes operations completed before the deadline
+func (s *Store) purgeOperations(deadline time.Time) {
+	s.opsMu.Lock()
+	defer s.opsMu.Unlock()
+
+	for id, uo := range s.operations {
+		if uo.op.CompletedAt != nil && uo.op.CompletedAt.Before(deadline) {
+			delete(s.operations, id)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package memorystore
+
+import (
+	"context"
+	"shortener/internal/app/service/store"
+)
+
+var _ store.Quarantiner = (*Store)(nil)
+
+// Quarantine sets threats of the rows in one wal record, items are expected to have distinct ids
+func (s *Store) Quarantine(ctx context.Context, items ...store.QuarantineItem) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := make([]walEntry, 0, len(items))
+	for _, item := range items {
+		key, ok := s.idIndex[item.ID]
+		if !ok {
+			continue
+		}
+		row := s.db[key]
+		if row.OriginalURL != item.OriginalURL || row.Threat == item.Threat {
+			continue
+		}
+		row.Threat = item.Threat
+		entries = append(entries, walEntry{Key: key, Row: row})
+	}
+
+	if len(entries) > 0 {
+		if err := s.apply(entries...); err != nil {
+			return 0, err
+		}
+	}
+	return len(entries), nil
+}
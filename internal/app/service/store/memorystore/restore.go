@@ -0,0 +1,73 @@
+package memorystore
+
+import (
+	"context"
+	"shortener/internal/app/service/store"
+	"time"
+)
+
+var _ store.Restorer = (*Store)(nil)
+
+func (s *Store) Restore(ctx context.Context, uid string, ids ...string) ([]store.OperationItem, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(ids) == 0 {
+		return nil, store.ErrEmptyIDs
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	retained := time.Now().Add(-s.deletedRetention)
+	items := make([]store.OperationItem, len(ids))
+	entries := make([]walEntry, 0, len(ids))
+	// dedup keys restored by the request mapped to the row keys, so the same url is not restored twice
+	restored := make(map[string]uint64, len(ids))
+	for i, id := range ids {
+		items[i] = store.OperationItem{ID: id, Outcome: store.OutcomeRestored}
+
+		if store.ValidateAlias(id) != nil {
+			items[i].Outcome = store.OutcomeInvalid
+			continue
+		}
+		key, ok := s.idIndex[id]
+		if !ok || s.db[key].UID != uid {
+			items[i].Outcome = store.OutcomeNotFound
+			continue
+		}
+		row := s.db[key]
+		dedupKey, dedup := s.dedup.Key(row.UID, row.OriginalURL)
+		if restoredKey, ok := restored[dedupKey]; dedup && ok {
+			if restoredKey != key {
+				items[i].Outcome = store.OutcomeConflict
+			}
+			continue
+		}
+		switch {
+		case row.DeletedAt == nil:
+			items[i].Outcome = store.OutcomeNotDeleted
+		case row.DeletedAt.Before(retained):
+			items[i].Outcome = store.OutcomeNotFound
+		default:
+			if _, ok := s.urlIndex[dedupKey]; dedup && ok {
+				items[i].Outcome = store.OutcomeConflict
+				continue
+			}
+			row.DeletedAt = nil
+			entries = append(entries, walEntry{Key: key, Row: row})
+			if dedup {
+				restored[dedupKey] = key
+			}
+		}
+	}
+
+	if len(entries) > 0 {
+		if err := s.apply(entries...); err != nil {
+			return nil, err
+		}
+	}
+
+	return items, nil
+}
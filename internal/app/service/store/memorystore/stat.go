@@ -0,0 +1,28 @@
+package memorystore
+
+import (
+	"context"
+	"shortener/internal/app/service/store"
+)
+
+// store.StatProvider interface implementation
+var _ store.StatProvider = (*Store)(nil)
+
+func (s *Store) Stat(ctx context.Context) (*store.StatData, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	users := make(map[string]struct{})
+	for _, row := range s.db {
+		users[row.UID] = struct{}{}
+	}
+
+	return &store.StatData{
+		URLCount:  len(s.db),
+		UserCount: len(users),
+	}, nil
+}
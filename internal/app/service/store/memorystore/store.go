@@ -1,62 +1,285 @@
 package memorystore
 
 import (
+	"context"
 	"fmt"
 	"shortener/internal/app/service/store"
-	"strconv"
+	"sort"
+	"strings"
+	"time"
 )
 
 // store.Store interface implementation
 var _ store.Store = (*Store)(nil)
 
-func (s *Store) ReadURL(id string) (string, error) {
-	intID, err := strconv.ParseUint(id, s.base, 64)
-	if err != nil {
-		return "", fmt.Errorf("invalid id %q: %w", id, store.ErrBadInput)
+func (s *Store) ReadURL(ctx context.Context, id string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	if id == "" {
+		return "", fmt.Errorf("empty id: %w", store.ErrBadInput)
 	}
 
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	if val, ok := s.db[intID]; ok {
-		return val.OriginalURL, nil
+	key, ok := s.idIndex[id]
+	if !ok {
+		return "", store.ErrNotFound
 	}
 
-	return "", store.ErrNotFound
+	val := s.db[key]
+
+	if val.DeletedAt != nil {
+		return "", store.ErrDeleted
+	}
+
+	if store.Expired(val.ExpiresAt, time.Now()) {
+		return "", store.ErrExpired
+	}
+
+	if s.disabled(val) {
+		return "", store.ErrDisabled
+	}
+
+	if val.Threat != "" {
+		return "", &store.QuarantineError{OriginalURL: val.OriginalURL, Threat: val.Threat}
+	}
+
+	return val.OriginalURL, nil
 }
 
-func (s *Store) WriteURL(url string, uid string) (string, error) {
+func (s *Store) WriteURL(ctx context.Context, url string, uid string, opts ...store.WriteOption) (string, error) {
 	if err := store.ValidateURL(url); err != nil {
 		return "", err
 	}
 
+	o := store.NewWriteOptions(opts...)
+	if err := store.ValidateExpiration(o.ExpiresAt, time.Now()); err != nil {
+		return "", err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.counter++
-	id := strconv.FormatUint(s.counter, s.base)
-	shortURL := fmt.Sprintf("%s/%s", s.baseURL, id)
+	released, err := s.checkConflict(uid, url)
+	if err != nil {
+		return "", err
+	}
 
-	s.db[s.counter] = dbRow{
-		ID:          id,
-		OriginalURL: url,
-		ShortURL:    shortURL,
-		UID:         uid,
+	e, err := s.newRow(store.Record{OriginalURL: url, ExpiresAt: o.ExpiresAt}, uid, nil)
+	if err != nil {
+		return "", err
+	}
+	row, err := s.insert(e, released...)
+	if err != nil {
+		return "", err
 	}
 
-	return shortURL, nil
+	return row.ShortURL, nil
 }
 
-func (s *Store) ReadUserData(uid string) []store.Record {
-	var result []store.Record
-	for _, row := range s.db {
-		if row.UID != uid {
+func (s *Store) WriteAlias(ctx context.Context, url string, alias string, uid string, opts ...store.WriteOption) (string, error) {
+	if err := store.ValidateURL(url); err != nil {
+		return "", err
+	}
+	if err := store.ValidateAlias(alias); err != nil {
+		return "", err
+	}
+
+	o := store.NewWriteOptions(opts...)
+	if err := store.ValidateExpiration(o.ExpiresAt, time.Now()); err != nil {
+		return "", err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkAlias(alias); err != nil {
+		return "", err
+	}
+	released, err := s.checkConflict(uid, url)
+	if err != nil {
+		return "", err
+	}
+
+	row, err := s.insert(s.newAliasRow(store.Record{ID: alias, OriginalURL: url, ExpiresAt: o.ExpiresAt}, uid), released...)
+	if err != nil {
+		return "", err
+	}
+
+	return row.ShortURL, nil
+}
+
+func (s *Store) ReadUserData(ctx context.Context, uid string, q store.UserDataQuery) (*store.UserDataPage, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	cursor, err := q.Normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	keys := make([]uint64, 0)
+	for key, row := range s.db {
+		if row.UID != uid || row.DeletedAt != nil || store.Expired(row.ExpiresAt, now) {
+			continue
+		}
+		if q.CreatedFrom != nil && row.CreatedAt.Before(*q.CreatedFrom) {
 			continue
 		}
-		result = append(result, store.Record{
+		if q.CreatedTo != nil && !row.CreatedAt.Before(*q.CreatedTo) {
+			continue
+		}
+		if q.URLContains != "" && !strings.Contains(row.OriginalURL, q.URLContains) {
+			continue
+		}
+		if cursor != nil && !cursor.After(row.sortKey(key)) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return q.Sort.Less(s.db[keys[i]].sortKey(keys[i]), s.db[keys[j]].sortKey(keys[j]))
+	})
+
+	page := &store.UserDataPage{}
+	if len(keys) > q.Limit {
+		keys = keys[:q.Limit]
+		last := keys[len(keys)-1]
+		page.NextCursor = store.NewCursor(q.Sort, s.db[last].sortKey(last)).Encode()
+	}
+
+	for _, key := range keys {
+		row := s.db[key]
+		page.Records = append(page.Records, store.Record{
+			ID:          row.ID,
 			OriginalURL: row.OriginalURL,
 			ShortURL:    row.ShortURL,
+			CreatedAt:   row.CreatedAt,
+			ExpiresAt:   row.ExpiresAt,
 		})
 	}
-	return result
+	return page, nil
+}
+
+// sortKey of the row with the key
+func (r dbRow) sortKey(key uint64) store.SortKey {
+	return store.SortKey{CreatedAt: r.CreatedAt, URL: r.OriginalURL, RowID: key}
+}
+
+// checkConflict returns store.ConflictError if active row with the same url exists in the dedup scope of the user.
+// Expired row does not conflict, the entry releasing it (marking deleted) is returned to be applied with the new row.
+// Must be called under the lock.
+func (s *Store) checkConflict(uid string, url string) ([]walEntry, error) {
+	k, ok := s.dedup.Key(uid, url)
+	if !ok {
+		return nil, nil
+	}
+	key, ok := s.urlIndex[k]
+	if !ok {
+		return nil, nil
+	}
+
+	row := s.db[key]
+	now := time.Now()
+	if store.Expired(row.ExpiresAt, now) {
+		row.DeletedAt = &now
+		return []walEntry{{Key: key, Row: row}}, nil
+	}
+
+	return nil, &store.ConflictError{
+		ExistingURL: row.ShortURL,
+	}
+}
+
+// indexedAs reports if the dedup key is taken by the row. Must be called under the lock.
+func (s *Store) indexedAs(dedupKey string, key uint64) bool {
+	indexed, ok := s.urlIndex[dedupKey]
+	return ok && indexed == key
+}
+
+// checkAlias returns store.ErrAliasTaken if the alias is used by any row, including deleted ones.
+// Must be called under the lock.
+func (s *Store) checkAlias(alias string) error {
+	if _, ok := s.idIndex[alias]; ok {
+		return fmt.Errorf("%w: %q", store.ErrAliasTaken, alias)
+	}
+	return nil
+}
+
+// insert new row into db together with the released rows. Must be called under the write lock.
+func (s *Store) insert(e walEntry, released ...walEntry) (dbRow, error) {
+	if err := s.apply(append(released, e)...); err != nil {
+		return dbRow{}, err
+	}
+	return e.Row, nil
+}
+
+// newRow allocates key and generates short id for the new row.
+// Reserved ids, ids already taken by aliases or listed in skip are not used. Must be called under the write lock.
+func (s *Store) newRow(rec store.Record, uid string, skip map[string]struct{}) (walEntry, error) {
+	for i := 0; i < store.MaxIDAttempts; i++ {
+		key := s.nextKey()
+		id, err := s.idGen.Generate(key)
+		if err != nil {
+			return walEntry{}, fmt.Errorf("generate id: %w", err)
+		}
+		if store.Reserved(id) {
+			continue
+		}
+		if _, ok := s.idIndex[id]; ok {
+			continue
+		}
+		if _, ok := skip[id]; ok {
+			continue
+		}
+		return s.newEntry(key, id, rec, uid), nil
+	}
+	return walEntry{}, store.ErrIDExhausted
+}
+
+// newAliasRow allocates key for the new row with custom short id taken from the record. Must be called under the write lock.
+func (s *Store) newAliasRow(rec store.Record, uid string) walEntry {
+	return s.newEntry(s.nextKey(), rec.ID, rec, uid)
+}
+
+// nextKey allocates db key. Must be called under the write lock.
+func (s *Store) nextKey() uint64 {
+	s.counter++
+	return s.counter
+}
+
+func (s *Store) newEntry(key uint64, id string, rec store.Record, uid string) walEntry {
+	return walEntry{
+		Key: key,
+		Row: dbRow{
+			ID:          id,
+			OriginalURL: rec.OriginalURL,
+			ShortURL:    s.shortURL(id),
+			UID:         uid,
+			CreatedAt:   time.Now(),
+			ExpiresAt:   rec.ExpiresAt,
+		},
+	}
+}
+
+// shortURL returns short url of the id
+func (s *Store) shortURL(id string) string {
+	return fmt.Sprintf("%s/%s", s.baseURL, id)
 }
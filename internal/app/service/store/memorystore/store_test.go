@@ -1,10 +1,15 @@
 package memorystore
 
 import (
+	"context"
+	"shortener/internal/app/service/store"
 	"testing"
+	"time"
 )
 
 func TestStore_ReadURL(t *testing.T) {
+	deletedAt := time.Now()
+
 	type fields struct {
 		counter uint64
 		db      db
@@ -24,10 +29,10 @@ func TestStore_ReadURL(t *testing.T) {
 			fields{
 				db: db{
 					1: {
-						"1",
-						"https://example.org",
-						"http://localhost/1",
-						"test",
+						ID:          "1",
+						OriginalURL: "https://example.org",
+						ShortURL:    "http://localhost/1",
+						UID:         "test",
 					},
 				},
 			},
@@ -42,10 +47,10 @@ func TestStore_ReadURL(t *testing.T) {
 			fields{
 				db: db{
 					1: {
-						"1",
-						"https://example.org",
-						"http://localhost/1",
-						"test",
+						ID:          "1",
+						OriginalURL: "https://example.org",
+						ShortURL:    "http://localhost/1",
+						UID:         "test",
 					},
 				},
 			},
@@ -60,10 +65,10 @@ func TestStore_ReadURL(t *testing.T) {
 			fields{
 				db: db{
 					1: {
-						"1",
-						"https://example.org",
-						"http://localhost/1",
-						"test",
+						ID:          "1",
+						OriginalURL: "https://example.org",
+						ShortURL:    "http://localhost/1",
+						UID:         "test",
 					},
 				},
 			},
@@ -73,16 +78,36 @@ func TestStore_ReadURL(t *testing.T) {
 			"",
 			true,
 		},
+		{
+			"read deleted",
+			fields{
+				db: db{
+					1: {
+						ID:          "1",
+						OriginalURL: "https://example.org",
+						ShortURL:    "http://localhost/1",
+						UID:         "test",
+						DeletedAt:   &deletedAt,
+					},
+				},
+			},
+			args{
+				id: "1",
+			},
+			"",
+			true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			store := &Store{
 				listenAddr: "localhost:8080",
-				base:       10,
+				idGen:      store.NewSequentialGenerator(10),
 				counter:    tt.fields.counter,
 				db:         tt.fields.db,
+				idIndex:    tt.fields.db.buildIDIndex(),
 			}
-			got, err := store.ReadURL(tt.args.id)
+			got, err := store.ReadURL(context.Background(), tt.args.id)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ReadURL() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -133,17 +158,57 @@ func TestStore_WriteURL(t *testing.T) {
 			"",
 			true,
 		},
+		{
+			"write conflicting url",
+			fields{
+				counter: 1,
+				db: db{
+					1: {
+						ID:          "1",
+						OriginalURL: "https://example.org",
+						ShortURL:    "http://localhost:8080/1",
+						UID:         "other",
+					},
+				},
+			},
+			args{
+				url: "https://example.org",
+			},
+			"",
+			true,
+		},
+		{
+			"skip id taken by alias",
+			fields{
+				counter: 1,
+				db: db{
+					1: {
+						ID:          "2",
+						OriginalURL: "https://example.org/alias",
+						ShortURL:    "http://localhost:8080/2",
+						UID:         "other",
+					},
+				},
+			},
+			args{
+				url: "https://example.org",
+			},
+			"http://localhost:8080/3",
+			false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			store := &Store{
 				listenAddr: "localhost:8080",
 				baseURL:    "http://localhost:8080",
-				base:       10,
+				idGen:      store.NewSequentialGenerator(10),
 				counter:    tt.fields.counter,
 				db:         tt.fields.db,
+				urlIndex:   tt.fields.db.buildURLIndex(store.Dedup{}),
+				idIndex:    tt.fields.db.buildIDIndex(),
 			}
-			got, err := store.WriteURL(tt.args.url, "test")
+			got, err := store.WriteURL(context.Background(), tt.args.url, "test")
 			if (err != nil) != tt.wantErr {
 				t.Errorf("WriteURL() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -154,3 +219,17 @@ func TestStore_WriteURL(t *testing.T) {
 		})
 	}
 }
+
+func TestStore_WriteURLSkipsReserved(t *testing.T) {
+	s := NewStore(WithBaseURL("http://localhost:8080"))
+	// base36 id of the next key is "ping", shadowed by the ping route
+	s.counter = 1190571
+
+	got, err := s.WriteURL(context.Background(), "https://example.org", "test")
+	if err != nil {
+		t.Fatalf("WriteURL() error = %v", err)
+	}
+	if want := "http://localhost:8080/pinh"; got != want {
+		t.Errorf("WriteURL() got = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,172 @@
+package memorystore
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+)
+
+var ErrCorruptedWAL = errors.New("corrupted wal record")
+
+const (
+	// walHeaderSize is a size of the record header: payload length and payload crc32 checksum
+	walHeaderSize = 8
+	// walMaxRecordSize protects from huge allocations when the length header is corrupted
+	walMaxRecordSize = 64 << 20
+)
+
+// walEntry describes a single db mutation: row with the key is set to the new value or removed if purged
+type walEntry struct {
+	Key    uint64
+	Row    dbRow
+	Purged bool
+}
+
+// wal is an append-only write-ahead log of the db mutations.
+//
+// Every record is a batch of entries applied atomically, so it is stored as
+//
+//	[4 bytes payload length][4 bytes payload crc32][gob encoded []walEntry]
+//
+// and is synced to disk before the mutation is applied in memory.
+type wal struct {
+	file *os.File
+	size int64
+}
+
+// openWAL for appending. File is truncated to the size, so torn tail left by crash is dropped.
+func openWAL(path string, size int64) (*wal, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("wal open: %w", err)
+	}
+
+	if err := file.Truncate(size); err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("wal truncate: %w", err)
+	}
+
+	if _, err := file.Seek(size, io.SeekStart); err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("wal seek: %w", err)
+	}
+
+	return &wal{file: file, size: size}, nil
+}
+
+// append record to the log and sync it to disk
+func (w *wal) append(entries ...walEntry) error {
+	var payload bytes.Buffer
+	if err := gob.NewEncoder(&payload).Encode(entries); err != nil {
+		return fmt.Errorf("wal encode: %w", err)
+	}
+
+	record := make([]byte, walHeaderSize, walHeaderSize+payload.Len())
+	binary.LittleEndian.PutUint32(record[0:4], uint32(payload.Len()))
+	binary.LittleEndian.PutUint32(record[4:8], crc32.ChecksumIEEE(payload.Bytes()))
+	record = append(record, payload.Bytes()...)
+
+	if _, err := w.file.Write(record); err != nil {
+		// drop partially written record
+		_ = w.file.Truncate(w.size)
+		_, _ = w.file.Seek(w.size, io.SeekStart)
+		return fmt.Errorf("wal write: %w", err)
+	}
+
+	if err := w.file.Sync(); err != nil {
+		return fmt.Errorf("wal sync: %w", err)
+	}
+
+	w.size += int64(len(record))
+
+	return nil
+}
+
+// reset the log once its records were saved into the snapshot
+func (w *wal) reset() error {
+	if err := w.file.Truncate(0); err != nil {
+		return fmt.Errorf("wal truncate: %w", err)
+	}
+	if _, err := w.file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("wal seek: %w", err)
+	}
+	if err := w.file.Sync(); err != nil {
+		return fmt.Errorf("wal sync: %w", err)
+	}
+	w.size = 0
+	return nil
+}
+
+// empty reports if there are no records since the last reset
+func (w *wal) empty() bool {
+	return w.size == 0
+}
+
+func (w *wal) close() error {
+	return w.file.Close()
+}
+
+// replayWAL calls fn for every complete record of the log.
+// It returns the size of the valid part of the log, torn or corrupted tail is skipped.
+func replayWAL(path string, fn func(entries []walEntry)) (int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("wal open: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	r := bufio.NewReader(file)
+
+	var valid int64
+	for {
+		entries, n, err := readWALRecord(r)
+		if err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, ErrCorruptedWAL) {
+				return valid, nil
+			}
+			return valid, err
+		}
+		fn(entries)
+		valid += n
+	}
+}
+
+// readWALRecord returns record entries and the record size
+func readWALRecord(r io.Reader) ([]walEntry, int64, error) {
+	header := make([]byte, walHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, 0, err
+	}
+
+	size := binary.LittleEndian.Uint32(header[0:4])
+	if size > walMaxRecordSize {
+		return nil, 0, ErrCorruptedWAL
+	}
+
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, 0, err
+	}
+
+	if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(header[4:8]) {
+		return nil, 0, ErrCorruptedWAL
+	}
+
+	var entries []walEntry
+	if err := gob.NewDecoder(bytes.NewReader(payload)).Decode(&entries); err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", ErrCorruptedWAL, err)
+	}
+
+	return entries, int64(walHeaderSize + len(payload)), nil
+}
@@ -0,0 +1,124 @@
+package memorystore
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"shortener/internal/app/service/store"
+	"testing"
+)
+
+func TestWAL_Replay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.wal")
+
+	w, err := openWAL(path, 0)
+	require.NoError(t, err)
+	require.NoError(t, w.append(walEntry{Key: 1, Row: dbRow{ID: "1"}}))
+	require.NoError(t, w.append(walEntry{Key: 2, Row: dbRow{ID: "2"}}, walEntry{Key: 3, Row: dbRow{ID: "3"}}))
+	validSize := w.size
+	require.NoError(t, w.close())
+
+	// simulate crash in the middle of the record write
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	require.NoError(t, err)
+	_, err = f.Write([]byte{42, 0, 0, 0, 1, 2})
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	var keys []uint64
+	size, err := replayWAL(path, func(entries []walEntry) {
+		for _, e := range entries {
+			keys = append(keys, e.Key)
+		}
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{1, 2, 3}, keys)
+	assert.Equal(t, validSize, size)
+
+	// torn tail must be dropped on open
+	w, err = openWAL(path, size)
+	require.NoError(t, err)
+	require.NoError(t, w.append(walEntry{Key: 4, Row: dbRow{ID: "4"}}))
+	require.NoError(t, w.close())
+
+	keys = nil
+	_, err = replayWAL(path, func(entries []walEntry) {
+		for _, e := range entries {
+			keys = append(keys, e.Key)
+		}
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{1, 2, 3, 4}, keys)
+}
+
+func TestStore_CrashRecovery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "urls.gob")
+
+	s := NewStore(WithBaseURL("http://localhost:8080"), WithFilePath(path))
+	require.NoError(t, s.Start())
+	_, err := s.WriteURL(context.Background(), "https://example.org/a", "test")
+	require.NoError(t, err)
+	out, err := s.BatchWrite(context.Background(), "test", []store.Record{
+		{OriginalURL: "https://example.org/b"},
+		{OriginalURL: "https://example.org/c"},
+	})
+	require.NoError(t, err)
+	_, err = s.BatchRemove(context.Background(), "test", out[0].ID)
+	require.NoError(t, err)
+	// no Stop call: the process has crashed before the snapshot
+
+	restored := NewStore(WithBaseURL("http://localhost:8080"), WithFilePath(path))
+	require.NoError(t, restored.Start())
+	defer func() {
+		_ = restored.Stop()
+	}()
+
+	page, err := restored.ReadUserData(context.Background(), "test", store.UserDataQuery{})
+	require.NoError(t, err)
+	assert.Len(t, page.Records, 2)
+	_, err = restored.ReadURL(context.Background(), out[0].ID)
+	assert.ErrorIs(t, err, store.ErrDeleted)
+
+	shortURL, err := restored.WriteURL(context.Background(), "https://example.org/d", "test")
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:8080/4", shortURL)
+}
+
+func TestStore_SnapshotReplacesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "urls.gob")
+
+	// garbage left by the previous non-truncating writes must not survive the snapshot
+	var buf bytes.Buffer
+	require.NoError(t, gob.NewEncoder(&buf).Encode(db{
+		1: {ID: "1", OriginalURL: "https://example.org/a", ShortURL: "http://localhost:8080/1", UID: "test"},
+	}))
+	buf.Write(make([]byte, 1<<16))
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0600))
+
+	s := NewStore(WithBaseURL("http://localhost:8080"), WithFilePath(path))
+	require.NoError(t, s.Start())
+	_, err := s.WriteURL(context.Background(), "https://example.org/b", "test")
+	require.NoError(t, err)
+	require.NoError(t, s.Stop())
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Less(t, info.Size(), int64(1<<16))
+
+	walInfo, err := os.Stat(path + ".wal")
+	require.NoError(t, err)
+	assert.Zero(t, walInfo.Size())
+
+	restored := NewStore(WithBaseURL("http://localhost:8080"), WithFilePath(path))
+	require.NoError(t, restored.Start())
+	defer func() {
+		_ = restored.Stop()
+	}()
+	page, err := restored.ReadUserData(context.Background(), "test", store.UserDataQuery{})
+	require.NoError(t, err)
+	assert.Len(t, page.Records, 2)
+}
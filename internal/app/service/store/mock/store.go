@@ -5,6 +5,7 @@
 package storemock
 
 import (
+	context "context"
 	reflect "reflect"
 	store "shortener/internal/app/service/store"
 
@@ -35,17 +36,465 @@ func (m *MockHealthChecker) EXPECT() *MockHealthCheckerMockRecorder {
 }
 
 // HealthCheck mocks base method.
-func (m *MockHealthChecker) HealthCheck() error {
+func (m *MockHealthChecker) HealthCheck(ctx context.Context) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "HealthCheck")
+	ret := m.ctrl.Call(m, "HealthCheck", ctx)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // HealthCheck indicates an expected call of HealthCheck.
-func (mr *MockHealthCheckerMockRecorder) HealthCheck() *gomock.Call {
+func (mr *MockHealthCheckerMockRecorder) HealthCheck(ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HealthCheck", reflect.TypeOf((*MockHealthChecker)(nil).HealthCheck))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HealthCheck", reflect.TypeOf((*MockHealthChecker)(nil).HealthCheck), ctx)
+}
+
+// MockLifecycle is a mock of Lifecycle interface.
+type MockLifecycle struct {
+	ctrl     *gomock.Controller
+	recorder *MockLifecycleMockRecorder
+}
+
+// MockLifecycleMockRecorder is the mock recorder for MockLifecycle.
+type MockLifecycleMockRecorder struct {
+	mock *MockLifecycle
+}
+
+// NewMockLifecycle creates a new mock instance.
+func NewMockLifecycle(ctrl *gomock.Controller) *MockLifecycle {
+	mock := &MockLifecycle{ctrl: ctrl}
+	mock.recorder = &MockLifecycleMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLifecycle) EXPECT() *MockLifecycleMockRecorder {
+	return m.recorder
+}
+
+// Start mocks base method.
+func (m *MockLifecycle) Start() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Start")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Start indicates an expected call of Start.
+func (mr *MockLifecycleMockRecorder) Start() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Start", reflect.TypeOf((*MockLifecycle)(nil).Start))
+}
+
+// Stop mocks base method.
+func (m *MockLifecycle) Stop() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stop")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Stop indicates an expected call of Stop.
+func (mr *MockLifecycleMockRecorder) Stop() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stop", reflect.TypeOf((*MockLifecycle)(nil).Stop))
+}
+
+// MockBackend is a mock of Backend interface.
+type MockBackend struct {
+	ctrl     *gomock.Controller
+	recorder *MockBackendMockRecorder
+}
+
+// MockBackendMockRecorder is the mock recorder for MockBackend.
+type MockBackendMockRecorder struct {
+	mock *MockBackend
+}
+
+// NewMockBackend creates a new mock instance.
+func NewMockBackend(ctrl *gomock.Controller) *MockBackend {
+	mock := &MockBackend{ctrl: ctrl}
+	mock.recorder = &MockBackendMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBackend) EXPECT() *MockBackendMockRecorder {
+	return m.recorder
+}
+
+// BatchRemove mocks base method.
+func (m *MockBackend) BatchRemove(ctx context.Context, uid string, ids ...string) (string, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, uid}
+	for _, a := range ids {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "BatchRemove", varargs...)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BatchRemove indicates an expected call of BatchRemove.
+func (mr *MockBackendMockRecorder) BatchRemove(ctx, uid interface{}, ids ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, uid}, ids...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchRemove", reflect.TypeOf((*MockBackend)(nil).BatchRemove), varargs...)
+}
+
+// BatchWrite mocks base method.
+func (m *MockBackend) BatchWrite(ctx context.Context, uid string, in []store.Record, opts ...store.WriteOption) ([]store.Record, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, uid, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "BatchWrite", varargs...)
+	ret0, _ := ret[0].([]store.Record)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BatchWrite indicates an expected call of BatchWrite.
+func (mr *MockBackendMockRecorder) BatchWrite(ctx, uid, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, uid, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchWrite", reflect.TypeOf((*MockBackend)(nil).BatchWrite), varargs...)
+}
+
+// Dump mocks base method.
+func (m *MockBackend) Dump(ctx context.Context, after uint64, limit int) ([]store.DumpRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Dump", ctx, after, limit)
+	ret0, _ := ret[0].([]store.DumpRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Dump indicates an expected call of Dump.
+func (mr *MockBackendMockRecorder) Dump(ctx, after, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Dump", reflect.TypeOf((*MockBackend)(nil).Dump), ctx, after, limit)
+}
+
+// HealthCheck mocks base method.
+func (m *MockBackend) HealthCheck(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HealthCheck", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// HealthCheck indicates an expected call of HealthCheck.
+func (mr *MockBackendMockRecorder) HealthCheck(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HealthCheck", reflect.TypeOf((*MockBackend)(nil).HealthCheck), ctx)
+}
+
+// Quarantine mocks base method.
+func (m *MockBackend) Quarantine(ctx context.Context, items ...store.QuarantineItem) (int, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx}
+	for _, a := range items {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Quarantine", varargs...)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Quarantine indicates an expected call of Quarantine.
+func (mr *MockBackendMockRecorder) Quarantine(ctx interface{}, items ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx}, items...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Quarantine", reflect.TypeOf((*MockBackend)(nil).Quarantine), varargs...)
+}
+
+// ReadHistory mocks base method.
+func (m *MockBackend) ReadHistory(ctx context.Context, uid, id string) ([]store.URLVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadHistory", ctx, uid, id)
+	ret0, _ := ret[0].([]store.URLVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadHistory indicates an expected call of ReadHistory.
+func (mr *MockBackendMockRecorder) ReadHistory(ctx, uid, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadHistory", reflect.TypeOf((*MockBackend)(nil).ReadHistory), ctx, uid, id)
+}
+
+// ReadLinkStat mocks base method.
+func (m *MockBackend) ReadLinkStat(ctx context.Context, uid, id string, days int) (*store.LinkStat, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadLinkStat", ctx, uid, id, days)
+	ret0, _ := ret[0].(*store.LinkStat)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadLinkStat indicates an expected call of ReadLinkStat.
+func (mr *MockBackendMockRecorder) ReadLinkStat(ctx, uid, id, days interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadLinkStat", reflect.TypeOf((*MockBackend)(nil).ReadLinkStat), ctx, uid, id, days)
+}
+
+// ReadOperation mocks base method.
+func (m *MockBackend) ReadOperation(ctx context.Context, uid, id string) (*store.Operation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadOperation", ctx, uid, id)
+	ret0, _ := ret[0].(*store.Operation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadOperation indicates an expected call of ReadOperation.
+func (mr *MockBackendMockRecorder) ReadOperation(ctx, uid, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadOperation", reflect.TypeOf((*MockBackend)(nil).ReadOperation), ctx, uid, id)
+}
+
+// ReadReports mocks base method.
+func (m *MockBackend) ReadReports(ctx context.Context, q store.ReportQuery) ([]store.AbuseReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadReports", ctx, q)
+	ret0, _ := ret[0].([]store.AbuseReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadReports indicates an expected call of ReadReports.
+func (mr *MockBackendMockRecorder) ReadReports(ctx, q interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadReports", reflect.TypeOf((*MockBackend)(nil).ReadReports), ctx, q)
+}
+
+// ReadURL mocks base method.
+func (m *MockBackend) ReadURL(ctx context.Context, id string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadURL", ctx, id)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadURL indicates an expected call of ReadURL.
+func (mr *MockBackendMockRecorder) ReadURL(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadURL", reflect.TypeOf((*MockBackend)(nil).ReadURL), ctx, id)
+}
+
+// ReadUserData mocks base method.
+func (m *MockBackend) ReadUserData(ctx context.Context, uid string, q store.UserDataQuery) (*store.UserDataPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadUserData", ctx, uid, q)
+	ret0, _ := ret[0].(*store.UserDataPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadUserData indicates an expected call of ReadUserData.
+func (mr *MockBackendMockRecorder) ReadUserData(ctx, uid, q interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadUserData", reflect.TypeOf((*MockBackend)(nil).ReadUserData), ctx, uid, q)
+}
+
+// RecordClicks mocks base method.
+func (m *MockBackend) RecordClicks(ctx context.Context, events ...store.ClickEvent) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx}
+	for _, a := range events {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "RecordClicks", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordClicks indicates an expected call of RecordClicks.
+func (mr *MockBackendMockRecorder) RecordClicks(ctx interface{}, events ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx}, events...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordClicks", reflect.TypeOf((*MockBackend)(nil).RecordClicks), varargs...)
+}
+
+// Report mocks base method.
+func (m *MockBackend) Report(ctx context.Context, r store.AbuseReport) (*store.AbuseReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Report", ctx, r)
+	ret0, _ := ret[0].(*store.AbuseReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Report indicates an expected call of Report.
+func (mr *MockBackendMockRecorder) Report(ctx, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Report", reflect.TypeOf((*MockBackend)(nil).Report), ctx, r)
+}
+
+// Restore mocks base method.
+func (m *MockBackend) Restore(ctx context.Context, uid string, ids ...string) ([]store.OperationItem, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, uid}
+	for _, a := range ids {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Restore", varargs...)
+	ret0, _ := ret[0].([]store.OperationItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockBackendMockRecorder) Restore(ctx, uid interface{}, ids ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, uid}, ids...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockBackend)(nil).Restore), varargs...)
+}
+
+// RollbackURL mocks base method.
+func (m *MockBackend) RollbackURL(ctx context.Context, uid, id string, version int) (*store.URLVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RollbackURL", ctx, uid, id, version)
+	ret0, _ := ret[0].(*store.URLVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RollbackURL indicates an expected call of RollbackURL.
+func (mr *MockBackendMockRecorder) RollbackURL(ctx, uid, id, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RollbackURL", reflect.TypeOf((*MockBackend)(nil).RollbackURL), ctx, uid, id, version)
+}
+
+// SetBanned mocks base method.
+func (m *MockBackend) SetBanned(ctx context.Context, uid string, banned bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetBanned", ctx, uid, banned)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetBanned indicates an expected call of SetBanned.
+func (mr *MockBackendMockRecorder) SetBanned(ctx, uid, banned interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetBanned", reflect.TypeOf((*MockBackend)(nil).SetBanned), ctx, uid, banned)
+}
+
+// SetDisabled mocks base method.
+func (m *MockBackend) SetDisabled(ctx context.Context, id string, disabled bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDisabled", ctx, id, disabled)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDisabled indicates an expected call of SetDisabled.
+func (mr *MockBackendMockRecorder) SetDisabled(ctx, id, disabled interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDisabled", reflect.TypeOf((*MockBackend)(nil).SetDisabled), ctx, id, disabled)
+}
+
+// Start mocks base method.
+func (m *MockBackend) Start() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Start")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Start indicates an expected call of Start.
+func (mr *MockBackendMockRecorder) Start() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Start", reflect.TypeOf((*MockBackend)(nil).Start))
+}
+
+// Stat mocks base method.
+func (m *MockBackend) Stat(ctx context.Context) (*store.StatData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stat", ctx)
+	ret0, _ := ret[0].(*store.StatData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Stat indicates an expected call of Stat.
+func (mr *MockBackendMockRecorder) Stat(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stat", reflect.TypeOf((*MockBackend)(nil).Stat), ctx)
+}
+
+// Stop mocks base method.
+func (m *MockBackend) Stop() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stop")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Stop indicates an expected call of Stop.
+func (mr *MockBackendMockRecorder) Stop() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stop", reflect.TypeOf((*MockBackend)(nil).Stop))
+}
+
+// UpdateURL mocks base method.
+func (m *MockBackend) UpdateURL(ctx context.Context, uid, id, url string) (*store.URLVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateURL", ctx, uid, id, url)
+	ret0, _ := ret[0].(*store.URLVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateURL indicates an expected call of UpdateURL.
+func (mr *MockBackendMockRecorder) UpdateURL(ctx, uid, id, url interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateURL", reflect.TypeOf((*MockBackend)(nil).UpdateURL), ctx, uid, id, url)
+}
+
+// WriteAlias mocks base method.
+func (m *MockBackend) WriteAlias(ctx context.Context, url, alias, uid string, opts ...store.WriteOption) (string, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, url, alias, uid}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "WriteAlias", varargs...)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WriteAlias indicates an expected call of WriteAlias.
+func (mr *MockBackendMockRecorder) WriteAlias(ctx, url, alias, uid interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, url, alias, uid}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteAlias", reflect.TypeOf((*MockBackend)(nil).WriteAlias), varargs...)
+}
+
+// WriteURL mocks base method.
+func (m *MockBackend) WriteURL(ctx context.Context, url, uid string, opts ...store.WriteOption) (string, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, url, uid}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "WriteURL", varargs...)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WriteURL indicates an expected call of WriteURL.
+func (mr *MockBackendMockRecorder) WriteURL(ctx, url, uid interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, url, uid}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteURL", reflect.TypeOf((*MockBackend)(nil).WriteURL), varargs...)
 }
 
 // MockStore is a mock of Store interface.
@@ -72,81 +521,113 @@ func (m *MockStore) EXPECT() *MockStoreMockRecorder {
 }
 
 // BatchRemove mocks base method.
-func (m *MockStore) BatchRemove(uid string, ids ...string) error {
+func (m *MockStore) BatchRemove(ctx context.Context, uid string, ids ...string) (string, error) {
 	m.ctrl.T.Helper()
-	varargs := []interface{}{uid}
+	varargs := []interface{}{ctx, uid}
 	for _, a := range ids {
 		varargs = append(varargs, a)
 	}
 	ret := m.ctrl.Call(m, "BatchRemove", varargs...)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // BatchRemove indicates an expected call of BatchRemove.
-func (mr *MockStoreMockRecorder) BatchRemove(uid interface{}, ids ...interface{}) *gomock.Call {
+func (mr *MockStoreMockRecorder) BatchRemove(ctx, uid interface{}, ids ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	varargs := append([]interface{}{uid}, ids...)
+	varargs := append([]interface{}{ctx, uid}, ids...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchRemove", reflect.TypeOf((*MockStore)(nil).BatchRemove), varargs...)
 }
 
 // BatchWrite mocks base method.
-func (m *MockStore) BatchWrite(uid string, in []store.Record) ([]store.Record, error) {
+func (m *MockStore) BatchWrite(ctx context.Context, uid string, in []store.Record, opts ...store.WriteOption) ([]store.Record, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "BatchWrite", uid, in)
+	varargs := []interface{}{ctx, uid, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "BatchWrite", varargs...)
 	ret0, _ := ret[0].([]store.Record)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // BatchWrite indicates an expected call of BatchWrite.
-func (mr *MockStoreMockRecorder) BatchWrite(uid, in interface{}) *gomock.Call {
+func (mr *MockStoreMockRecorder) BatchWrite(ctx, uid, in interface{}, opts ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchWrite", reflect.TypeOf((*MockStore)(nil).BatchWrite), uid, in)
+	varargs := append([]interface{}{ctx, uid, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchWrite", reflect.TypeOf((*MockStore)(nil).BatchWrite), varargs...)
 }
 
 // ReadURL mocks base method.
-func (m *MockStore) ReadURL(id string) (string, error) {
+func (m *MockStore) ReadURL(ctx context.Context, id string) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ReadURL", id)
+	ret := m.ctrl.Call(m, "ReadURL", ctx, id)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ReadURL indicates an expected call of ReadURL.
-func (mr *MockStoreMockRecorder) ReadURL(id interface{}) *gomock.Call {
+func (mr *MockStoreMockRecorder) ReadURL(ctx, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadURL", reflect.TypeOf((*MockStore)(nil).ReadURL), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadURL", reflect.TypeOf((*MockStore)(nil).ReadURL), ctx, id)
 }
 
 // ReadUserData mocks base method.
-func (m *MockStore) ReadUserData(uid string) []store.Record {
+func (m *MockStore) ReadUserData(ctx context.Context, uid string, q store.UserDataQuery) (*store.UserDataPage, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ReadUserData", uid)
-	ret0, _ := ret[0].([]store.Record)
-	return ret0
+	ret := m.ctrl.Call(m, "ReadUserData", ctx, uid, q)
+	ret0, _ := ret[0].(*store.UserDataPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadUserData indicates an expected call of ReadUserData.
+func (mr *MockStoreMockRecorder) ReadUserData(ctx, uid, q interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadUserData", reflect.TypeOf((*MockStore)(nil).ReadUserData), ctx, uid, q)
+}
+
+// WriteAlias mocks base method.
+func (m *MockStore) WriteAlias(ctx context.Context, url, alias, uid string, opts ...store.WriteOption) (string, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, url, alias, uid}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "WriteAlias", varargs...)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// ReadUserData indicates an expected call of ReadUserData.
-func (mr *MockStoreMockRecorder) ReadUserData(uid interface{}) *gomock.Call {
+// WriteAlias indicates an expected call of WriteAlias.
+func (mr *MockStoreMockRecorder) WriteAlias(ctx, url, alias, uid interface{}, opts ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadUserData", reflect.TypeOf((*MockStore)(nil).ReadUserData), uid)
+	varargs := append([]interface{}{ctx, url, alias, uid}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteAlias", reflect.TypeOf((*MockStore)(nil).WriteAlias), varargs...)
 }
 
 // WriteURL mocks base method.
-func (m *MockStore) WriteURL(url, uid string) (string, error) {
+func (m *MockStore) WriteURL(ctx context.Context, url, uid string, opts ...store.WriteOption) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "WriteURL", url, uid)
+	varargs := []interface{}{ctx, url, uid}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "WriteURL", varargs...)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // WriteURL indicates an expected call of WriteURL.
-func (mr *MockStoreMockRecorder) WriteURL(url, uid interface{}) *gomock.Call {
+func (mr *MockStoreMockRecorder) WriteURL(ctx, url, uid interface{}, opts ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteURL", reflect.TypeOf((*MockStore)(nil).WriteURL), url, uid)
+	varargs := append([]interface{}{ctx, url, uid}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteURL", reflect.TypeOf((*MockStore)(nil).WriteURL), varargs...)
 }
 
 // MockReader is a mock of Reader interface.
@@ -173,18 +654,18 @@ func (m *MockReader) EXPECT() *MockReaderMockRecorder {
 }
 
 // ReadURL mocks base method.
-func (m *MockReader) ReadURL(id string) (string, error) {
+func (m *MockReader) ReadURL(ctx context.Context, id string) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ReadURL", id)
+	ret := m.ctrl.Call(m, "ReadURL", ctx, id)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ReadURL indicates an expected call of ReadURL.
-func (mr *MockReaderMockRecorder) ReadURL(id interface{}) *gomock.Call {
+func (mr *MockReaderMockRecorder) ReadURL(ctx, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadURL", reflect.TypeOf((*MockReader)(nil).ReadURL), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadURL", reflect.TypeOf((*MockReader)(nil).ReadURL), ctx, id)
 }
 
 // MockUserDataReader is a mock of UserDataReader interface.
@@ -211,17 +692,18 @@ func (m *MockUserDataReader) EXPECT() *MockUserDataReaderMockRecorder {
 }
 
 // ReadUserData mocks base method.
-func (m *MockUserDataReader) ReadUserData(uid string) []store.Record {
+func (m *MockUserDataReader) ReadUserData(ctx context.Context, uid string, q store.UserDataQuery) (*store.UserDataPage, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ReadUserData", uid)
-	ret0, _ := ret[0].([]store.Record)
-	return ret0
+	ret := m.ctrl.Call(m, "ReadUserData", ctx, uid, q)
+	ret0, _ := ret[0].(*store.UserDataPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // ReadUserData indicates an expected call of ReadUserData.
-func (mr *MockUserDataReaderMockRecorder) ReadUserData(uid interface{}) *gomock.Call {
+func (mr *MockUserDataReaderMockRecorder) ReadUserData(ctx, uid, q interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadUserData", reflect.TypeOf((*MockUserDataReader)(nil).ReadUserData), uid)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadUserData", reflect.TypeOf((*MockUserDataReader)(nil).ReadUserData), ctx, uid, q)
 }
 
 // MockWriter is a mock of Writer interface.
@@ -247,19 +729,44 @@ func (m *MockWriter) EXPECT() *MockWriterMockRecorder {
 	return m.recorder
 }
 
+// WriteAlias mocks base method.
+func (m *MockWriter) WriteAlias(ctx context.Context, url, alias, uid string, opts ...store.WriteOption) (string, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, url, alias, uid}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "WriteAlias", varargs...)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WriteAlias indicates an expected call of WriteAlias.
+func (mr *MockWriterMockRecorder) WriteAlias(ctx, url, alias, uid interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, url, alias, uid}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteAlias", reflect.TypeOf((*MockWriter)(nil).WriteAlias), varargs...)
+}
+
 // WriteURL mocks base method.
-func (m *MockWriter) WriteURL(url, uid string) (string, error) {
+func (m *MockWriter) WriteURL(ctx context.Context, url, uid string, opts ...store.WriteOption) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "WriteURL", url, uid)
+	varargs := []interface{}{ctx, url, uid}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "WriteURL", varargs...)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // WriteURL indicates an expected call of WriteURL.
-func (mr *MockWriterMockRecorder) WriteURL(url, uid interface{}) *gomock.Call {
+func (mr *MockWriterMockRecorder) WriteURL(ctx, url, uid interface{}, opts ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteURL", reflect.TypeOf((*MockWriter)(nil).WriteURL), url, uid)
+	varargs := append([]interface{}{ctx, url, uid}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteURL", reflect.TypeOf((*MockWriter)(nil).WriteURL), varargs...)
 }
 
 // MockBatchWriter is a mock of BatchWriter interface.
@@ -286,18 +793,23 @@ func (m *MockBatchWriter) EXPECT() *MockBatchWriterMockRecorder {
 }
 
 // BatchWrite mocks base method.
-func (m *MockBatchWriter) BatchWrite(uid string, in []store.Record) ([]store.Record, error) {
+func (m *MockBatchWriter) BatchWrite(ctx context.Context, uid string, in []store.Record, opts ...store.WriteOption) ([]store.Record, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "BatchWrite", uid, in)
+	varargs := []interface{}{ctx, uid, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "BatchWrite", varargs...)
 	ret0, _ := ret[0].([]store.Record)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // BatchWrite indicates an expected call of BatchWrite.
-func (mr *MockBatchWriterMockRecorder) BatchWrite(uid, in interface{}) *gomock.Call {
+func (mr *MockBatchWriterMockRecorder) BatchWrite(ctx, uid, in interface{}, opts ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchWrite", reflect.TypeOf((*MockBatchWriter)(nil).BatchWrite), uid, in)
+	varargs := append([]interface{}{ctx, uid, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchWrite", reflect.TypeOf((*MockBatchWriter)(nil).BatchWrite), varargs...)
 }
 
 // MockBatchRemover is a mock of BatchRemover interface.
@@ -324,24 +836,454 @@ func (m *MockBatchRemover) EXPECT() *MockBatchRemoverMockRecorder {
 }
 
 // BatchRemove mocks base method.
-func (m *MockBatchRemover) BatchRemove(uid string, ids ...string) error {
+func (m *MockBatchRemover) BatchRemove(ctx context.Context, uid string, ids ...string) (string, error) {
 	m.ctrl.T.Helper()
-	varargs := []interface{}{uid}
+	varargs := []interface{}{ctx, uid}
 	for _, a := range ids {
 		varargs = append(varargs, a)
 	}
 	ret := m.ctrl.Call(m, "BatchRemove", varargs...)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // BatchRemove indicates an expected call of BatchRemove.
-func (mr *MockBatchRemoverMockRecorder) BatchRemove(uid interface{}, ids ...interface{}) *gomock.Call {
+func (mr *MockBatchRemoverMockRecorder) BatchRemove(ctx, uid interface{}, ids ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	varargs := append([]interface{}{uid}, ids...)
+	varargs := append([]interface{}{ctx, uid}, ids...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchRemove", reflect.TypeOf((*MockBatchRemover)(nil).BatchRemove), varargs...)
 }
 
+// MockRestorer is a mock of Restorer interface.
+type MockRestorer struct {
+	ctrl     *gomock.Controller
+	recorder *MockRestorerMockRecorder
+}
+
+// MockRestorerMockRecorder is the mock recorder for MockRestorer.
+type MockRestorerMockRecorder struct {
+	mock *MockRestorer
+}
+
+// NewMockRestorer creates a new mock instance.
+func NewMockRestorer(ctrl *gomock.Controller) *MockRestorer {
+	mock := &MockRestorer{ctrl: ctrl}
+	mock.recorder = &MockRestorerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRestorer) EXPECT() *MockRestorerMockRecorder {
+	return m.recorder
+}
+
+// Restore mocks base method.
+func (m *MockRestorer) Restore(ctx context.Context, uid string, ids ...string) ([]store.OperationItem, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, uid}
+	for _, a := range ids {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Restore", varargs...)
+	ret0, _ := ret[0].([]store.OperationItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockRestorerMockRecorder) Restore(ctx, uid interface{}, ids ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, uid}, ids...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockRestorer)(nil).Restore), varargs...)
+}
+
+// MockEditor is a mock of Editor interface.
+type MockEditor struct {
+	ctrl     *gomock.Controller
+	recorder *MockEditorMockRecorder
+}
+
+// MockEditorMockRecorder is the mock recorder for MockEditor.
+type MockEditorMockRecorder struct {
+	mock *MockEditor
+}
+
+// NewMockEditor creates a new mock instance.
+func NewMockEditor(ctrl *gomock.Controller) *MockEditor {
+	mock := &MockEditor{ctrl: ctrl}
+	mock.recorder = &MockEditorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEditor) EXPECT() *MockEditorMockRecorder {
+	return m.recorder
+}
+
+// ReadHistory mocks base method.
+func (m *MockEditor) ReadHistory(ctx context.Context, uid, id string) ([]store.URLVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadHistory", ctx, uid, id)
+	ret0, _ := ret[0].([]store.URLVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadHistory indicates an expected call of ReadHistory.
+func (mr *MockEditorMockRecorder) ReadHistory(ctx, uid, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadHistory", reflect.TypeOf((*MockEditor)(nil).ReadHistory), ctx, uid, id)
+}
+
+// RollbackURL mocks base method.
+func (m *MockEditor) RollbackURL(ctx context.Context, uid, id string, version int) (*store.URLVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RollbackURL", ctx, uid, id, version)
+	ret0, _ := ret[0].(*store.URLVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RollbackURL indicates an expected call of RollbackURL.
+func (mr *MockEditorMockRecorder) RollbackURL(ctx, uid, id, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RollbackURL", reflect.TypeOf((*MockEditor)(nil).RollbackURL), ctx, uid, id, version)
+}
+
+// UpdateURL mocks base method.
+func (m *MockEditor) UpdateURL(ctx context.Context, uid, id, url string) (*store.URLVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateURL", ctx, uid, id, url)
+	ret0, _ := ret[0].(*store.URLVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateURL indicates an expected call of UpdateURL.
+func (mr *MockEditorMockRecorder) UpdateURL(ctx, uid, id, url interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateURL", reflect.TypeOf((*MockEditor)(nil).UpdateURL), ctx, uid, id, url)
+}
+
+// MockOperationReader is a mock of OperationReader interface.
+type MockOperationReader struct {
+	ctrl     *gomock.Controller
+	recorder *MockOperationReaderMockRecorder
+}
+
+// MockOperationReaderMockRecorder is the mock recorder for MockOperationReader.
+type MockOperationReaderMockRecorder struct {
+	mock *MockOperationReader
+}
+
+// NewMockOperationReader creates a new mock instance.
+func NewMockOperationReader(ctrl *gomock.Controller) *MockOperationReader {
+	mock := &MockOperationReader{ctrl: ctrl}
+	mock.recorder = &MockOperationReaderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOperationReader) EXPECT() *MockOperationReaderMockRecorder {
+	return m.recorder
+}
+
+// ReadOperation mocks base method.
+func (m *MockOperationReader) ReadOperation(ctx context.Context, uid, id string) (*store.Operation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadOperation", ctx, uid, id)
+	ret0, _ := ret[0].(*store.Operation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadOperation indicates an expected call of ReadOperation.
+func (mr *MockOperationReaderMockRecorder) ReadOperation(ctx, uid, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadOperation", reflect.TypeOf((*MockOperationReader)(nil).ReadOperation), ctx, uid, id)
+}
+
+// MockDumper is a mock of Dumper interface.
+type MockDumper struct {
+	ctrl     *gomock.Controller
+	recorder *MockDumperMockRecorder
+}
+
+// MockDumperMockRecorder is the mock recorder for MockDumper.
+type MockDumperMockRecorder struct {
+	mock *MockDumper
+}
+
+// NewMockDumper creates a new mock instance.
+func NewMockDumper(ctrl *gomock.Controller) *MockDumper {
+	mock := &MockDumper{ctrl: ctrl}
+	mock.recorder = &MockDumperMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDumper) EXPECT() *MockDumperMockRecorder {
+	return m.recorder
+}
+
+// Dump mocks base method.
+func (m *MockDumper) Dump(ctx context.Context, after uint64, limit int) ([]store.DumpRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Dump", ctx, after, limit)
+	ret0, _ := ret[0].([]store.DumpRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Dump indicates an expected call of Dump.
+func (mr *MockDumperMockRecorder) Dump(ctx, after, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Dump", reflect.TypeOf((*MockDumper)(nil).Dump), ctx, after, limit)
+}
+
+// MockLoader is a mock of Loader interface.
+type MockLoader struct {
+	ctrl     *gomock.Controller
+	recorder *MockLoaderMockRecorder
+}
+
+// MockLoaderMockRecorder is the mock recorder for MockLoader.
+type MockLoaderMockRecorder struct {
+	mock *MockLoader
+}
+
+// NewMockLoader creates a new mock instance.
+func NewMockLoader(ctrl *gomock.Controller) *MockLoader {
+	mock := &MockLoader{ctrl: ctrl}
+	mock.recorder = &MockLoaderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLoader) EXPECT() *MockLoaderMockRecorder {
+	return m.recorder
+}
+
+// Load mocks base method.
+func (m *MockLoader) Load(ctx context.Context, rows []store.DumpRow) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Load", ctx, rows)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Load indicates an expected call of Load.
+func (mr *MockLoaderMockRecorder) Load(ctx, rows interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Load", reflect.TypeOf((*MockLoader)(nil).Load), ctx, rows)
+}
+
+// MockQuarantiner is a mock of Quarantiner interface.
+type MockQuarantiner struct {
+	ctrl     *gomock.Controller
+	recorder *MockQuarantinerMockRecorder
+}
+
+// MockQuarantinerMockRecorder is the mock recorder for MockQuarantiner.
+type MockQuarantinerMockRecorder struct {
+	mock *MockQuarantiner
+}
+
+// NewMockQuarantiner creates a new mock instance.
+func NewMockQuarantiner(ctrl *gomock.Controller) *MockQuarantiner {
+	mock := &MockQuarantiner{ctrl: ctrl}
+	mock.recorder = &MockQuarantinerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockQuarantiner) EXPECT() *MockQuarantinerMockRecorder {
+	return m.recorder
+}
+
+// Quarantine mocks base method.
+func (m *MockQuarantiner) Quarantine(ctx context.Context, items ...store.QuarantineItem) (int, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx}
+	for _, a := range items {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Quarantine", varargs...)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Quarantine indicates an expected call of Quarantine.
+func (mr *MockQuarantinerMockRecorder) Quarantine(ctx interface{}, items ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx}, items...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Quarantine", reflect.TypeOf((*MockQuarantiner)(nil).Quarantine), varargs...)
+}
+
+// MockModerator is a mock of Moderator interface.
+type MockModerator struct {
+	ctrl     *gomock.Controller
+	recorder *MockModeratorMockRecorder
+}
+
+// MockModeratorMockRecorder is the mock recorder for MockModerator.
+type MockModeratorMockRecorder struct {
+	mock *MockModerator
+}
+
+// NewMockModerator creates a new mock instance.
+func NewMockModerator(ctrl *gomock.Controller) *MockModerator {
+	mock := &MockModerator{ctrl: ctrl}
+	mock.recorder = &MockModeratorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockModerator) EXPECT() *MockModeratorMockRecorder {
+	return m.recorder
+}
+
+// ReadReports mocks base method.
+func (m *MockModerator) ReadReports(ctx context.Context, q store.ReportQuery) ([]store.AbuseReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadReports", ctx, q)
+	ret0, _ := ret[0].([]store.AbuseReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadReports indicates an expected call of ReadReports.
+func (mr *MockModeratorMockRecorder) ReadReports(ctx, q interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadReports", reflect.TypeOf((*MockModerator)(nil).ReadReports), ctx, q)
+}
+
+// Report mocks base method.
+func (m *MockModerator) Report(ctx context.Context, r store.AbuseReport) (*store.AbuseReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Report", ctx, r)
+	ret0, _ := ret[0].(*store.AbuseReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Report indicates an expected call of Report.
+func (mr *MockModeratorMockRecorder) Report(ctx, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Report", reflect.TypeOf((*MockModerator)(nil).Report), ctx, r)
+}
+
+// SetBanned mocks base method.
+func (m *MockModerator) SetBanned(ctx context.Context, uid string, banned bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetBanned", ctx, uid, banned)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetBanned indicates an expected call of SetBanned.
+func (mr *MockModeratorMockRecorder) SetBanned(ctx, uid, banned interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetBanned", reflect.TypeOf((*MockModerator)(nil).SetBanned), ctx, uid, banned)
+}
+
+// SetDisabled mocks base method.
+func (m *MockModerator) SetDisabled(ctx context.Context, id string, disabled bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDisabled", ctx, id, disabled)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDisabled indicates an expected call of SetDisabled.
+func (mr *MockModeratorMockRecorder) SetDisabled(ctx, id, disabled interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDisabled", reflect.TypeOf((*MockModerator)(nil).SetDisabled), ctx, id, disabled)
+}
+
+// MockClickRecorder is a mock of ClickRecorder interface.
+type MockClickRecorder struct {
+	ctrl     *gomock.Controller
+	recorder *MockClickRecorderMockRecorder
+}
+
+// MockClickRecorderMockRecorder is the mock recorder for MockClickRecorder.
+type MockClickRecorderMockRecorder struct {
+	mock *MockClickRecorder
+}
+
+// NewMockClickRecorder creates a new mock instance.
+func NewMockClickRecorder(ctrl *gomock.Controller) *MockClickRecorder {
+	mock := &MockClickRecorder{ctrl: ctrl}
+	mock.recorder = &MockClickRecorderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockClickRecorder) EXPECT() *MockClickRecorderMockRecorder {
+	return m.recorder
+}
+
+// RecordClicks mocks base method.
+func (m *MockClickRecorder) RecordClicks(ctx context.Context, events ...store.ClickEvent) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx}
+	for _, a := range events {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "RecordClicks", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordClicks indicates an expected call of RecordClicks.
+func (mr *MockClickRecorderMockRecorder) RecordClicks(ctx interface{}, events ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx}, events...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordClicks", reflect.TypeOf((*MockClickRecorder)(nil).RecordClicks), varargs...)
+}
+
+// MockLinkStatReader is a mock of LinkStatReader interface.
+type MockLinkStatReader struct {
+	ctrl     *gomock.Controller
+	recorder *MockLinkStatReaderMockRecorder
+}
+
+// MockLinkStatReaderMockRecorder is the mock recorder for MockLinkStatReader.
+type MockLinkStatReaderMockRecorder struct {
+	mock *MockLinkStatReader
+}
+
+// NewMockLinkStatReader creates a new mock instance.
+func NewMockLinkStatReader(ctrl *gomock.Controller) *MockLinkStatReader {
+	mock := &MockLinkStatReader{ctrl: ctrl}
+	mock.recorder = &MockLinkStatReaderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLinkStatReader) EXPECT() *MockLinkStatReaderMockRecorder {
+	return m.recorder
+}
+
+// ReadLinkStat mocks base method.
+func (m *MockLinkStatReader) ReadLinkStat(ctx context.Context, uid, id string, days int) (*store.LinkStat, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadLinkStat", ctx, uid, id, days)
+	ret0, _ := ret[0].(*store.LinkStat)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadLinkStat indicates an expected call of ReadLinkStat.
+func (mr *MockLinkStatReaderMockRecorder) ReadLinkStat(ctx, uid, id, days interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadLinkStat", reflect.TypeOf((*MockLinkStatReader)(nil).ReadLinkStat), ctx, uid, id, days)
+}
+
 // MockStatProvider is a mock of StatProvider interface.
 type MockStatProvider struct {
 	ctrl     *gomock.Controller
@@ -366,16 +1308,16 @@ func (m *MockStatProvider) EXPECT() *MockStatProviderMockRecorder {
 }
 
 // Stat mocks base method.
-func (m *MockStatProvider) Stat() (*store.StatData, error) {
+func (m *MockStatProvider) Stat(ctx context.Context) (*store.StatData, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Stat")
+	ret := m.ctrl.Call(m, "Stat", ctx)
 	ret0, _ := ret[0].(*store.StatData)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Stat indicates an expected call of Stat.
-func (mr *MockStatProviderMockRecorder) Stat() *gomock.Call {
+func (mr *MockStatProviderMockRecorder) Stat(ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stat", reflect.TypeOf((*MockStatProvider)(nil).Stat))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stat", reflect.TypeOf((*MockStatProvider)(nil).Stat), ctx)
 }
@@ -0,0 +1,71 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+// ReportReason is a kind of the link abuse
+type ReportReason string
+
+const (
+	ReasonPhishing ReportReason = "phishing"
+	ReasonMalware  ReportReason = "malware"
+	ReasonSpam     ReportReason = "spam"
+	ReasonIllegal  ReportReason = "illegal"
+	ReasonOther    ReportReason = "other"
+)
+
+// MaxReportComment is the longest allowed report comment in bytes
+const MaxReportComment = 2000
+
+// AbuseReport is a complaint about the link sent by the public
+type AbuseReport struct {
+	ID      int64
+	LinkID  string
+	Reason  ReportReason
+	Comment string
+	// Reporter is the client address of the report sender
+	Reporter  string
+	CreatedAt time.Time
+}
+
+// Validate checks reason and comment of the new report
+func (r AbuseReport) Validate() error {
+	switch r.Reason {
+	case ReasonPhishing, ReasonMalware, ReasonSpam, ReasonIllegal, ReasonOther:
+	default:
+		return fmt.Errorf("%w: unknown report reason %q", ErrBadInput, r.Reason)
+	}
+	if r.LinkID == "" {
+		return fmt.Errorf("empty id: %w", ErrBadInput)
+	}
+	if len(r.Comment) > MaxReportComment {
+		return fmt.Errorf("%w: comment is longer than %d bytes", ErrBadInput, MaxReportComment)
+	}
+	return nil
+}
+
+// ReportQuery selects a page of the open reports, the oldest first
+type ReportQuery struct {
+	// LinkID selects reports of one link, empty selects reports of all links
+	LinkID string
+	// After is the id of the last report of the previous page, zero for the first page
+	After int64
+	// Limit of the page size, DefaultPageLimit is used if zero
+	Limit int
+}
+
+// Normalize validates query and fills the defaults
+func (q *ReportQuery) Normalize() error {
+	switch {
+	case q.Limit == 0:
+		q.Limit = DefaultPageLimit
+	case q.Limit < 0 || q.Limit > MaxPageLimit:
+		return fmt.Errorf("limit must be in range 1..%d: %w", MaxPageLimit, ErrBadInput)
+	}
+	if q.After < 0 {
+		return fmt.Errorf("after must not be negative: %w", ErrBadInput)
+	}
+	return nil
+}
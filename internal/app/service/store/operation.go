@@ -0,0 +1,53 @@
+package store
+
+import "time"
+
+// OperationStatus of the asynchronous operation
+type OperationStatus string
+
+const (
+	OperationPending   OperationStatus = "pending"
+	OperationCompleted OperationStatus = "completed"
+	OperationFailed    OperationStatus = "failed"
+)
+
+// Outcome of the operation for a single id
+type Outcome string
+
+const (
+	OutcomePending Outcome = "pending"
+	OutcomeDeleted Outcome = "deleted"
+	// OutcomeAlreadyDeleted means the row was removed before the operation
+	OutcomeAlreadyDeleted Outcome = "already_deleted"
+	// OutcomeNotFound means the row does not exist or is owned by another user
+	OutcomeNotFound Outcome = "not_found"
+	// OutcomeInvalid means the id is not a valid short id
+	OutcomeInvalid Outcome = "invalid"
+	OutcomeFailed  Outcome = "failed"
+
+	OutcomeRestored Outcome = "restored"
+	// OutcomeNotDeleted means the restored row is not removed
+	OutcomeNotDeleted Outcome = "not_deleted"
+	// OutcomeConflict means the restored row original url was shortened again
+	OutcomeConflict Outcome = "conflict"
+	// OutcomeForbidden means the restored row original url is not allowed anymore
+	OutcomeForbidden Outcome = "forbidden_target"
+)
+
+// Operation is a state of the asynchronous user request, e.g. batch removal
+type Operation struct {
+	ID        string
+	Status    OperationStatus
+	CreatedAt time.Time
+	// CompletedAt is set once the operation is completed or failed
+	CompletedAt *time.Time
+	// Error describes the failure of the failed operation
+	Error string
+	Items []OperationItem
+}
+
+// OperationItem is an outcome of the operation for a single id
+type OperationItem struct {
+	ID      string
+	Outcome Outcome
+}
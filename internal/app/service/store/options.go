@@ -0,0 +1,71 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+// WriteOptions of the new record
+type WriteOptions struct {
+	// ExpiresAt is the moment the link stops working, nil means never
+	ExpiresAt *time.Time
+	// Atomic batch writes all the records or none of them
+	Atomic bool
+}
+
+// WriteOption is a functional parameter of the writes
+type WriteOption func(*WriteOptions)
+
+// NewWriteOptions applies opts to the default options
+func NewWriteOptions(opts ...WriteOption) WriteOptions {
+	o := WriteOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithExpiresAt sets link expiration moment, nil means never
+func WithExpiresAt(t *time.Time) WriteOption {
+	return func(o *WriteOptions) {
+		o.ExpiresAt = t
+	}
+}
+
+// WithAtomic makes the batch write all the records or none of them
+func WithAtomic() WriteOption {
+	return func(o *WriteOptions) {
+		o.Atomic = true
+	}
+}
+
+// ResolveExpiration returns expiration moment requested either as absolute time or as ttl relative to now
+func ResolveExpiration(expiresAt *time.Time, ttl time.Duration, now time.Time) (*time.Time, error) {
+	switch {
+	case ttl < 0:
+		return nil, fmt.Errorf("%w: negative ttl", ErrBadInput)
+	case expiresAt != nil && ttl > 0:
+		return nil, fmt.Errorf("%w: both expiration time and ttl are set", ErrBadInput)
+	case ttl > 0:
+		t := now.Add(ttl)
+		return &t, nil
+	}
+
+	if err := ValidateExpiration(expiresAt, now); err != nil {
+		return nil, err
+	}
+	return expiresAt, nil
+}
+
+// ValidateExpiration checks the expiration moment is not in the past
+func ValidateExpiration(expiresAt *time.Time, now time.Time) error {
+	if expiresAt != nil && !expiresAt.After(now) {
+		return fmt.Errorf("%w: expiration time is in the past", ErrBadInput)
+	}
+	return nil
+}
+
+// Expired reports if the link expiring at expiresAt is expired at now
+func Expired(expiresAt *time.Time, now time.Time) bool {
+	return expiresAt != nil && !expiresAt.After(now)
+}
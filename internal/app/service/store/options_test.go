@@ -0,0 +1,42 @@
+package store
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestResolveExpiration(t *testing.T) {
+	now := time.Date(2022, 4, 20, 12, 0, 0, 0, time.UTC)
+	future := now.Add(time.Hour)
+	past := now.Add(-time.Hour)
+	inTTL := now.Add(time.Minute)
+
+	tests := []struct {
+		name      string
+		expiresAt *time.Time
+		ttl       time.Duration
+		want      *time.Time
+		wantErr   error
+	}{
+		{name: "no expiration"},
+		{name: "absolute time", expiresAt: &future, want: &future},
+		{name: "ttl", ttl: time.Minute, want: &inTTL},
+		{name: "past time", expiresAt: &past, wantErr: ErrBadInput},
+		{name: "now", expiresAt: &now, wantErr: ErrBadInput},
+		{name: "negative ttl", ttl: -time.Minute, wantErr: ErrBadInput},
+		{name: "both set", expiresAt: &future, ttl: time.Minute, wantErr: ErrBadInput},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveExpiration(tt.expiresAt, tt.ttl, now)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
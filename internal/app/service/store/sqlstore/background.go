@@ -0,0 +1,66 @@
+package sqlstore
+
+import (
+	"shortener/pkg/workerpool"
+	"time"
+)
+
+// startBackground starts expired rows sweeper and deletion requests processor
+func (s *Store) startBackground() {
+	s.bgDone = make(chan struct{})
+
+	if s.sweepInterval > 0 {
+		s.runPeriodic(s.sweepInterval, nil, 0, s.purgeExpiredJob)
+	}
+	if s.deletionPollInterval > 0 {
+		s.runPeriodic(s.deletionPollInterval, s.deletionWake, s.deletionWindow, s.processDeletionsJob)
+	}
+}
+
+// stopBackground waits for the background loops to exit, so no more jobs are sent to the worker pool
+func (s *Store) stopBackground() {
+	if s.bgDone == nil {
+		return
+	}
+	close(s.bgDone)
+	s.bgWG.Wait()
+	s.bgDone = nil
+}
+
+// runPeriodic runs job on the worker pool every interval and after the window since the wake signal
+func (s *Store) runPeriodic(interval time.Duration, wake <-chan struct{}, window time.Duration, newJob func() workerpool.Job) {
+	s.bgWG.Add(1)
+	go func(done <-chan struct{}) {
+		defer s.bgWG.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			case <-wake:
+				if window > 0 {
+					select {
+					case <-done:
+						return
+					case <-time.After(window):
+					}
+					// requests arrived within the window are processed by this run
+					select {
+					case <-wake:
+					default:
+					}
+				}
+			}
+
+			job := newJob()
+			if s.timeouts.Batch > 0 {
+				job = workerpool.AddTimeout(job, s.timeouts.Batch)
+			}
+			s.wp.Run(job)
+		}
+	}(s.bgDone)
+}
@@ -3,37 +3,64 @@ package sqlstore
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	pg "github.com/lib/pq"
 	"shortener/internal/app/service/store"
-	"shortener/pkg/workerpool"
+	"strconv"
+	"time"
 )
 
 // store.BatchWriter interface implementation
 var _ store.BatchWriter = (*Store)(nil)
 var _ store.BatchRemover = (*Store)(nil)
 
-func (s *Store) BatchWrite(uid string, in []store.Record) ([]store.Record, error) {
-	const batchWriteSQL = `
-INSERT INTO urls (uid, original_url)
-VALUES ($1, $2)
-RETURNING id
-`
-	err := s.inTransaction(func(tx *sql.Tx) error {
-		stmt, err := tx.Prepare(batchWriteSQL)
-		if err != nil {
-			return fmt.Errorf("sql prepare: %w", err)
+// BatchWrite inserts records in one transaction. Every record is inserted within the savepoint,
+// so the failed record is skipped without aborting the transaction, unless the batch is atomic.
+func (s *Store) BatchWrite(ctx context.Context, uid string, in []store.Record, opts ...store.WriteOption) ([]store.Record, error) {
+	o := store.NewWriteOptions(opts...)
+	first := store.PrepareBatch(in, time.Now(), s.dedup)
+	if o.Atomic {
+		if err := store.BatchError(in); err != nil {
+			return nil, err
+		}
+	}
+
+	urls := make([]string, 0, len(in))
+	for i := range in {
+		if first[i] == i && in[i].Err == nil {
+			urls = append(urls, in[i].OriginalURL)
+		}
+	}
+	if len(urls) == 0 {
+		return in, nil
+	}
+
+	ctx, cancel := store.WithTimeout(ctx, s.timeouts.Batch)
+	defer cancel()
+
+	err := s.inTransaction(ctx, func(tx *sql.Tx) error {
+		if err := s.releaseExpired(ctx, tx, uid, urls...); err != nil {
+			return err
 		}
-		defer func() {
-			_ = stmt.Close()
-		}()
 		for i := range in {
-			var rawID int64
-			err := stmt.QueryRow(uid, in[i].OriginalURL).Scan(&rawID)
+			if first[i] != i || in[i].Err != nil {
+				continue
+			}
+			id, err := s.insertBatchRecord(ctx, tx, uid, in[i])
 			if err != nil {
-				return fmt.Errorf("sql query: %w", err)
+				if o.Atomic || !batchItemError(err) {
+					return fmt.Errorf("batch item %d: %w", i, err)
+				}
+				in[i].Err = err
+				var errConflict *store.ConflictError
+				if errors.As(err, &errConflict) {
+					in[i].ShortURL = errConflict.ExistingURL
+				}
+				continue
 			}
-			in[i].ID = s.idFromInt64(rawID)
-			in[i].ShortURL = s.shortURL(in[i].ID)
+			in[i].ID = id
+			in[i].ShortURL = s.shortURL(id)
 		}
 		return nil
 	})
@@ -42,34 +69,74 @@ RETURNING id
 		return nil, fmt.Errorf("tx: %w", err)
 	}
 
+	store.FinishBatch(in, first)
+
 	return in, nil
 }
 
-func (s *Store) BatchRemove(uid string, ids ...string) error {
-	const softDeleteQuery = `
-		UPDATE urls SET deleted_at = NOW()
-		WHERE id=$1 and uid=$2
+// insertBatchRecord inserts the record within the savepoint and converts url conflict into store.ConflictError
+func (s *Store) insertBatchRecord(ctx context.Context, tx *sql.Tx, uid string, rec store.Record) (string, error) {
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT batch_item"); err != nil {
+		return "", fmt.Errorf("savepoint: %w", err)
+	}
+
+	var (
+		id  string
+		err error
+	)
+	if rec.ID != "" {
+		id, err = s.insertAlias(ctx, tx, uid, rec)
+	} else {
+		id, err = s.insertGenerated(ctx, tx, uid, rec)
+	}
+	if err != nil {
+		if _, rbErr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT batch_item"); rbErr != nil {
+			return "", fmt.Errorf("rollback to savepoint: %w", rbErr)
+		}
+		return "", s.writeError(ctx, tx, err, uid, rec.OriginalURL)
+	}
+
+	if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT batch_item"); err != nil {
+		return "", fmt.Errorf("release savepoint: %w", err)
+	}
+	return id, nil
+}
+
+// batchItemError reports if the error is caused by the record itself, so the rest of the batch could be written
+func batchItemError(err error) bool {
+	var errConflict *store.ConflictError
+	return errors.As(err, &errConflict) || errors.Is(err, store.ErrAliasTaken) || errors.Is(err, store.ErrIDExhausted)
+}
+
+// BatchRemove persists deletion request, so the removal is guaranteed once nil error is returned.
+// Requests are processed asynchronously by the background workers, even after restart.
+// Returned operation id is the deletion request id.
+func (s *Store) BatchRemove(ctx context.Context, uid string, ids ...string) (string, error) {
+	const insertSQL = `
+		INSERT INTO deletion_requests (uid, short_ids) VALUES ($1, $2) RETURNING id
 `
-	asyncRemoveJob := func(uid string, id string) workerpool.Job {
-		return func(ctx context.Context) error {
-			rawID, err := s.idToInt64(id)
-			if err != nil {
-				return err
-			}
 
-			if err := s.execQuery(softDeleteQuery, rawID, uid); err != nil {
-				return err
-			}
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
 
-			return nil
-		}
+	if len(ids) == 0 {
+		return "", store.ErrEmptyIDs
 	}
 
-	go func() {
-		for _, v := range ids {
-			s.wp.Run(asyncRemoveJob(uid, v))
-		}
-	}()
+	ctx, cancel := store.WithTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	var id int64
+	if err := s.db.QueryRowContext(ctx, insertSQL, uid, pg.Array(ids)).Scan(&id); err != nil {
+		return "", fmt.Errorf("deletion request query: %w", err)
+	}
+
+	// wake up the processor, it is already awake if the signal is pending
+	select {
+	case s.deletionWake <- struct{}{}:
+	default:
+	}
 
-	return nil
+	return strconv.FormatInt(id, 10), nil
 }
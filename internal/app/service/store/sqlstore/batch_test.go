@@ -0,0 +1,88 @@
+package sqlstore
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	pg "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"shortener/internal/app/service/store"
+	"testing"
+)
+
+func TestStore_BatchWrite(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() {
+		_ = db.Close()
+	}()
+
+	s, err := New(db, WithBaseURL("http://localhost"), WithIDGenerator(store.NewSequentialGenerator(10)))
+	require.NoError(t, err)
+
+	uniqueViolation := &pg.Error{Code: "23505"}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE urls SET deleted_at = NOW()").
+		WithArgs(arrayArg([]string{"https://example.org/a", "https://example.org/b"})).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	// first record is written
+	mock.ExpectExec("SAVEPOINT batch_item").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT nextval").WillReturnRows(sqlmock.NewRows([]string{"nextval"}).AddRow(1))
+	mock.ExpectQuery("INSERT INTO urls").
+		WithArgs(1, "1", "user1", "https://example.org/a", nil, "https://example.org/a").
+		WillReturnRows(sqlmock.NewRows([]string{"short_id"}).AddRow("1"))
+	mock.ExpectExec("RELEASE SAVEPOINT batch_item").WillReturnResult(sqlmock.NewResult(0, 0))
+	// second one conflicts with the existing url
+	mock.ExpectExec("SAVEPOINT batch_item").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT nextval").WillReturnRows(sqlmock.NewRows([]string{"nextval"}).AddRow(2))
+	mock.ExpectQuery("INSERT INTO urls").WillReturnError(uniqueViolation)
+	mock.ExpectExec("ROLLBACK TO SAVEPOINT batch_item").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT short_id FROM urls WHERE dedup_key").
+		WithArgs("https://example.org/b").
+		WillReturnRows(sqlmock.NewRows([]string{"short_id"}).AddRow("old"))
+	mock.ExpectCommit()
+
+	got, err := s.BatchWrite(context.Background(), "user1", []store.Record{
+		{CorrelationID: "a", OriginalURL: "https://example.org/a"},
+		{CorrelationID: "b", OriginalURL: "https://example.org/b"},
+		{CorrelationID: "c", OriginalURL: "bad"},
+		{CorrelationID: "d", OriginalURL: "https://example.org/a"},
+	})
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	assert.Equal(t, "http://localhost/1", got[0].ShortURL)
+	assert.NoError(t, got[0].Err)
+	assert.Equal(t, "http://localhost/old", got[1].ShortURL)
+	var errConflict *store.ConflictError
+	assert.ErrorAs(t, got[1].Err, &errConflict)
+	assert.ErrorIs(t, got[2].Err, store.ErrBadInput)
+	assert.Equal(t, "http://localhost/1", got[3].ShortURL, "repeated url must share the result")
+
+	// atomic batch is rolled back on the first failure
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE urls SET deleted_at = NOW()").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("SAVEPOINT batch_item").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("INSERT INTO urls").
+		WithArgs("taken", "user1", "https://example.org/a", nil, "https://example.org/a").
+		WillReturnRows(sqlmock.NewRows([]string{"short_id"}))
+	mock.ExpectExec("ROLLBACK TO SAVEPOINT batch_item").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	_, err = s.BatchWrite(context.Background(), "user1", []store.Record{
+		{OriginalURL: "https://example.org/a", ID: "taken"},
+	}, store.WithAtomic())
+	assert.ErrorIs(t, err, store.ErrAliasTaken)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// infrastructure failure aborts the batch
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE urls SET deleted_at = NOW()").WillReturnError(errors.New("db is down"))
+	mock.ExpectRollback()
+
+	_, err = s.BatchWrite(context.Background(), "user1", []store.Record{{OriginalURL: "https://example.org/a"}})
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,129 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	pg "github.com/lib/pq"
+	"shortener/internal/app/service/store"
+	"time"
+)
+
+// store.ClickRecorder interface implementation
+var _ store.ClickRecorder = (*Store)(nil)
+var _ store.LinkStatReader = (*Store)(nil)
+
+// RecordClicks inserts all the events with a single statement
+func (s *Store) RecordClicks(ctx context.Context, events ...store.ClickEvent) error {
+	const insertSQL = `
+		INSERT INTO clicks (url_id, clicked_at, referer, user_agent, ip, request_id)
+		SELECT u.id, e.clicked_at, e.referer, e.user_agent, e.ip, e.request_id
+		FROM unnest($1::text[], $2::timestamptz[], $3::text[], $4::text[], $5::text[], $6::text[])
+			AS e (short_id, clicked_at, referer, user_agent, ip, request_id)
+		JOIN urls u ON u.short_id = e.short_id
+`
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	n := len(events)
+	var (
+		ids        = make([]string, n)
+		times      = make([]string, n)
+		referers   = make([]string, n)
+		userAgents = make([]string, n)
+		ips        = make([]string, n)
+		requestIDs = make([]string, n)
+	)
+	for i, e := range events {
+		ids[i] = e.ID
+		times[i] = e.Time.Format(time.RFC3339Nano)
+		referers[i] = e.Referer
+		userAgents[i] = e.UserAgent
+		ips[i] = e.IP
+		requestIDs[i] = e.RequestID
+	}
+
+	ctx, cancel := store.WithTimeout(ctx, s.timeouts.Batch)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, insertSQL,
+		pg.Array(ids), pg.Array(times), pg.Array(referers), pg.Array(userAgents), pg.Array(ips), pg.Array(requestIDs),
+	)
+	if err != nil {
+		return fmt.Errorf("record clicks query: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Store) ReadLinkStat(ctx context.Context, uid string, id string, days int) (*store.LinkStat, error) {
+	const (
+		ownerSQL = `
+		SELECT id FROM urls WHERE short_id=$1 AND uid=$2
+`
+		totalSQL = `
+		SELECT COUNT(*), COUNT(DISTINCT ip) FROM clicks WHERE url_id=$1
+`
+		dailySQL = `
+		SELECT (clicked_at AT TIME ZONE 'UTC')::date AS day, COUNT(*) FROM clicks
+		WHERE url_id=$1 AND clicked_at >= $2
+		GROUP BY day
+`
+	)
+
+	if id == "" {
+		return nil, fmt.Errorf("empty id: %w", store.ErrBadInput)
+	}
+
+	ctx, cancel := store.WithTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+
+	var key int64
+	if err := s.db.QueryRowContext(ctx, ownerSQL, id, uid).Scan(&key); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, store.ErrNotFound
+		}
+		return nil, fmt.Errorf("link owner query: %w", err)
+	}
+
+	stat := &store.LinkStat{ID: id, ShortURL: s.shortURL(id)}
+	if err := s.db.QueryRowContext(ctx, totalSQL, key).Scan(&stat.TotalClicks, &stat.UniqueVisitors); err != nil {
+		return nil, fmt.Errorf("link clicks query: %w", err)
+	}
+
+	now := time.Now()
+	series := store.DailySeries(nil, days, now)
+	if len(series) == 0 {
+		return stat, nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, dailySQL, key, series[0].Date)
+	if err != nil {
+		return nil, fmt.Errorf("link daily clicks query: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	counts := make(map[time.Time]int)
+	for rows.Next() {
+		var (
+			day    time.Time
+			clicks int
+		)
+		if err := rows.Scan(&day, &clicks); err != nil {
+			return nil, fmt.Errorf("link daily clicks scan: %w", err)
+		}
+		counts[store.Day(day)] = clicks
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("link daily clicks rows: %w", err)
+	}
+
+	stat.Daily = store.DailySeries(counts, days, now)
+
+	return stat, nil
+}
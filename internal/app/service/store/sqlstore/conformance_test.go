@@ -0,0 +1,50 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"os"
+	"shortener/internal/app/service/store"
+	"shortener/internal/app/service/store/storetest"
+	"shortener/internal/migrate"
+	"testing"
+)
+
+// TestConformance requires running postgres, set TEST_DATABASE_DSN to enable it. CI runs it against the postgres service.
+func TestConformance(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" || testing.Short() {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+
+	storetest.Run(t, factory(dsn))
+
+	for _, scope := range []store.DedupScope{store.DedupUser, store.DedupNone} {
+		t.Run(string(scope), func(t *testing.T) {
+			storetest.RunDedupScope(t, scope, factory(dsn, WithDedupScope(scope)))
+		})
+	}
+}
+
+func factory(dsn string, opts ...Option) storetest.Factory {
+	return func(t *testing.T) store.Store {
+		db, err := sql.Open("postgres", dsn)
+		if err != nil {
+			t.Fatalf("db open error = %v", err)
+		}
+		if err := migrate.Up(db); err != nil {
+			t.Fatalf("migrate up error = %v", err)
+		}
+
+		s, err := New(db, append([]Option{WithBaseURL("http://localhost:8080"), WithCanonicalizer(store.NewCanonicalizer())}, opts...)...)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		if err := s.Start(); err != nil {
+			t.Fatalf("Start() error = %v", err)
+		}
+		t.Cleanup(func() {
+			_ = s.Stop()
+		})
+		return s
+	}
+}
@@ -1,12 +1,13 @@
 package sqlstore
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 )
 
-func (s *Store) inTransaction(cb func(tx *sql.Tx) error) error {
-	tx, err := s.db.Begin()
+func (s *Store) inTransaction(ctx context.Context, cb func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("tx begin: %w", err)
 	}
@@ -25,20 +26,3 @@ func (s *Store) inTransaction(cb func(tx *sql.Tx) error) error {
 
 	return nil
 }
-
-func (s *Store) execQuery(query string, args ...interface{}) error {
-	stmt, err := s.db.Prepare(query)
-	if err != nil {
-		return fmt.Errorf("prepare: %w", err)
-	}
-	defer func() {
-		_ = stmt.Close()
-	}()
-
-	_, err = stmt.Exec(args...)
-	if err != nil {
-		return fmt.Errorf("exec: %w", err)
-	}
-
-	return nil
-}
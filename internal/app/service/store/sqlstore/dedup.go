@@ -0,0 +1,134 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	pg "github.com/lib/pq"
+	"shortener/internal/app/service/store"
+)
+
+const (
+	// dedupSetting is the name of the store setting keeping the rules the stored dedup keys are built by
+	dedupSetting = "dedup"
+	// dedupSyncBatch is a number of dedup keys updated by a single query
+	dedupSyncBatch = 1000
+)
+
+// dedupKey returns the value of dedup_key column, null rows are not deduplicated by the unique index
+func (s *Store) dedupKey(uid string, url string) sql.NullString {
+	key, ok := s.dedup.Key(uid, url)
+	return sql.NullString{String: key, Valid: ok}
+}
+
+// syncDedupKeys rebuilds dedup keys if they were built by the other rules, so the dedup scope or canonicalization
+// change applies to the existing rows. The table is locked for writes while the keys are rebuilt.
+// Narrowing the rules always succeeds, widening them fails while the rows duplicate each other by the new rules.
+func (s *Store) syncDedupKeys(ctx context.Context) error {
+	const (
+		settingSQL = `SELECT value FROM store_settings WHERE name = $1`
+		lockSQL    = `LOCK TABLE urls IN SHARE ROW EXCLUSIVE MODE`
+		updateSQL  = `
+		UPDATE urls SET dedup_key = v.key
+		FROM unnest($1::bigint[], $2::text[]) AS v(id, key)
+		WHERE urls.id = v.id
+`
+		saveSQL = `
+		INSERT INTO store_settings (name, value) VALUES ($1, $2)
+		ON CONFLICT (name) DO UPDATE SET value = EXCLUDED.value
+`
+	)
+
+	ctx, cancel := store.WithTimeout(ctx, s.timeouts.Batch)
+	defer cancel()
+
+	rules := s.dedup.String()
+	synced := func(tx *sql.Tx) (bool, error) {
+		var current string
+		err := tx.QueryRowContext(ctx, settingSQL, dedupSetting).Scan(&current)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return false, fmt.Errorf("setting query: %w", err)
+		}
+		return current == rules, nil
+	}
+
+	updated := 0
+	err := s.inTransaction(ctx, func(tx *sql.Tx) error {
+		if ok, err := synced(tx); err != nil || ok {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, lockSQL); err != nil {
+			return fmt.Errorf("lock query: %w", err)
+		}
+		// other instance could rebuild the keys while the lock was awaited
+		if ok, err := synced(tx); err != nil || ok {
+			return err
+		}
+
+		ids, keys, err := s.staleDedupKeys(ctx, tx)
+		if err != nil {
+			return err
+		}
+		for start := 0; start < len(ids); start += dedupSyncBatch {
+			end := start + dedupSyncBatch
+			if end > len(ids) {
+				end = len(ids)
+			}
+			if _, err := tx.ExecContext(ctx, updateSQL, pg.Array(ids[start:end]), pg.Array(keys[start:end])); err != nil {
+				return fmt.Errorf("update query: %w", err)
+			}
+		}
+		updated = len(ids)
+
+		if _, err := tx.ExecContext(ctx, saveSQL, dedupSetting, rules); err != nil {
+			return fmt.Errorf("save setting query: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("sync dedup keys to %q, active links may duplicate each other: %w", rules, err)
+	}
+
+	if updated > 0 {
+		s.log.Info().Msgf("dedup keys updated to %q: %d", rules, updated)
+	}
+	return nil
+}
+
+// staleDedupKeys returns ids of the rows with the dedup keys built by the other rules together with the new keys
+func (s *Store) staleDedupKeys(ctx context.Context, tx *sql.Tx) ([]int64, []sql.NullString, error) {
+	const selectSQL = `SELECT id, uid, original_url, dedup_key FROM urls`
+
+	rows, err := tx.QueryContext(ctx, selectSQL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("select query: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var (
+		ids  []int64
+		keys []sql.NullString
+	)
+	for rows.Next() {
+		var (
+			id       int64
+			uid, key sql.NullString
+			url      string
+		)
+		if err := rows.Scan(&id, &uid, &url, &key); err != nil {
+			return nil, nil, fmt.Errorf("select scan: %w", err)
+		}
+		if k := s.dedupKey(uid.String, url); k != key {
+			ids = append(ids, id)
+			keys = append(keys, k)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("select rows: %w", err)
+	}
+
+	return ids, keys, nil
+}
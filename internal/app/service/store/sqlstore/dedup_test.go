@@ -0,0 +1,141 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	pg "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"shortener/internal/app/service/store"
+	"testing"
+)
+
+func TestStore_WriteURLDedupScope(t *testing.T) {
+	const url = "https://example.org/a"
+
+	tests := []struct {
+		name    string
+		scope   store.DedupScope
+		prepare func(mock sqlmock.Sqlmock)
+	}{
+		{
+			"user",
+			store.DedupUser,
+			func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("UPDATE urls SET deleted_at").WithArgs(arrayArg([]string{"user1 " + url})).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery("SELECT nextval").WillReturnRows(sqlmock.NewRows([]string{"nextval"}).AddRow(1))
+				mock.ExpectQuery("INSERT INTO urls").WithArgs(1, "1", "user1", url, nil, "user1 "+url).
+					WillReturnRows(sqlmock.NewRows([]string{"short_id"}).AddRow("1"))
+			},
+		},
+		{
+			"none",
+			store.DedupNone,
+			func(mock sqlmock.Sqlmock) {
+				// nothing to release, the link does not take the url
+				mock.ExpectQuery("SELECT nextval").WillReturnRows(sqlmock.NewRows([]string{"nextval"}).AddRow(1))
+				mock.ExpectQuery("INSERT INTO urls").WithArgs(1, "1", "user1", url, nil, nil).
+					WillReturnRows(sqlmock.NewRows([]string{"short_id"}).AddRow("1"))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer func() {
+				_ = db.Close()
+			}()
+
+			s, err := New(db, WithBaseURL("http://localhost"), WithDedupScope(tt.scope))
+			require.NoError(t, err)
+
+			tt.prepare(mock)
+			got, err := s.WriteURL(context.Background(), url, "user1")
+			require.NoError(t, err)
+			assert.Equal(t, "http://localhost/1", got)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestStore_SyncDedupKeys(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() {
+		_ = db.Close()
+	}()
+
+	s, err := New(db, WithDedupScope(store.DedupUser), WithCanonicalizer(store.NewCanonicalizer()))
+	require.NoError(t, err)
+
+	const rules = "scope=user;canonical=v1;sort=false;strip="
+	settingRows := func(values ...string) *sqlmock.Rows {
+		rows := sqlmock.NewRows([]string{"value"})
+		for _, v := range values {
+			rows.AddRow(v)
+		}
+		return rows
+	}
+
+	// keys are built by the same rules
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT value FROM store_settings").WithArgs("dedup").WillReturnRows(settingRows(rules))
+	mock.ExpectCommit()
+	require.NoError(t, s.syncDedupKeys(context.Background()))
+
+	// keys are rebuilt after the rules change
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT value FROM store_settings").WillReturnRows(settingRows("scope=global;canonical=none"))
+	mock.ExpectExec("LOCK TABLE urls").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT value FROM store_settings").WillReturnRows(settingRows("scope=global;canonical=none"))
+	mock.ExpectQuery("SELECT id, uid, original_url, dedup_key FROM urls").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "uid", "original_url", "dedup_key"}).
+			AddRow(1, "user1", "HTTP://Example.org:80/a", "HTTP://Example.org:80/a").
+			AddRow(2, "user1", "https://example.org/b", "user1 https://example.org/b"))
+	mock.ExpectExec("UPDATE urls SET dedup_key").
+		WithArgs(arrayArg([]int64{1}), arrayArg([]sql.NullString{{String: "user1 http://example.org/a", Valid: true}})).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO store_settings").WithArgs("dedup", rules).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	require.NoError(t, s.syncDedupKeys(context.Background()))
+
+	// users shortened the same url while the rules were narrower
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT value FROM store_settings").WillReturnRows(settingRows())
+	mock.ExpectExec("LOCK TABLE urls").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT value FROM store_settings").WillReturnRows(settingRows())
+	mock.ExpectQuery("SELECT id, uid, original_url, dedup_key FROM urls").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "uid", "original_url", "dedup_key"}).AddRow(1, "user1", "https://example.org/a", nil))
+	mock.ExpectExec("UPDATE urls SET dedup_key").WillReturnError(&pg.Error{Code: "23505"})
+	mock.ExpectRollback()
+	assert.Error(t, s.Start(), "store must not start with the inconsistent keys")
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStore_WriteURLSkipsReserved(t *testing.T) {
+	const url = "https://example.org/a"
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() {
+		_ = db.Close()
+	}()
+
+	s, err := New(db, WithBaseURL("http://localhost"), WithDedupScope(store.DedupNone), WithIDGenerator(store.NewSequentialGenerator(36)))
+	require.NoError(t, err)
+
+	// base36 id of the sequence value is "ping", shadowed by the ping route
+	mock.ExpectQuery("SELECT nextval").WillReturnRows(sqlmock.NewRows([]string{"nextval"}).AddRow(1190572))
+	mock.ExpectQuery("SELECT nextval").WillReturnRows(sqlmock.NewRows([]string{"nextval"}).AddRow(1190573))
+	mock.ExpectQuery("INSERT INTO urls").WithArgs(1190573, "pinh", "user1", url, nil, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"short_id"}).AddRow("pinh"))
+
+	got, err := s.WriteURL(context.Background(), url, "user1")
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost/pinh", got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
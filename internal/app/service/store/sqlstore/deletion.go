@@ -0,0 +1,326 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	pg "github.com/lib/pq"
+	"shortener/internal/app/service/store"
+	"shortener/pkg/workerpool"
+	"strconv"
+	"time"
+)
+
+var _ store.OperationReader = (*Store)(nil)
+
+const (
+	// deletionClaimSize limits number of deletion requests processed in a single transaction
+	deletionClaimSize = 100
+	// maxDeletionAttempts limits processing attempts before the deletion request is marked failed
+	maxDeletionAttempts = 5
+	// deletionFailureTimeout limits the failed attempt update, it does not share the deadline of the failed transaction
+	deletionFailureTimeout = 5 * time.Second
+)
+
+// deletionRequest is a pending deletion request with the processing result
+type deletionRequest struct {
+	id        int64
+	uid       string
+	ids       []string
+	affected  int
+	notOwned  []string
+	unchanged []string
+	invalid   []string
+}
+
+// processDeletionsJob processes pending deletion requests until there are none left
+func (s *Store) processDeletionsJob() workerpool.Job {
+	return func(ctx context.Context) error {
+		for {
+			n, err := s.processDeletions(ctx)
+			if err != nil {
+				return err
+			}
+			if n < deletionClaimSize {
+				return nil
+			}
+		}
+	}
+}
+
+// processDeletions claims a portion of the pending deletion requests, soft deletes their rows and
+// marks the requests processed in the same transaction. Claimed requests are locked, so concurrent
+// processors (e.g. other instances) skip them. Ids of the same user are coalesced across the requests,
+// so a single statement is issued per user. Returns the number of processed requests.
+//
+// If processing fails the claimed requests attempts are counted, the requests exceeding
+// maxDeletionAttempts are marked failed and are not processed anymore.
+func (s *Store) processDeletions(ctx context.Context) (int, error) {
+	const (
+		claimSQL = `
+		SELECT id, uid, short_ids FROM deletion_requests
+		WHERE processed_at IS NULL
+		ORDER BY id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+`
+		doneSQL = `
+		UPDATE deletion_requests
+		SET processed_at = NOW(), affected = $2, not_owned = $3, unchanged = $4, invalid = $5
+		WHERE id = $1
+`
+	)
+
+	var claimed []int64
+	err := s.inTransaction(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, claimSQL, deletionClaimSize)
+		if err != nil {
+			return fmt.Errorf("claim query: %w", err)
+		}
+
+		var requests []*deletionRequest
+		for rows.Next() {
+			r := &deletionRequest{}
+			if err := rows.Scan(&r.id, &r.uid, pg.Array(&r.ids)); err != nil {
+				_ = rows.Close()
+				return fmt.Errorf("claim scan: %w", err)
+			}
+			requests = append(requests, r)
+			claimed = append(claimed, r.id)
+		}
+		if err := rows.Close(); err != nil {
+			return fmt.Errorf("claim rows: %w", err)
+		}
+
+		if len(requests) == 0 {
+			return nil
+		}
+
+		for _, group := range groupByUser(requests) {
+			if err := softDeleteUserRows(ctx, tx, group); err != nil {
+				return err
+			}
+		}
+
+		var affected, notOwned int
+		for _, r := range requests {
+			_, err := tx.ExecContext(ctx, doneSQL,
+				r.id, r.affected, pg.Array(r.notOwned), pg.Array(r.unchanged), pg.Array(r.invalid))
+			if err != nil {
+				return fmt.Errorf("deletion done query: %w", err)
+			}
+			affected += r.affected
+			notOwned += len(r.notOwned)
+		}
+
+		s.log.Debug().
+			Int("requests", len(requests)).
+			Int("affected", affected).
+			Int("not_owned", notOwned).
+			Msg("Deletion requests processed")
+
+		return nil
+	})
+	if err != nil {
+		if len(claimed) > 0 {
+			s.countDeletionFailure(claimed, err)
+		}
+		return 0, fmt.Errorf("process deletions: %w", err)
+	}
+
+	return len(claimed), nil
+}
+
+// countDeletionFailure counts failed attempt of the requests, the requests running out of attempts are marked failed.
+// The update gets its own context, so it is not lost if the processing failed because of the timeout.
+func (s *Store) countDeletionFailure(ids []int64, cause error) {
+	// SET expressions refer to the old attempts value
+	const failSQL = `
+		UPDATE deletion_requests
+		SET attempts = attempts + 1,
+			last_error = $2,
+			failed = attempts + 1 >= $3,
+			processed_at = CASE WHEN attempts + 1 >= $3 THEN NOW() END
+		WHERE id = ANY($1)
+`
+
+	ctx, cancel := context.WithTimeout(context.Background(), deletionFailureTimeout)
+	defer cancel()
+
+	if _, err := s.db.ExecContext(ctx, failSQL, pg.Array(ids), cause.Error(), maxDeletionAttempts); err != nil {
+		s.log.Error().Err(err).Msg("Deletion failure update")
+	}
+}
+
+// groupByUser groups requests by user keeping the claim order
+func groupByUser(requests []*deletionRequest) [][]*deletionRequest {
+	var groups [][]*deletionRequest
+	index := make(map[string]int)
+	for _, r := range requests {
+		i, ok := index[r.uid]
+		if !ok {
+			i = len(groups)
+			index[r.uid] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], r)
+	}
+	return groups
+}
+
+// softDeleteUserRows removes rows of the requests issued by the same user with a single statement
+// and fills the requests results. Row is counted as affected by every request containing its id,
+// ids of the rows not found or owned by other users are reported as not owned.
+func softDeleteUserRows(ctx context.Context, tx *sql.Tx, requests []*deletionRequest) error {
+	// rows already deleted are selected from the statement snapshot, so they are not counted as affected
+	const softDeleteSQL = `
+		WITH deleted AS (
+			UPDATE urls SET deleted_at = NOW()
+			WHERE uid = $1 AND short_id = ANY($2) AND deleted_at IS NULL
+			RETURNING short_id
+		)
+		SELECT short_id, TRUE FROM deleted
+		UNION ALL
+		SELECT short_id, FALSE FROM urls
+		WHERE uid = $1 AND short_id = ANY($2) AND deleted_at IS NOT NULL
+`
+
+	var ids []string
+	seen := make(map[string]struct{})
+	for _, r := range requests {
+		for _, id := range r.ids {
+			if _, ok := seen[id]; ok || store.ValidateAlias(id) != nil {
+				continue
+			}
+			seen[id] = struct{}{}
+			ids = append(ids, id)
+		}
+	}
+
+	// owned maps owned ids to the flag if the row was removed by the statement
+	owned := make(map[string]bool)
+	if len(ids) > 0 {
+		rows, err := tx.QueryContext(ctx, softDeleteSQL, requests[0].uid, pg.Array(ids))
+		if err != nil {
+			return fmt.Errorf("soft delete query: %w", err)
+		}
+
+		for rows.Next() {
+			var (
+				id      string
+				removed bool
+			)
+			if err := rows.Scan(&id, &removed); err != nil {
+				_ = rows.Close()
+				return fmt.Errorf("soft delete scan: %w", err)
+			}
+			owned[id] = removed
+		}
+		if err := rows.Close(); err != nil {
+			return fmt.Errorf("soft delete rows: %w", err)
+		}
+	}
+
+	for _, r := range requests {
+		for _, id := range r.ids {
+			if _, ok := seen[id]; !ok {
+				r.invalid = append(r.invalid, id)
+				continue
+			}
+			removed, ok := owned[id]
+			switch {
+			case !ok:
+				r.notOwned = append(r.notOwned, id)
+			case removed:
+				r.affected++
+			default:
+				r.unchanged = append(r.unchanged, id)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ReadOperation returns deletion request state with per id outcomes
+func (s *Store) ReadOperation(ctx context.Context, uid string, id string) (*store.Operation, error) {
+	const selectSQL = `
+		SELECT short_ids, created_at, processed_at, failed, last_error, not_owned, unchanged, invalid
+		FROM deletion_requests
+		WHERE id = $1 AND uid = $2
+`
+
+	requestID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("operation %q: %w", id, store.ErrNotFound)
+	}
+
+	ctx, cancel := store.WithTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+
+	var (
+		ids, notOwned, unchanged, invalid []string
+		createdAt                         time.Time
+		processedAt                       pg.NullTime
+		failed                            bool
+		lastError                         sql.NullString
+	)
+	err = s.db.QueryRowContext(ctx, selectSQL, requestID, uid).Scan(
+		pg.Array(&ids), &createdAt, &processedAt, &failed, &lastError,
+		pg.Array(&notOwned), pg.Array(&unchanged), pg.Array(&invalid),
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("operation %q: %w", id, store.ErrNotFound)
+		}
+		return nil, fmt.Errorf("operation query: %w", err)
+	}
+
+	op := &store.Operation{
+		ID:          id,
+		Status:      store.OperationCompleted,
+		CreatedAt:   createdAt,
+		CompletedAt: nullTime(processedAt),
+		Items:       make([]store.OperationItem, len(ids)),
+	}
+
+	outcomes := make(map[string]store.Outcome)
+	switch {
+	case !processedAt.Valid:
+		op.Status = store.OperationPending
+	case failed:
+		op.Status = store.OperationFailed
+		op.Error = lastError.String
+	default:
+		for _, v := range notOwned {
+			outcomes[v] = store.OutcomeNotFound
+		}
+		for _, v := range unchanged {
+			outcomes[v] = store.OutcomeAlreadyDeleted
+		}
+		for _, v := range invalid {
+			outcomes[v] = store.OutcomeInvalid
+		}
+	}
+
+	for i, v := range ids {
+		op.Items[i] = store.OperationItem{ID: v, Outcome: itemOutcome(op.Status, outcomes[v])}
+	}
+
+	return op, nil
+}
+
+// itemOutcome of the deletion request id, ids without recorded outcome of the completed request are deleted
+func itemOutcome(status store.OperationStatus, recorded store.Outcome) store.Outcome {
+	switch {
+	case status == store.OperationPending:
+		return store.OutcomePending
+	case status == store.OperationFailed:
+		return store.OutcomeFailed
+	case recorded != "":
+		return recorded
+	default:
+		return store.OutcomeDeleted
+	}
+}
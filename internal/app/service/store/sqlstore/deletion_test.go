@@ -0,0 +1,235 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	pg "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"shortener/internal/app/service/store"
+	"testing"
+	"time"
+)
+
+func TestStore_BatchRemove(t *testing.T) {
+	tests := []struct {
+		name    string
+		ids     []string
+		dbErr   error
+		wantID  string
+		wantErr bool
+		wantRow bool
+	}{
+		{name: "request persisted", ids: []string{"a", "b"}, wantID: "7", wantRow: true},
+		{name: "nothing to remove", ids: nil, wantErr: true},
+		{name: "db error", ids: []string{"a"}, dbErr: errors.New("db error"), wantErr: true, wantRow: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer func() {
+				_ = db.Close()
+			}()
+
+			s, err := New(db)
+			require.NoError(t, err)
+
+			if tt.wantRow {
+				exp := mock.ExpectQuery("INSERT INTO deletion_requests").WithArgs("user1", arrayArg(tt.ids))
+				if tt.dbErr != nil {
+					exp.WillReturnError(tt.dbErr)
+				} else {
+					exp.WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+				}
+			}
+
+			id, err := s.BatchRemove(context.Background(), "user1", tt.ids...)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantID, id)
+			assert.NoError(t, mock.ExpectationsWereMet())
+
+			// processor is woken up only when the request was persisted
+			woken := len(s.deletionWake) > 0
+			assert.Equal(t, tt.wantID != "", woken)
+		})
+	}
+}
+
+func TestStore_processDeletions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() {
+		_ = db.Close()
+	}()
+
+	s, err := New(db)
+	require.NoError(t, err)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, uid, short_ids FROM deletion_requests").
+		WithArgs(deletionClaimSize).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "uid", "short_ids"}).
+			AddRow(1, "user1", "{a,b}").
+			AddRow(2, "user2", "{c,bad/id}").
+			AddRow(3, "user1", "{b,d,e}"))
+	// ids of the same user are coalesced into a single statement
+	mock.ExpectQuery("WITH deleted AS").WithArgs("user1", arrayArg([]string{"a", "b", "d", "e"})).
+		WillReturnRows(sqlmock.NewRows([]string{"short_id", "removed"}).
+			AddRow("a", true).
+			AddRow("b", true).
+			AddRow("d", false))
+	mock.ExpectQuery("WITH deleted AS").WithArgs("user2", arrayArg([]string{"c"})).
+		WillReturnRows(sqlmock.NewRows([]string{"short_id", "removed"}))
+	mock.ExpectExec("UPDATE deletion_requests").
+		WithArgs(1, 2, arrayArg([]string(nil)), arrayArg([]string(nil)), arrayArg([]string(nil))).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE deletion_requests").
+		WithArgs(2, 0, arrayArg([]string{"c"}), arrayArg([]string(nil)), arrayArg([]string{"bad/id"})).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE deletion_requests").
+		WithArgs(3, 1, arrayArg([]string{"e"}), arrayArg([]string{"d"}), arrayArg([]string(nil))).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	n, err := s.processDeletions(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	// failed soft delete keeps requests pending and counts the attempt
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, uid, short_ids FROM deletion_requests").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "uid", "short_ids"}).AddRow(4, "user1", "{f}"))
+	mock.ExpectQuery("WITH deleted AS").WillReturnError(errors.New("db error"))
+	mock.ExpectRollback()
+	mock.ExpectExec("UPDATE deletion_requests SET attempts").
+		WithArgs(arrayArg([]int64{4}), sqlmock.AnyArg(), maxDeletionAttempts).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	_, err = s.processDeletions(context.Background())
+	assert.Error(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStore_processDeletionsTimeout(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() {
+		_ = db.Close()
+	}()
+
+	s, err := New(db)
+	require.NoError(t, err)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, uid, short_ids FROM deletion_requests").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "uid", "short_ids"}).AddRow(5, "user1", "{g}"))
+	mock.ExpectQuery("WITH deleted AS").WillDelayFor(time.Second).
+		WillReturnRows(sqlmock.NewRows([]string{"short_id", "removed"}))
+	// rollback of the timed out transaction is issued by database/sql asynchronously, so it is not expected
+	mock.ExpectExec("UPDATE deletion_requests SET attempts").
+		WithArgs(arrayArg([]int64{5}), sqlmock.AnyArg(), maxDeletionAttempts).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = s.processDeletions(ctx)
+	assert.Error(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "attempt must be counted after the timeout")
+}
+
+func TestStore_ReadOperation(t *testing.T) {
+	created := time.Date(2022, 5, 1, 10, 0, 0, 0, time.UTC)
+	processed := created.Add(time.Second)
+	columns := []string{"short_ids", "created_at", "processed_at", "failed", "last_error", "not_owned", "unchanged", "invalid"}
+
+	tests := []struct {
+		name    string
+		id      string
+		row     []driver.Value
+		want    *store.Operation
+		wantErr error
+	}{
+		{
+			name: "pending",
+			id:   "1",
+			row:  []driver.Value{"{a,b}", created, nil, false, nil, nil, nil, nil},
+			want: &store.Operation{ID: "1", Status: store.OperationPending, CreatedAt: created, Items: []store.OperationItem{
+				{ID: "a", Outcome: store.OutcomePending},
+				{ID: "b", Outcome: store.OutcomePending},
+			}},
+		},
+		{
+			name: "completed",
+			id:   "2",
+			row:  []driver.Value{"{a,b,c,bad/id}", created, processed, false, nil, "{c}", "{b}", "{bad/id}"},
+			want: &store.Operation{ID: "2", Status: store.OperationCompleted, CreatedAt: created, CompletedAt: &processed, Items: []store.OperationItem{
+				{ID: "a", Outcome: store.OutcomeDeleted},
+				{ID: "b", Outcome: store.OutcomeAlreadyDeleted},
+				{ID: "c", Outcome: store.OutcomeNotFound},
+				{ID: "bad/id", Outcome: store.OutcomeInvalid},
+			}},
+		},
+		{
+			name: "failed",
+			id:   "3",
+			row:  []driver.Value{"{a}", created, processed, true, "db error", nil, nil, nil},
+			want: &store.Operation{ID: "3", Status: store.OperationFailed, CreatedAt: created, CompletedAt: &processed, Error: "db error", Items: []store.OperationItem{
+				{ID: "a", Outcome: store.OutcomeFailed},
+			}},
+		},
+		{name: "missing", id: "4", wantErr: store.ErrNotFound},
+		{name: "malformed id", id: "x", wantErr: store.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer func() {
+				_ = db.Close()
+			}()
+
+			s, err := New(db)
+			require.NoError(t, err)
+
+			if tt.id != "x" {
+				rows := sqlmock.NewRows(columns)
+				if tt.row != nil {
+					rows.AddRow(tt.row...)
+				}
+				mock.ExpectQuery("FROM deletion_requests").WithArgs(sqlmock.AnyArg(), "user1").WillReturnRows(rows)
+			}
+
+			got, err := s.ReadOperation(context.Background(), "user1", tt.id)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// arrayArg matches postgres array argument
+func arrayArg(v interface{}) sqlmock.Argument {
+	want, _ := pg.Array(v).Value()
+	return argFunc(func(got driver.Value) bool {
+		return got == want
+	})
+}
+
+type argFunc func(driver.Value) bool
+
+func (f argFunc) Match(v driver.Value) bool {
+	return f(v)
+}
@@ -0,0 +1,86 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"shortener/internal/app/service/store"
+)
+
+var _ store.Dumper = (*Store)(nil)
+var _ store.Loader = (*Store)(nil)
+
+// Dump returns rows in the order of the serial id, which is used as the key
+func (s *Store) Dump(ctx context.Context, after uint64, limit int) ([]store.DumpRow, error) {
+	const selectSQL = `
+		SELECT id, short_id, original_url, uid, created_at, updated_at, deleted_at, expires_at, version, threat, disabled_at
+		FROM urls
+		WHERE id > $1
+		ORDER BY id
+		LIMIT $2
+`
+
+	ctx, cancel := store.WithTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, selectSQL, after, limit)
+	if err != nil {
+		return nil, fmt.Errorf("dump query: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	res := make([]store.DumpRow, 0, limit)
+	for rows.Next() {
+		var (
+			r           store.DumpRow
+			uid, threat sql.NullString
+		)
+		err := rows.Scan(&r.Key, &r.ID, &r.OriginalURL, &uid, &r.CreatedAt, &r.UpdatedAt, &r.DeletedAt, &r.ExpiresAt, &r.Version, &threat, &r.DisabledAt)
+		if err != nil {
+			return nil, fmt.Errorf("dump scan: %w", err)
+		}
+		r.UID = uid.String
+		r.Threat = threat.String
+		res = append(res, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("dump rows: %w", err)
+	}
+
+	return res, nil
+}
+
+// Load inserts rows in one transaction, rows violating short id or active url uniqueness in the dedup scope are skipped
+func (s *Store) Load(ctx context.Context, rows []store.DumpRow) (int, error) {
+	const insertSQL = `
+		INSERT INTO urls (short_id, original_url, uid, created_at, updated_at, deleted_at, expires_at, version, dedup_key, threat, disabled_at)
+		VALUES ($1, $2, NULLIF($3, '')::uuid, $4, $5, $6, $7, $8, $9, NULLIF($10, ''), $11)
+		ON CONFLICT DO NOTHING
+`
+
+	ctx, cancel := store.WithTimeout(ctx, s.timeouts.Batch)
+	defer cancel()
+
+	inserted := 0
+	err := s.inTransaction(ctx, func(tx *sql.Tx) error {
+		for _, r := range rows {
+			res, err := tx.ExecContext(ctx, insertSQL, r.ID, r.OriginalURL, r.UID, r.CreatedAt, r.UpdatedAt, r.DeletedAt, r.ExpiresAt, r.Version, s.dedupKey(r.UID, r.OriginalURL), r.Threat, r.DisabledAt)
+			if err != nil {
+				return fmt.Errorf("load row %q: %w", r.ID, err)
+			}
+			n, err := res.RowsAffected()
+			if err != nil {
+				return fmt.Errorf("load row %q: %w", r.ID, err)
+			}
+			inserted += int(n)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("tx: %w", err)
+	}
+
+	return inserted, nil
+}
@@ -0,0 +1,79 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql/driver"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"shortener/internal/app/service/store"
+	"testing"
+	"time"
+)
+
+func TestStore_Dump(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() {
+		_ = db.Close()
+	}()
+
+	s, err := New(db)
+	require.NoError(t, err)
+
+	created := time.Date(2022, 5, 1, 10, 0, 0, 0, time.UTC)
+	deleted := created.Add(time.Hour)
+	columns := []string{"id", "short_id", "original_url", "uid", "created_at", "updated_at", "deleted_at", "expires_at", "version", "threat", "disabled_at"}
+	mock.ExpectQuery("SELECT id, short_id, original_url, uid").WithArgs(10, 2).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(11, "b", "https://example.org/b", "user1", created, nil, nil, nil, 1, "malware", nil).
+			AddRow(14, "e", "https://example.org/e", nil, created, nil, deleted, nil, 2, nil, deleted))
+
+	got, err := s.Dump(context.Background(), 10, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []store.DumpRow{
+		{Key: 11, ID: "b", OriginalURL: "https://example.org/b", UID: "user1", CreatedAt: created, Version: 1, Threat: "malware"},
+		{Key: 14, ID: "e", OriginalURL: "https://example.org/e", CreatedAt: created, DeletedAt: &deleted, Version: 2, DisabledAt: &deleted},
+	}, got)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStore_Load(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() {
+		_ = db.Close()
+	}()
+
+	s, err := New(db)
+	require.NoError(t, err)
+
+	created := time.Date(2022, 5, 1, 10, 0, 0, 0, time.UTC)
+	rows := []store.DumpRow{
+		{Key: 1, ID: "a", OriginalURL: "https://example.org/a", UID: "user1", CreatedAt: created, Version: 1},
+		{Key: 2, ID: "b", OriginalURL: "https://example.org/b", UID: "user1", CreatedAt: created, Version: 1},
+	}
+	args := func(r store.DumpRow) []driver.Value {
+		return []driver.Value{r.ID, r.OriginalURL, r.UID, r.CreatedAt, nil, nil, nil, r.Version, r.OriginalURL, r.Threat, nil}
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO urls").WithArgs(args(rows[0])...).WillReturnResult(sqlmock.NewResult(0, 1))
+	// conflicting row is skipped
+	mock.ExpectExec("INSERT INTO urls").WithArgs(args(rows[1])...).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	n, err := s.Load(context.Background(), rows)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO urls").WillReturnError(driver.ErrBadConn)
+	mock.ExpectRollback()
+
+	_, err = s.Load(context.Background(), rows[:1])
+	assert.ErrorIs(t, err, driver.ErrBadConn)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -1,6 +1,7 @@
 package sqlstore
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"github.com/DATA-DOG/go-sqlmock"
@@ -22,7 +23,7 @@ func Example() {
 		return
 	}
 
-	if url, err := s.WriteURL("http://somelongurl.test/foo/bar", "user1"); err != nil {
+	if url, err := s.WriteURL(context.Background(), "http://somelongurl.test/foo/bar", "user1"); err != nil {
 		fmt.Println(err)
 		return
 	} else {
@@ -42,8 +43,17 @@ func getDB() *sql.DB {
 	if err != nil {
 		log.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
 	}
-	mock.ExpectQuery("INSERT INTO").WillReturnRows(
-		sqlmock.NewRows([]string{"id"}).AddRow(1),
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT value FROM store_settings").WithArgs("dedup").WillReturnRows(
+		sqlmock.NewRows([]string{"value"}).AddRow("scope=global;canonical=none"),
+	)
+	mock.ExpectCommit()
+	mock.ExpectExec("UPDATE urls SET deleted_at").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT nextval").WillReturnRows(
+		sqlmock.NewRows([]string{"nextval"}).AddRow(1),
+	)
+	mock.ExpectQuery("INSERT INTO").WithArgs(1, "1", "user1", "http://somelongurl.test/foo/bar", nil, "http://somelongurl.test/foo/bar").WillReturnRows(
+		sqlmock.NewRows([]string{"short_id"}).AddRow("1"),
 	)
 	mock.ExpectClose()
 	return db
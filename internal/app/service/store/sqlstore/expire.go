@@ -0,0 +1,49 @@
+package sqlstore
+
+import (
+	"context"
+	"fmt"
+	"shortener/pkg/workerpool"
+	"time"
+)
+
+// purgeExpiredJob removes rows expired and deletion requests processed more than grace period ago,
+// and rows removed more than retention period ago
+func (s *Store) purgeExpiredJob() workerpool.Job {
+	const (
+		purgeSQL = `
+		DELETE FROM urls WHERE expires_at < $1
+`
+		purgeDeletedSQL = `
+		DELETE FROM urls WHERE deleted_at < $1
+`
+		purgeDeletionsSQL = `
+		DELETE FROM deletion_requests WHERE processed_at < $1
+`
+	)
+
+	return func(ctx context.Context) error {
+		deadline := time.Now().Add(-s.expiredGracePeriod)
+
+		res, err := s.db.ExecContext(ctx, purgeSQL, deadline)
+		if err != nil {
+			return fmt.Errorf("purge expired query: %w", err)
+		}
+		if n, err := res.RowsAffected(); err == nil && n > 0 {
+			s.log.Info().Int64("purged", n).Msg("Expired urls purged")
+		}
+
+		res, err = s.db.ExecContext(ctx, purgeDeletedSQL, time.Now().Add(-s.deletedRetention))
+		if err != nil {
+			return fmt.Errorf("purge deleted query: %w", err)
+		}
+		if n, err := res.RowsAffected(); err == nil && n > 0 {
+			s.log.Info().Int64("purged", n).Msg("Deleted urls purged")
+		}
+
+		if _, err := s.db.ExecContext(ctx, purgeDeletionsSQL, deadline); err != nil {
+			return fmt.Errorf("purge deletion requests query: %w", err)
+		}
+		return nil
+	}
+}
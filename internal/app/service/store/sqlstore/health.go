@@ -1,6 +1,7 @@
 package sqlstore
 
 import (
+	"context"
 	"fmt"
 	"shortener/internal/app/service/store"
 )
@@ -8,8 +9,11 @@ import (
 // store.HealthChecker interface implementation
 var _ store.HealthChecker = (*Store)(nil)
 
-func (s *Store) HealthCheck() error {
-	if err := s.db.Ping(); err != nil {
+func (s *Store) HealthCheck(ctx context.Context) error {
+	ctx, cancel := store.WithTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+
+	if err := s.db.PingContext(ctx); err != nil {
 		return fmt.Errorf("ping: %w", err)
 	}
 	return nil
@@ -1,6 +1,7 @@
 package sqlstore
 
 import (
+	"context"
 	"errors"
 	"github.com/DATA-DOG/go-sqlmock"
 	"testing"
@@ -41,7 +42,7 @@ func Test_HealthCheck(t *testing.T) {
 				mock.ExpectPing()
 			}
 
-			err := r.HealthCheck()
+			err := r.HealthCheck(context.Background())
 			if (err != nil) != tt.wantErr {
 				t.Errorf("HealthCheck() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -0,0 +1,202 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	pg "github.com/lib/pq"
+	"shortener/internal/app/service/store"
+	"time"
+)
+
+var _ store.Editor = (*Store)(nil)
+
+// versionedRow is a user row with its current destination
+type versionedRow struct {
+	id        int64
+	current   store.URLVersion
+	deletedAt pg.NullTime
+	expiresAt pg.NullTime
+}
+
+// UpdateURL archives the current destination into url_versions and sets the new one in the same transaction
+func (s *Store) UpdateURL(ctx context.Context, uid string, id string, url string) (*store.URLVersion, error) {
+	if err := store.ValidateURL(url); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := store.WithTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	var result *store.URLVersion
+	err := s.inTransaction(ctx, func(tx *sql.Tx) error {
+		row, err := s.lockActiveRow(ctx, tx, uid, id)
+		if err != nil {
+			return err
+		}
+
+		result, err = s.setURL(ctx, tx, uid, row, url)
+		return err
+	})
+	if err != nil {
+		return nil, s.writeError(ctx, s.db, err, uid, url)
+	}
+
+	return result, nil
+}
+
+func (s *Store) ReadHistory(ctx context.Context, uid string, id string) ([]store.URLVersion, error) {
+	const historySQL = `
+		SELECT version, original_url, created_at FROM url_versions
+		WHERE url_id = $1
+		ORDER BY version DESC
+`
+
+	ctx, cancel := store.WithTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+
+	row, err := s.readRow(ctx, s.db, uid, id, false)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, historySQL, row.id)
+	if err != nil {
+		return nil, fmt.Errorf("history query: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	history := []store.URLVersion{row.current}
+	for rows.Next() {
+		var v store.URLVersion
+		if err := rows.Scan(&v.Version, &v.OriginalURL, &v.CreatedAt); err != nil {
+			return nil, fmt.Errorf("history scan: %w", err)
+		}
+		history = append(history, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("history rows: %w", err)
+	}
+
+	return history, nil
+}
+
+func (s *Store) RollbackURL(ctx context.Context, uid string, id string, version int) (*store.URLVersion, error) {
+	const versionSQL = `
+		SELECT original_url FROM url_versions WHERE url_id = $1 AND version = $2
+`
+
+	ctx, cancel := store.WithTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	var (
+		result *store.URLVersion
+		url    string
+	)
+	err := s.inTransaction(ctx, func(tx *sql.Tx) error {
+		row, err := s.lockActiveRow(ctx, tx, uid, id)
+		if err != nil {
+			return err
+		}
+
+		if version == row.current.Version {
+			result = &row.current
+			return nil
+		}
+
+		err = tx.QueryRowContext(ctx, versionSQL, row.id, version).Scan(&url)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: %d", store.ErrVersionNotFound, version)
+		}
+		if err != nil {
+			return fmt.Errorf("version query: %w", err)
+		}
+
+		result, err = s.setURL(ctx, tx, uid, row, url)
+		return err
+	})
+	if err != nil {
+		return nil, s.writeError(ctx, s.db, err, uid, url)
+	}
+
+	return result, nil
+}
+
+// readRow returns row of the user, store.ErrNotFound is returned for the rows of other users
+func (s *Store) readRow(ctx context.Context, q queryer, uid string, id string, lock bool) (*versionedRow, error) {
+	const readSQL = `
+		SELECT id, original_url, version, COALESCE(updated_at, created_at), deleted_at, expires_at
+		FROM urls
+		WHERE short_id = $1 AND uid = $2
+`
+
+	query := readSQL
+	if lock {
+		query += "		FOR UPDATE\n"
+	}
+
+	row := &versionedRow{}
+	err := q.QueryRowContext(ctx, query, id, uid).Scan(
+		&row.id, &row.current.OriginalURL, &row.current.Version, &row.current.CreatedAt, &row.deletedAt, &row.expiresAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, store.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read row query: %w", err)
+	}
+
+	return row, nil
+}
+
+// lockActiveRow returns working row of the user locked till the end of the transaction
+func (s *Store) lockActiveRow(ctx context.Context, tx *sql.Tx, uid string, id string) (*versionedRow, error) {
+	row, err := s.readRow(ctx, tx, uid, id, true)
+	if err != nil {
+		return nil, err
+	}
+	if row.deletedAt.Valid {
+		return nil, store.ErrDeleted
+	}
+	if store.Expired(nullTime(row.expiresAt), time.Now()) {
+		return nil, store.ErrExpired
+	}
+	return row, nil
+}
+
+// setURL archives the current destination of the locked row of the user and sets the new one
+func (s *Store) setURL(ctx context.Context, tx *sql.Tx, uid string, row *versionedRow, url string) (*store.URLVersion, error) {
+	const (
+		archiveSQL = `
+		INSERT INTO url_versions (url_id, version, original_url, created_at) VALUES ($1, $2, $3, $4)
+`
+		updateSQL = `
+		UPDATE urls SET original_url = $2, dedup_key = $3, version = version + 1, updated_at = NOW(), threat = NULL
+		WHERE id = $1
+		RETURNING version, updated_at
+`
+	)
+
+	if row.current.OriginalURL == url {
+		return &row.current, nil
+	}
+
+	if err := s.releaseExpired(ctx, tx, uid, url); err != nil {
+		return nil, err
+	}
+
+	_, err := tx.ExecContext(ctx, archiveSQL, row.id, row.current.Version, row.current.OriginalURL, row.current.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("archive version query: %w", err)
+	}
+
+	v := &store.URLVersion{OriginalURL: url}
+	if err := tx.QueryRowContext(ctx, updateSQL, row.id, url, s.dedupKey(uid, url)).Scan(&v.Version, &v.CreatedAt); err != nil {
+		return nil, fmt.Errorf("update url query: %w", err)
+	}
+
+	return v, nil
+}
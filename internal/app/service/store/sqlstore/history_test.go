@@ -0,0 +1,51 @@
+package sqlstore
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"shortener/internal/app/service/store"
+	"testing"
+	"time"
+)
+
+func TestStore_UpdateURL(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() {
+		_ = db.Close()
+	}()
+
+	s, err := New(db)
+	require.NoError(t, err)
+
+	created := time.Date(2022, 5, 1, 10, 0, 0, 0, time.UTC)
+	updated := created.Add(time.Hour)
+	columns := []string{"id", "original_url", "version", "created_at", "deleted_at", "expires_at"}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, original_url, version").WithArgs("abc", "user1").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(7, "https://example.org/a", 1, created, nil, nil))
+	mock.ExpectExec("UPDATE urls SET deleted_at").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO url_versions").WithArgs(7, 1, "https://example.org/a", created).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("UPDATE urls SET original_url").WithArgs(7, "https://example.org/b", "https://example.org/b").
+		WillReturnRows(sqlmock.NewRows([]string{"version", "updated_at"}).AddRow(2, updated))
+	mock.ExpectCommit()
+
+	got, err := s.UpdateURL(context.Background(), "user1", "abc", "https://example.org/b")
+	require.NoError(t, err)
+	assert.Equal(t, &store.URLVersion{Version: 2, OriginalURL: "https://example.org/b", CreatedAt: updated}, got)
+
+	// removed link is not editable
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, original_url, version").WithArgs("abc", "user1").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(7, "https://example.org/b", 2, updated, updated, nil))
+	mock.ExpectRollback()
+
+	_, err = s.UpdateURL(context.Background(), "user1", "abc", "https://example.org/c")
+	assert.ErrorIs(t, err, store.ErrDeleted)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
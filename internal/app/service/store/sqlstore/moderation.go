@@ -0,0 +1,140 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"shortener/internal/app/service/store"
+)
+
+var _ store.Moderator = (*Store)(nil)
+
+func (s *Store) Report(ctx context.Context, r store.AbuseReport) (*store.AbuseReport, error) {
+	const insertSQL = `
+		INSERT INTO reports (url_id, reason, comment, reporter)
+		SELECT id, $2, $3, $4 FROM urls WHERE short_id = $1
+		RETURNING id, created_at
+`
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := store.WithTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, insertSQL, r.LinkID, r.Reason, r.Comment, r.Reporter).Scan(&r.ID, &r.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, store.ErrNotFound
+		}
+		return nil, fmt.Errorf("report query: %w", err)
+	}
+
+	return &r, nil
+}
+
+func (s *Store) ReadReports(ctx context.Context, q store.ReportQuery) ([]store.AbuseReport, error) {
+	const selectSQL = `
+		SELECT r.id, u.short_id, r.reason, r.comment, r.reporter, r.created_at
+		FROM reports r JOIN urls u ON u.id = r.url_id
+		WHERE r.resolved_at IS NULL AND r.id > $1 AND ($2 = '' OR u.short_id = $2)
+		ORDER BY r.id
+		LIMIT $3
+`
+	if err := q.Normalize(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := store.WithTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, selectSQL, q.After, q.LinkID, q.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("reports query: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	res := make([]store.AbuseReport, 0, q.Limit)
+	for rows.Next() {
+		var r store.AbuseReport
+		if err := rows.Scan(&r.ID, &r.LinkID, &r.Reason, &r.Comment, &r.Reporter, &r.CreatedAt); err != nil {
+			return nil, fmt.Errorf("reports scan: %w", err)
+		}
+		res = append(res, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("reports rows: %w", err)
+	}
+
+	return res, nil
+}
+
+// SetDisabled updates the link and resolves its open reports in one transaction.
+// Time of the disabled link is kept if it is disabled again.
+func (s *Store) SetDisabled(ctx context.Context, id string, disabled bool) error {
+	const (
+		updateSQL = `
+		UPDATE urls SET disabled_at = CASE WHEN $2 THEN COALESCE(disabled_at, NOW()) END
+		WHERE short_id = $1
+		RETURNING id
+`
+		resolveSQL = `
+		UPDATE reports SET resolved_at = NOW() WHERE url_id = $1 AND resolved_at IS NULL
+`
+	)
+	if id == "" {
+		return fmt.Errorf("empty id: %w", store.ErrBadInput)
+	}
+
+	ctx, cancel := store.WithTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	err := s.inTransaction(ctx, func(tx *sql.Tx) error {
+		var rowID int64
+		if err := tx.QueryRowContext(ctx, updateSQL, id, disabled).Scan(&rowID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return store.ErrNotFound
+			}
+			return fmt.Errorf("update url: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, resolveSQL, rowID); err != nil {
+			return fmt.Errorf("resolve reports: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("tx: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Store) SetBanned(ctx context.Context, uid string, banned bool) error {
+	const (
+		insertSQL = `
+		INSERT INTO banned_users (uid) VALUES ($1) ON CONFLICT DO NOTHING
+`
+		deleteSQL = `
+		DELETE FROM banned_users WHERE uid = $1
+`
+	)
+	if uid == "" {
+		return fmt.Errorf("empty uid: %w", store.ErrBadInput)
+	}
+
+	ctx, cancel := store.WithTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	query := deleteSQL
+	if banned {
+		query = insertSQL
+	}
+	if _, err := s.db.ExecContext(ctx, query, uid); err != nil {
+		return fmt.Errorf("ban query: %w", err)
+	}
+
+	return nil
+}
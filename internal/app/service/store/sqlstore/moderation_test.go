@@ -0,0 +1,109 @@
+package sqlstore
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"shortener/internal/app/service/store"
+	"testing"
+	"time"
+)
+
+func TestStore_Report(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() {
+		_ = db.Close()
+	}()
+
+	s, err := New(db)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	created := time.Date(2022, 5, 9, 10, 0, 0, 0, time.UTC)
+	r := store.AbuseReport{LinkID: "a", Reason: store.ReasonSpam, Comment: "ads", Reporter: "10.0.0.1"}
+
+	_, err = s.Report(ctx, store.AbuseReport{LinkID: "a", Reason: "boring"})
+	assert.ErrorIs(t, err, store.ErrBadInput)
+
+	mock.ExpectQuery("INSERT INTO reports").WithArgs("a", "spam", "ads", "10.0.0.1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(7, created))
+	got, err := s.Report(ctx, r)
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), got.ID)
+	assert.Equal(t, created, got.CreatedAt)
+
+	mock.ExpectQuery("INSERT INTO reports").WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}))
+	_, err = s.Report(ctx, r)
+	assert.ErrorIs(t, err, store.ErrNotFound)
+
+	mock.ExpectQuery("SELECT r.id, u.short_id").WithArgs(7, "", store.DefaultPageLimit).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "short_id", "reason", "comment", "reporter", "created_at"}).
+			AddRow(8, "b", "phishing", "", "10.0.0.2", created))
+	reports, err := s.ReadReports(ctx, store.ReportQuery{After: 7})
+	require.NoError(t, err)
+	assert.Equal(t, []store.AbuseReport{
+		{ID: 8, LinkID: "b", Reason: store.ReasonPhishing, Reporter: "10.0.0.2", CreatedAt: created},
+	}, reports)
+
+	_, err = s.ReadReports(ctx, store.ReportQuery{Limit: store.MaxPageLimit + 1})
+	assert.ErrorIs(t, err, store.ErrBadInput)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStore_SetDisabled(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() {
+		_ = db.Close()
+	}()
+
+	s, err := New(db)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("UPDATE urls SET disabled_at").WithArgs("a", true).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(11))
+	mock.ExpectExec("UPDATE reports SET resolved_at").WithArgs(11).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+	require.NoError(t, s.SetDisabled(ctx, "a", true))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("UPDATE urls SET disabled_at").WithArgs("b", false).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+	assert.ErrorIs(t, s.SetDisabled(ctx, "b", false), store.ErrNotFound)
+
+	assert.ErrorIs(t, s.SetDisabled(ctx, "", true), store.ErrBadInput)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStore_SetBanned(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() {
+		_ = db.Close()
+	}()
+
+	s, err := New(db)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	uid := "8a1ab1d4-7a48-4bd0-9e07-1b3f4f1c2a11"
+
+	mock.ExpectExec("INSERT INTO banned_users").WithArgs(uid).WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, s.SetBanned(ctx, uid, true))
+
+	errDB := errors.New("db is down")
+	mock.ExpectExec("DELETE FROM banned_users").WithArgs(uid).WillReturnError(errDB)
+	assert.ErrorIs(t, s.SetBanned(ctx, uid, false), errDB)
+
+	assert.ErrorIs(t, s.SetBanned(ctx, "", true), store.ErrBadInput)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
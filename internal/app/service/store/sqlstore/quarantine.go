@@ -0,0 +1,44 @@
+package sqlstore
+
+import (
+	"context"
+	"fmt"
+	pg "github.com/lib/pq"
+	"shortener/internal/app/service/store"
+)
+
+var _ store.Quarantiner = (*Store)(nil)
+
+// Quarantine sets threats of the rows by one query, rows with the changed destinations are skipped
+func (s *Store) Quarantine(ctx context.Context, items ...store.QuarantineItem) (int, error) {
+	const updateSQL = `
+		UPDATE urls SET threat = NULLIF(q.threat, '')
+		FROM unnest($1::text[], $2::text[], $3::text[]) AS q(short_id, original_url, threat)
+		WHERE urls.short_id = q.short_id AND urls.original_url = q.original_url
+		  AND urls.threat IS DISTINCT FROM NULLIF(q.threat, '')
+`
+
+	if len(items) == 0 {
+		return 0, nil
+	}
+
+	ctx, cancel := store.WithTimeout(ctx, s.timeouts.Batch)
+	defer cancel()
+
+	ids := make([]string, len(items))
+	urls := make([]string, len(items))
+	threats := make([]string, len(items))
+	for i, item := range items {
+		ids[i], urls[i], threats[i] = item.ID, item.OriginalURL, item.Threat
+	}
+
+	res, err := s.db.ExecContext(ctx, updateSQL, pg.Array(ids), pg.Array(urls), pg.Array(threats))
+	if err != nil {
+		return 0, fmt.Errorf("quarantine query: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("quarantine query: %w", err)
+	}
+	return int(n), nil
+}
@@ -0,0 +1,49 @@
+package sqlstore
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"shortener/internal/app/service/store"
+	"testing"
+)
+
+func TestStore_Quarantine(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() {
+		_ = db.Close()
+	}()
+
+	s, err := New(db)
+	require.NoError(t, err)
+
+	n, err := s.Quarantine(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, n, "empty input must not be queried")
+
+	items := []store.QuarantineItem{
+		{ID: "a", OriginalURL: "https://example.org/a", Threat: "malware"},
+		{ID: "b", OriginalURL: "https://example.org/b"},
+	}
+	mock.ExpectExec(`UPDATE urls SET threat = NULLIF\(q.threat, ''\)`).
+		WithArgs(
+			arrayArg([]string{"a", "b"}),
+			arrayArg([]string{"https://example.org/a", "https://example.org/b"}),
+			arrayArg([]string{"malware", ""}),
+		).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	n, err = s.Quarantine(context.Background(), items...)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	errDB := errors.New("db is down")
+	mock.ExpectExec("UPDATE urls SET threat").WillReturnError(errDB)
+	_, err = s.Quarantine(context.Background(), items...)
+	assert.ErrorIs(t, err, errDB)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
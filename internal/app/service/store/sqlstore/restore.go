@@ -0,0 +1,144 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	pg "github.com/lib/pq"
+	"shortener/internal/app/service/store"
+	"time"
+)
+
+var _ store.Restorer = (*Store)(nil)
+
+// Restore user rows removed within the retention window. Rows are locked while checked,
+// the url conflict is checked again by the update, so concurrently shortened urls are not restored.
+func (s *Store) Restore(ctx context.Context, uid string, ids ...string) ([]store.OperationItem, error) {
+	const (
+		selectSQL = `
+		SELECT short_id, original_url, deleted_at FROM urls
+		WHERE uid = $1 AND short_id = ANY($2)
+		FOR UPDATE
+`
+		restoreSQL = `
+		UPDATE urls SET deleted_at = NULL
+		WHERE uid = $1 AND short_id = ANY($2) AND NOT EXISTS (
+			SELECT 1 FROM urls active WHERE active.dedup_key = urls.dedup_key AND active.deleted_at IS NULL
+		)
+		RETURNING short_id
+`
+	)
+
+	type row struct {
+		url       string
+		deletedAt pg.NullTime
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(ids) == 0 {
+		return nil, store.ErrEmptyIDs
+	}
+
+	var valid []string
+	for _, id := range ids {
+		if store.ValidateAlias(id) == nil {
+			valid = append(valid, id)
+		}
+	}
+
+	ctx, cancel := store.WithTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	outcomes := make(map[string]store.Outcome, len(ids))
+	err := s.inTransaction(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, selectSQL, uid, pg.Array(valid))
+		if err != nil {
+			return fmt.Errorf("select query: %w", err)
+		}
+
+		found := make(map[string]row)
+		for rows.Next() {
+			var (
+				id string
+				r  row
+			)
+			if err := rows.Scan(&id, &r.url, &r.deletedAt); err != nil {
+				_ = rows.Close()
+				return fmt.Errorf("select scan: %w", err)
+			}
+			found[id] = r
+		}
+		if err := rows.Close(); err != nil {
+			return fmt.Errorf("select rows: %w", err)
+		}
+
+		retained := time.Now().Add(-s.deletedRetention)
+		// dedup keys to be restored mapped to the short ids, so the same url is not restored twice
+		candidates := make(map[string]string)
+		var restore []string
+		for _, id := range valid {
+			r, ok := found[id]
+			switch {
+			case !ok:
+				outcomes[id] = store.OutcomeNotFound
+			case !r.deletedAt.Valid:
+				outcomes[id] = store.OutcomeNotDeleted
+			case r.deletedAt.Time.Before(retained):
+				outcomes[id] = store.OutcomeNotFound
+			default:
+				key, dedup := s.dedup.Key(uid, r.url)
+				if candidate, ok := candidates[key]; dedup && ok {
+					if candidate != id {
+						outcomes[id] = store.OutcomeConflict
+					}
+					continue
+				}
+				if dedup {
+					candidates[key] = id
+				}
+				restore = append(restore, id)
+				// the url was shortened again unless the update restores the row
+				outcomes[id] = store.OutcomeConflict
+			}
+		}
+
+		if len(restore) == 0 {
+			return nil
+		}
+
+		rows, err = tx.QueryContext(ctx, restoreSQL, uid, pg.Array(restore))
+		if err != nil {
+			return fmt.Errorf("restore query: %w", err)
+		}
+		for rows.Next() {
+			var id string
+			if err := rows.Scan(&id); err != nil {
+				_ = rows.Close()
+				return fmt.Errorf("restore scan: %w", err)
+			}
+			outcomes[id] = store.OutcomeRestored
+		}
+		if err := rows.Close(); err != nil {
+			return fmt.Errorf("restore rows: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("restore: %w", err)
+	}
+
+	items := make([]store.OperationItem, len(ids))
+	for i, id := range ids {
+		outcome, ok := outcomes[id]
+		if !ok {
+			outcome = store.OutcomeInvalid
+		}
+		items[i] = store.OperationItem{ID: id, Outcome: outcome}
+	}
+
+	return items, nil
+}
@@ -0,0 +1,54 @@
+package sqlstore
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"shortener/internal/app/service/store"
+	"testing"
+	"time"
+)
+
+func TestStore_Restore(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() {
+		_ = db.Close()
+	}()
+
+	s, err := New(db, WithDeletedRetention(time.Hour))
+	require.NoError(t, err)
+
+	recently := time.Now().Add(-time.Minute)
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT short_id, original_url, deleted_at FROM urls").
+		WithArgs("user1", arrayArg([]string{"a", "b", "c", "d", "e", "f"})).
+		WillReturnRows(sqlmock.NewRows([]string{"short_id", "original_url", "deleted_at"}).
+			AddRow("a", "https://example.org/a", recently).
+			AddRow("b", "https://example.org/b", recently).
+			AddRow("c", "https://example.org/c", nil).
+			AddRow("d", "https://example.org/d", time.Now().Add(-2*time.Hour)).
+			AddRow("e", "https://example.org/a", recently))
+	// b original url was shortened again, so the update skips it
+	mock.ExpectQuery("UPDATE urls SET deleted_at = NULL").
+		WithArgs("user1", arrayArg([]string{"a", "b"})).
+		WillReturnRows(sqlmock.NewRows([]string{"short_id"}).AddRow("a"))
+	mock.ExpectCommit()
+
+	got, err := s.Restore(context.Background(), "user1", "a", "b", "c", "d", "e", "f", "bad/id")
+	require.NoError(t, err)
+	assert.Equal(t, []store.OperationItem{
+		{ID: "a", Outcome: store.OutcomeRestored},
+		{ID: "b", Outcome: store.OutcomeConflict},
+		{ID: "c", Outcome: store.OutcomeNotDeleted},
+		{ID: "d", Outcome: store.OutcomeNotFound},
+		{ID: "e", Outcome: store.OutcomeConflict},
+		{ID: "f", Outcome: store.OutcomeNotFound},
+		{ID: "bad/id", Outcome: store.OutcomeInvalid},
+	}, got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	_, err = s.Restore(context.Background(), "user1")
+	assert.ErrorIs(t, err, store.ErrBadInput)
+}
@@ -1,33 +1,68 @@
 package sqlstore
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"runtime"
 	"shortener/internal/app/logger"
+	"shortener/internal/app/service/store"
 	"shortener/pkg/workerpool"
+	"sync"
+	"time"
 )
 
+// store.Backend interface implementation
+var _ store.Backend = (*Store)(nil)
+
 type Store struct {
-	baseURL string
-	base    int
-	db      *sql.DB
-	log     logger.Logger
+	baseURL  string
+	db       *sql.DB
+	log      logger.Logger
+	timeouts store.Timeouts
+	idGen    store.IDGenerator
 
 	wp *workerpool.Pool
+
+	sweepInterval        time.Duration
+	expiredGracePeriod   time.Duration
+	deletedRetention     time.Duration
+	deletionPollInterval time.Duration
+	deletionWindow       time.Duration
+	// dedup defines which active rows are duplicates, it is enforced by the dedup_key unique index
+	dedup store.Dedup
+	// deletionWake signals the deletion requests processor about the new request
+	deletionWake chan struct{}
+
+	bgDone chan struct{}
+	bgWG   sync.WaitGroup
 }
 
+const (
+	// defaultBase of the sequential short ids
+	defaultBase          = 36
+	defaultSweepInterval = time.Minute
+	defaultGracePeriod   = time.Hour * 24
+	defaultRetention     = time.Hour * 24 * 30
+	defaultDeletionPoll  = time.Second
+	// defaultDeletionWindow is a time new deletion requests are collected for to be processed together
+	defaultDeletionWindow = time.Millisecond * 100
+)
+
 // New constructor
 func New(db *sql.DB, opts ...Option) (*Store, error) {
-	const (
-		defaultBase = 36
-	)
-
 	s := &Store{
-		base: defaultBase,
-		db:   db,
-		wp:   workerpool.New(),
-		log:  logger.Global().Component("Store"),
+		db:    db,
+		wp:    workerpool.New(),
+		log:   logger.Global().Component("Store"),
+		idGen: store.NewSequentialGenerator(defaultBase),
+
+		sweepInterval:        defaultSweepInterval,
+		expiredGracePeriod:   defaultGracePeriod,
+		deletedRetention:     defaultRetention,
+		deletionPollInterval: defaultDeletionPoll,
+		deletionWindow:       defaultDeletionWindow,
+		deletionWake:         make(chan struct{}, 1),
 	}
 
 	for _, opt := range opts {
@@ -45,16 +80,84 @@ func WithBaseURL(url string) Option {
 	}
 }
 
-// Start db connection
+func WithTimeouts(t store.Timeouts) Option {
+	return func(s *Store) {
+		s.timeouts = t
+	}
+}
+
+// WithIDGenerator sets short id generation strategy, sequential base36 ids are generated by default
+func WithIDGenerator(g store.IDGenerator) Option {
+	return func(s *Store) {
+		s.idGen = g
+	}
+}
+
+// WithSweepInterval sets how often expired rows are purged, zero disables purging
+func WithSweepInterval(d time.Duration) Option {
+	return func(s *Store) {
+		s.sweepInterval = d
+	}
+}
+
+// WithExpiredGracePeriod sets how long expired rows are kept before they are purged
+func WithExpiredGracePeriod(d time.Duration) Option {
+	return func(s *Store) {
+		s.expiredGracePeriod = d
+	}
+}
+
+// WithDeletedRetention sets how long removed rows can be restored before they are purged
+func WithDeletedRetention(d time.Duration) Option {
+	return func(s *Store) {
+		s.deletedRetention = d
+	}
+}
+
+// WithDedupScope sets among which rows active urls must be unique, urls are unique across all users by default
+func WithDedupScope(v store.DedupScope) Option {
+	return func(s *Store) {
+		s.dedup.Scope = v
+	}
+}
+
+// WithCanonicalizer sets conversion of the urls into the canonical form used to detect duplicates
+func WithCanonicalizer(c *store.Canonicalizer) Option {
+	return func(s *Store) {
+		s.dedup.Canonicalizer = c
+	}
+}
+
+// WithDeletionPollInterval sets how often pending deletion requests are checked, zero disables processing
+func WithDeletionPollInterval(d time.Duration) Option {
+	return func(s *Store) {
+		s.deletionPollInterval = d
+	}
+}
+
+// WithDeletionWindow sets how long the processor waits for more deletion requests after the new one,
+// so ids of the requests arrived within the window are removed together
+func WithDeletionWindow(d time.Duration) Option {
+	return func(s *Store) {
+		s.deletionWindow = d
+	}
+}
+
+// Start db connection. Dedup keys of the existing rows are updated if the dedup scope is changed.
 func (s *Store) Start() error {
+	if err := s.syncDedupKeys(context.Background()); err != nil {
+		return err
+	}
 
 	s.wp.Start(runtime.GOMAXPROCS(0) * 2)
+	s.startBackground()
 
 	return nil
 }
 
 // Stop store db connection
 func (s *Store) Stop() error {
+	s.stopBackground()
 	s.wp.Stop()
 	if err := s.db.Close(); err != nil {
 		return fmt.Errorf("db close: %w", err)
@@ -1,6 +1,7 @@
 package sqlstore
 
 import (
+	"context"
 	"fmt"
 	"shortener/internal/app/service/store"
 )
@@ -8,19 +9,22 @@ import (
 // store.StatProvider interface implementation
 var _ store.StatProvider = (*Store)(nil)
 
-func (s *Store) Stat() (*store.StatData, error) {
+func (s *Store) Stat(ctx context.Context) (*store.StatData, error) {
 	const (
 		userSQL = `SELECT COUNT(distinct uid) FROM urls`
 		urlSQL  = `SELECT COUNT(*) FROM urls`
 	)
 
+	ctx, cancel := store.WithTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+
 	res := &store.StatData{}
 
-	if err := s.db.QueryRow(userSQL).Scan(&res.UserCount); err != nil {
+	if err := s.db.QueryRowContext(ctx, userSQL).Scan(&res.UserCount); err != nil {
 		return nil, fmt.Errorf("user count query: %w", err)
 	}
 
-	if err := s.db.QueryRow(urlSQL).Scan(&res.URLCount); err != nil {
+	if err := s.db.QueryRowContext(ctx, urlSQL).Scan(&res.URLCount); err != nil {
 		return nil, fmt.Errorf("url count query: %w", err)
 	}
 
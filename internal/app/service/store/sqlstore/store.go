@@ -1,30 +1,48 @@
 package sqlstore
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"github.com/jackc/pgerrcode"
 	pg "github.com/lib/pq"
 	"shortener/internal/app/service/store"
-	"strconv"
+	"strings"
+	"time"
 )
 
 // store.Store interface implementation
 var _ store.Store = (*Store)(nil)
 
-func (s *Store) ReadURL(id string) (string, error) {
+// queryer is implemented by both *sql.DB and *sql.Tx
+type queryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+func (s *Store) ReadURL(ctx context.Context, id string) (string, error) {
 	const readSQL = `
-		SELECT original_url, deleted_at FROM urls WHERE id=$1
+		SELECT u.original_url, u.deleted_at, u.expires_at, u.threat, u.disabled_at IS NOT NULL OR b.uid IS NOT NULL
+		FROM urls u LEFT JOIN banned_users b ON b.uid = u.uid
+		WHERE u.short_id=$1
 `
-	rawID, err := s.idToInt64(id)
-	if err != nil {
-		return "", fmt.Errorf("invalid id %q: %w", id, store.ErrBadInput)
+	if id == "" {
+		return "", fmt.Errorf("empty id: %w", store.ErrBadInput)
 	}
 
+	ctx, cancel := store.WithTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+
 	var url string
-	var deletedAt pg.NullTime
-	err = s.db.QueryRow(readSQL, rawID).Scan(&url, &deletedAt)
+	var deletedAt, expiresAt pg.NullTime
+	var threat sql.NullString
+	var disabled bool
+	err := s.db.QueryRowContext(ctx, readSQL, id).Scan(&url, &deletedAt, &expiresAt, &threat, &disabled)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", store.ErrNotFound
+		}
 		return "", fmt.Errorf("read url query: %w", err)
 	}
 
@@ -32,89 +50,278 @@ func (s *Store) ReadURL(id string) (string, error) {
 		return "", store.ErrDeleted
 	}
 
+	if expiresAt.Valid && !expiresAt.Time.After(time.Now()) {
+		return "", store.ErrExpired
+	}
+
+	if disabled {
+		return "", store.ErrDisabled
+	}
+
+	if threat.String != "" {
+		return "", &store.QuarantineError{OriginalURL: url, Threat: threat.String}
+	}
+
 	return url, err
 }
 
-func (s *Store) WriteURL(url string, uid string) (string, error) {
-	const writeSQL = `
-		INSERT INTO urls (uid, original_url)
-		VALUES ($1, $2)
-		RETURNING id
-`
-
+func (s *Store) WriteURL(ctx context.Context, url string, uid string, opts ...store.WriteOption) (string, error) {
 	if err := store.ValidateURL(url); err != nil {
 		return "", err
 	}
 
-	var rawID int64
-	err := s.db.QueryRow(writeSQL, uid, url).Scan(&rawID)
+	o := store.NewWriteOptions(opts...)
+	if err := store.ValidateExpiration(o.ExpiresAt, time.Now()); err != nil {
+		return "", err
+	}
+
+	ctx, cancel := store.WithTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	if err := s.releaseExpired(ctx, s.db, uid, url); err != nil {
+		return "", err
+	}
+
+	id, err := s.insertGenerated(ctx, s.db, uid, store.Record{OriginalURL: url, ExpiresAt: o.ExpiresAt})
 	if err != nil {
-		var pgErr *pg.Error
-		if errors.As(err, &pgErr) {
-			if pgerrcode.IsIntegrityConstraintViolation(string(pgErr.Code)) {
-				qErr := s.db.QueryRow(`SELECT id FROM urls WHERE original_url = $1`, url).Scan(&rawID)
-				if qErr != nil {
-					return "", fmt.Errorf("query conflicting id: %w", qErr)
-				}
-				return "", &store.ConflictError{
-					ExistingURL: s.shortURL(s.idFromInt64(rawID)),
-				}
-			}
-		}
+		return "", s.writeError(ctx, s.db, err, uid, url)
+	}
 
-		return "", fmt.Errorf("write url query: %w", err)
+	return s.shortURL(id), nil
+}
+
+func (s *Store) WriteAlias(ctx context.Context, url string, alias string, uid string, opts ...store.WriteOption) (string, error) {
+	if err := store.ValidateURL(url); err != nil {
+		return "", err
+	}
+	if err := store.ValidateAlias(alias); err != nil {
+		return "", err
+	}
+
+	o := store.NewWriteOptions(opts...)
+	if err := store.ValidateExpiration(o.ExpiresAt, time.Now()); err != nil {
+		return "", err
+	}
+
+	ctx, cancel := store.WithTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	if err := s.releaseExpired(ctx, s.db, uid, url); err != nil {
+		return "", err
+	}
+
+	id, err := s.insertAlias(ctx, s.db, uid, store.Record{ID: alias, OriginalURL: url, ExpiresAt: o.ExpiresAt})
+	if err != nil {
+		return "", s.writeError(ctx, s.db, err, uid, url)
 	}
 
-	return s.shortURL(s.idFromInt64(rawID)), nil
+	return s.shortURL(id), nil
 }
 
-func (s *Store) ReadUserData(uid string) []store.Record {
-	const readAllSQL = `
-		SELECT id, original_url FROM urls WHERE uid=$1 AND deleted_at IS NULL
-`
+// ReadUserData selects a page with the keyset condition on the sort key, so deep pages are as cheap as the first one
+func (s *Store) ReadUserData(ctx context.Context, uid string, q store.UserDataQuery) (*store.UserDataPage, error) {
+	cursor, err := q.Normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	query, args := userDataQuery(uid, q, cursor)
 
-	var result []store.Record
+	ctx, cancel := store.WithTimeout(ctx, s.timeouts.Read)
+	defer cancel()
 
-	rows, err := s.db.Query(readAllSQL, uid)
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return result
+		return nil, fmt.Errorf("user data query: %w", err)
 	}
 	defer func() {
 		_ = rows.Close()
 	}()
 
+	page := &store.UserDataPage{}
+	var last store.SortKey
 	for rows.Next() {
 		var (
-			rawID       int64
-			originalURL string
+			rec       store.Record
+			rowID     int64
+			expiresAt pg.NullTime
 		)
-		if err := rows.Scan(&rawID, &originalURL); err != nil {
-			s.log.Error().Err(err).Msg("Scan failed")
+		if err := rows.Scan(&rowID, &rec.ID, &rec.OriginalURL, &rec.CreatedAt, &expiresAt); err != nil {
+			return nil, fmt.Errorf("user data scan: %w", err)
+		}
+		if len(page.Records) == q.Limit {
+			page.NextCursor = store.NewCursor(q.Sort, last).Encode()
 			break
 		}
-		id := s.idFromInt64(rawID)
-		result = append(result, store.Record{
-			ID:          id,
-			OriginalURL: originalURL,
-			ShortURL:    s.shortURL(id),
-		})
+		rec.ShortURL = s.shortURL(rec.ID)
+		rec.ExpiresAt = nullTime(expiresAt)
+		page.Records = append(page.Records, rec)
+		last = store.SortKey{CreatedAt: rec.CreatedAt, URL: rec.OriginalURL, RowID: uint64(rowID)}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("user data rows: %w", err)
 	}
 
-	return result
+	return page, nil
 }
 
-// idFromInt64 converts sql id to short string id
-func (s *Store) idFromInt64(id int64) string {
-	return strconv.FormatInt(id, s.base)
+// userDataQuery builds user links page query, one extra row is selected to detect the next page
+func userDataQuery(uid string, q store.UserDataQuery, cursor *store.Cursor) (string, []interface{}) {
+	var sb strings.Builder
+	args := []interface{}{uid}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	sb.WriteString(`
+		SELECT id, short_id, original_url, created_at, expires_at FROM urls
+		WHERE uid=$1 AND deleted_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())`)
+
+	if q.CreatedFrom != nil {
+		sb.WriteString(" AND created_at >= " + arg(*q.CreatedFrom))
+	}
+	if q.CreatedTo != nil {
+		sb.WriteString(" AND created_at < " + arg(*q.CreatedTo))
+	}
+	if q.URLContains != "" {
+		sb.WriteString(" AND strpos(original_url, " + arg(q.URLContains) + ") > 0")
+	}
+
+	column, dir, cmp := "created_at", "ASC", ">"
+	if q.Sort.ByURL() {
+		// byte order, so the pages do not depend on the database locale
+		column = `original_url COLLATE "C"`
+	}
+	if q.Sort.Desc() {
+		dir, cmp = "DESC", "<"
+	}
+
+	if cursor != nil {
+		var key interface{} = cursor.Key.CreatedAt
+		if q.Sort.ByURL() {
+			key = cursor.Key.URL
+		}
+		sb.WriteString(fmt.Sprintf(" AND (%s, id) %s (%s, %s)", column, cmp, arg(key), arg(int64(cursor.Key.RowID))))
+	}
+
+	sb.WriteString(fmt.Sprintf("\n\t\tORDER BY %s %s, id %s\n\t\tLIMIT %s\n", column, dir, dir, arg(q.Limit+1)))
+
+	return sb.String(), args
+}
+
+// insertGenerated inserts record with the generated short id, reserved ids and ids already taken by aliases are skipped
+func (s *Store) insertGenerated(ctx context.Context, q queryer, uid string, rec store.Record) (string, error) {
+	const (
+		nextSQL = `
+		SELECT nextval(pg_get_serial_sequence('urls', 'id'))
+`
+		insertSQL = `
+		INSERT INTO urls (id, short_id, uid, original_url, expires_at, dedup_key)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (short_id) DO NOTHING
+		RETURNING short_id
+`
+	)
+
+	for i := 0; i < store.MaxIDAttempts; i++ {
+		var seq uint64
+		if err := q.QueryRowContext(ctx, nextSQL).Scan(&seq); err != nil {
+			return "", fmt.Errorf("next id query: %w", err)
+		}
+
+		id, err := s.idGen.Generate(seq)
+		if err != nil {
+			return "", fmt.Errorf("generate id: %w", err)
+		}
+		if store.Reserved(id) {
+			continue
+		}
+
+		err = q.QueryRowContext(ctx, insertSQL, seq, id, uid, rec.OriginalURL, rec.ExpiresAt, s.dedupKey(uid, rec.OriginalURL)).Scan(&id)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("write url query: %w", err)
+		}
+		return id, nil
+	}
+
+	return "", store.ErrIDExhausted
 }
 
-// idToInt64 converts short string id to sql id
-func (s *Store) idToInt64(id string) (int64, error) {
-	rawID, err := strconv.ParseInt(id, s.base, 64)
+// insertAlias inserts record with the custom short id taken from the record
+func (s *Store) insertAlias(ctx context.Context, q queryer, uid string, rec store.Record) (string, error) {
+	const insertSQL = `
+		INSERT INTO urls (short_id, uid, original_url, expires_at, dedup_key)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (short_id) DO NOTHING
+		RETURNING short_id
+`
+
+	var id string
+	err := q.QueryRowContext(ctx, insertSQL, rec.ID, uid, rec.OriginalURL, rec.ExpiresAt, s.dedupKey(uid, rec.OriginalURL)).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("%w: %q", store.ErrAliasTaken, rec.ID)
+	}
 	if err != nil {
-		return 0, fmt.Errorf("invalid id %q: %w", id, store.ErrBadInput)
+		return "", fmt.Errorf("write alias query: %w", err)
+	}
+
+	return id, nil
+}
+
+// releaseExpired marks expired rows with the urls of the user dedup scope deleted, so the urls could be shortened again
+func (s *Store) releaseExpired(ctx context.Context, q queryer, uid string, urls ...string) error {
+	const releaseSQL = `
+		UPDATE urls SET deleted_at = NOW()
+		WHERE dedup_key = ANY($1) AND deleted_at IS NULL AND expires_at <= NOW()
+`
+
+	keys := make([]string, 0, len(urls))
+	for _, url := range urls {
+		if key, ok := s.dedup.Key(uid, url); ok {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	if _, err := q.ExecContext(ctx, releaseSQL, pg.Array(keys)); err != nil {
+		return fmt.Errorf("release expired query: %w", err)
+	}
+	return nil
+}
+
+// writeError converts unique url constraint violation into store.ConflictError
+func (s *Store) writeError(ctx context.Context, q queryer, err error, uid string, url string) error {
+	const conflictSQL = `
+		SELECT short_id FROM urls WHERE dedup_key = $1 AND deleted_at IS NULL
+`
+
+	var pgErr *pg.Error
+	if !errors.As(err, &pgErr) || !pgerrcode.IsIntegrityConstraintViolation(string(pgErr.Code)) {
+		return err
+	}
+
+	var id string
+	if qErr := q.QueryRowContext(ctx, conflictSQL, s.dedupKey(uid, url)).Scan(&id); qErr != nil {
+		return fmt.Errorf("query conflicting id: %w", qErr)
+	}
+
+	return &store.ConflictError{
+		ExistingURL: s.shortURL(id),
+	}
+}
+
+// nullTime converts nullable db time into pointer
+func nullTime(t pg.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
 	}
-	return rawID, nil
+	return &t.Time
 }
 
 // shortURL returns short url of the id
@@ -0,0 +1,51 @@
+package sqlstore
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"shortener/internal/app/service/store"
+	"testing"
+	"time"
+)
+
+func TestStore_ReadUserData(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() {
+		_ = db.Close()
+	}()
+
+	s, err := New(db, WithBaseURL("http://localhost"))
+	require.NoError(t, err)
+
+	from := time.Date(2022, 5, 1, 0, 0, 0, 0, time.UTC)
+	cursor := store.NewCursor(store.SortURLDesc, store.SortKey{URL: "https://example.org/c", RowID: 7}).Encode()
+	columns := []string{"id", "short_id", "original_url", "created_at", "expires_at"}
+
+	mock.ExpectQuery(`AND created_at >= \$2 AND strpos\(original_url, \$3\) > 0 AND \(original_url COLLATE "C", id\) < \(\$4, \$5\)\s+ORDER BY original_url COLLATE "C" DESC, id DESC\s+LIMIT \$6`).
+		WithArgs("user1", from, "example", "https://example.org/c", int64(7), 3).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(6, "b", "https://example.org/b", from, nil).
+			AddRow(5, "a2", "https://example.org/a", from, nil).
+			AddRow(2, "a1", "https://example.org/a", from, nil))
+
+	page, err := s.ReadUserData(context.Background(), "user1", store.UserDataQuery{
+		Limit:       2,
+		Cursor:      cursor,
+		CreatedFrom: &from,
+		URLContains: "example",
+		Sort:        store.SortURLDesc,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []store.Record{
+		{ID: "b", OriginalURL: "https://example.org/b", ShortURL: "http://localhost/b", CreatedAt: from},
+		{ID: "a2", OriginalURL: "https://example.org/a", ShortURL: "http://localhost/a2", CreatedAt: from},
+	}, page.Records)
+	assert.Equal(t, store.NewCursor(store.SortURLDesc, store.SortKey{URL: "https://example.org/a", RowID: 5}).Encode(), page.NextCursor)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	_, err = s.ReadUserData(context.Background(), "user1", store.UserDataQuery{Cursor: cursor})
+	assert.ErrorIs(t, err, store.ErrBadInput, "cursor of another sort must be rejected before the query")
+}
@@ -0,0 +1,903 @@
+/*
+Package storetest provides conformance test suite for the store.Store implementations.
+
+Every backend is expected to pass the suite:
+
+	func TestConformance(t *testing.T) {
+		storetest.Run(t, func(t *testing.T) store.Store {
+			return NewStore(WithBaseURL("http://localhost:8080"))
+		})
+	}
+*/
+package storetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path"
+	"shortener/internal/app/service/store"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Factory creates a new ready to use store instance for every test.
+// Use t.Cleanup to release store resources.
+type Factory func(t *testing.T) store.Store
+
+// removeTimeout limits waiting for the asynchronous removal
+const removeTimeout = 5 * time.Second
+
+// Run all the conformance tests against the store created by factory.
+func Run(t *testing.T, factory Factory) {
+	t.Run("WriteRead", func(t *testing.T) { testWriteRead(t, factory(t)) })
+	t.Run("WriteInvalid", func(t *testing.T) { testWriteInvalid(t, factory(t)) })
+	t.Run("ReadMissing", func(t *testing.T) { testReadMissing(t, factory(t)) })
+	t.Run("WriteConflict", func(t *testing.T) { testWriteConflict(t, factory(t)) })
+	t.Run("BatchWrite", func(t *testing.T) { testBatchWrite(t, factory(t)) })
+	t.Run("BatchWritePartial", func(t *testing.T) { testBatchWritePartial(t, factory(t)) })
+	t.Run("Import", func(t *testing.T) { testImport(t, factory(t)) })
+	t.Run("BatchRemove", func(t *testing.T) { testBatchRemove(t, factory(t)) })
+	t.Run("BatchRemoveOwnership", func(t *testing.T) { testBatchRemoveOwnership(t, factory(t)) })
+	t.Run("RewriteRemoved", func(t *testing.T) { testRewriteRemoved(t, factory(t)) })
+	t.Run("UserData", func(t *testing.T) { testUserData(t, factory(t)) })
+	t.Run("UserDataPages", func(t *testing.T) { testUserDataPages(t, factory(t)) })
+	t.Run("Stat", func(t *testing.T) { testStat(t, factory(t)) })
+	t.Run("WriteAlias", func(t *testing.T) { testWriteAlias(t, factory(t)) })
+	t.Run("BatchWriteAlias", func(t *testing.T) { testBatchWriteAlias(t, factory(t)) })
+	t.Run("Expiration", func(t *testing.T) { testExpiration(t, factory(t)) })
+	t.Run("Clicks", func(t *testing.T) { testClicks(t, factory(t)) })
+	t.Run("Operations", func(t *testing.T) { testOperations(t, factory(t)) })
+	t.Run("Restore", func(t *testing.T) { testRestore(t, factory(t)) })
+	t.Run("Edit", func(t *testing.T) { testEdit(t, factory(t)) })
+	t.Run("Quarantine", func(t *testing.T) { testQuarantine(t, factory(t)) })
+	t.Run("Moderation", func(t *testing.T) { testModeration(t, factory(t)) })
+	t.Run("CanceledContext", func(t *testing.T) { testCanceledContext(t, factory(t)) })
+}
+
+// RunDedupScope runs the deduplication tests against the store created by factory with the dedup scope.
+// Run covers the default global scope.
+func RunDedupScope(t *testing.T, scope store.DedupScope, factory Factory) {
+	t.Run("DedupScope", func(t *testing.T) { testDedupScope(t, scope, factory(t)) })
+}
+
+// NewUID generates unique user id acceptable by all the backends
+func NewUID() string {
+	return uuid.New().String()
+}
+
+// NewURL generates unique original url, so tests do not conflict with the existing data
+func NewURL() string {
+	return fmt.Sprintf("https://example.org/%s", uuid.New().String())
+}
+
+// idFromShortURL extracts short id from the short url
+func idFromShortURL(shortURL string) string {
+	return path.Base(shortURL)
+}
+
+func testWriteRead(t *testing.T, s store.Store) {
+	uid, u := NewUID(), NewURL()
+
+	shortURL, err := s.WriteURL(context.Background(), u, uid)
+	require.NoError(t, err)
+	require.NotEmpty(t, shortURL)
+
+	got, err := s.ReadURL(context.Background(), idFromShortURL(shortURL))
+	require.NoError(t, err)
+	assert.Equal(t, u, got)
+}
+
+func testWriteInvalid(t *testing.T, s store.Store) {
+	for _, u := range []string{"", "localhost", "/relative/path"} {
+		_, err := s.WriteURL(context.Background(), u, NewUID())
+		assert.ErrorIs(t, err, store.ErrBadInput, "url %q", u)
+	}
+}
+
+func testReadMissing(t *testing.T, s store.Store) {
+	_, err := s.ReadURL(context.Background(), "zzzzzzzzzz")
+	assert.ErrorIs(t, err, store.ErrNotFound)
+
+	_, err = s.ReadURL(context.Background(), "")
+	assert.ErrorIs(t, err, store.ErrBadInput)
+}
+
+func testWriteConflict(t *testing.T, s store.Store) {
+	u := NewURL()
+
+	shortURL, err := s.WriteURL(context.Background(), u, NewUID())
+	require.NoError(t, err)
+
+	_, err = s.WriteURL(context.Background(), u, NewUID())
+	var errConflict *store.ConflictError
+	require.True(t, errors.As(err, &errConflict), "expected conflict error, got %v", err)
+	assert.Equal(t, shortURL, errConflict.ExistingURL)
+}
+
+func testBatchWrite(t *testing.T, s store.Store) {
+	uid := NewUID()
+	in := []store.Record{
+		{CorrelationID: "first", OriginalURL: NewURL()},
+		{CorrelationID: "second", OriginalURL: NewURL()},
+		{CorrelationID: "third", OriginalURL: NewURL()},
+	}
+	urls := make([]string, len(in))
+	for i := range in {
+		urls[i] = in[i].OriginalURL
+	}
+
+	out, err := s.BatchWrite(context.Background(), uid, in)
+	require.NoError(t, err)
+	require.Len(t, out, len(urls))
+
+	for i, rec := range out {
+		assert.Equal(t, []string{"first", "second", "third"}[i], rec.CorrelationID)
+		assert.NotEmpty(t, rec.ID)
+		assert.Equal(t, rec.ID, idFromShortURL(rec.ShortURL))
+
+		got, err := s.ReadURL(context.Background(), rec.ID)
+		require.NoError(t, err)
+		assert.Equal(t, urls[i], got)
+	}
+}
+
+func testBatchWritePartial(t *testing.T, s store.Store) {
+	uid := NewUID()
+	existing, err := s.WriteURL(context.Background(), NewURL(), NewUID())
+	require.NoError(t, err)
+	existingURL, err := s.ReadURL(context.Background(), idFromShortURL(existing))
+	require.NoError(t, err)
+	alias := "taken-" + uuid.New().String()[:8]
+	_, err = s.WriteAlias(context.Background(), NewURL(), alias, NewUID())
+	require.NoError(t, err)
+
+	repeated := NewURL()
+	out, err := s.BatchWrite(context.Background(), uid, []store.Record{
+		{CorrelationID: "ok", OriginalURL: repeated},
+		{CorrelationID: "invalid", OriginalURL: "not a url"},
+		{CorrelationID: "conflict", OriginalURL: existingURL},
+		{CorrelationID: "alias", OriginalURL: NewURL(), ID: alias},
+		{CorrelationID: "repeated", OriginalURL: repeated},
+	})
+	require.NoError(t, err)
+	require.Len(t, out, 5)
+
+	assert.NoError(t, out[0].Err)
+	assert.ErrorIs(t, out[1].Err, store.ErrBadInput)
+	var errConflict *store.ConflictError
+	assert.ErrorAs(t, out[2].Err, &errConflict)
+	assert.Equal(t, existing, out[2].ShortURL, "conflict must return the existing short url")
+	assert.ErrorIs(t, out[3].Err, store.ErrAliasTaken)
+	assert.NoError(t, out[4].Err)
+	assert.Equal(t, out[0].ShortURL, out[4].ShortURL, "repeated url must be shortened once")
+
+	assert.Len(t, userData(t, s, uid), 1, "only valid records must be written")
+
+	_, err = s.BatchWrite(context.Background(), uid, []store.Record{
+		{OriginalURL: NewURL()},
+		{OriginalURL: existingURL},
+	}, store.WithAtomic())
+	assert.ErrorAs(t, err, &errConflict)
+	assert.Len(t, userData(t, s, uid), 1, "atomic batch must not write anything on failure")
+}
+
+func testImport(t *testing.T, s store.Store) {
+	uid := NewUID()
+	free, taken := "free-"+uuid.New().String()[:8], "taken-"+uuid.New().String()[:8]
+	_, err := s.WriteAlias(context.Background(), NewURL(), taken, NewUID())
+	require.NoError(t, err)
+
+	in := []store.Record{
+		{ID: free, OriginalURL: NewURL()},
+		{ID: taken, OriginalURL: NewURL()},
+		{OriginalURL: NewURL()},
+	}
+	urls := []string{in[0].OriginalURL, in[1].OriginalURL, in[2].OriginalURL}
+
+	out, err := store.Import(context.Background(), s, uid, in)
+	require.NoError(t, err)
+	require.Len(t, out, 3)
+	for i, rec := range out {
+		require.NoError(t, rec.Err)
+		got, err := s.ReadURL(context.Background(), rec.ID)
+		require.NoError(t, err)
+		assert.Equal(t, urls[i], got)
+	}
+	assert.Equal(t, free, out[0].ID, "free alias must be kept")
+	assert.NotEqual(t, taken, out[1].ID, "taken alias must be replaced")
+	assert.Len(t, userData(t, s, uid), 3)
+}
+
+func testBatchRemove(t *testing.T, s store.Store) {
+	uid := NewUID()
+	out, err := s.BatchWrite(context.Background(), uid, []store.Record{
+		{OriginalURL: NewURL()},
+		{OriginalURL: NewURL()},
+	})
+	require.NoError(t, err)
+
+	_, err = s.BatchRemove(context.Background(), uid, out[0].ID)
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		_, err := s.ReadURL(context.Background(), out[0].ID)
+		return errors.Is(err, store.ErrDeleted)
+	}, removeTimeout, 10*time.Millisecond, "removed url must become deleted")
+
+	_, err = s.ReadURL(context.Background(), out[1].ID)
+	assert.NoError(t, err)
+
+	rows := userData(t, s, uid)
+	require.Len(t, rows, 1)
+	assert.Equal(t, out[1].ID, rows[0].ID)
+}
+
+func testBatchRemoveOwnership(t *testing.T, s store.Store) {
+	owner, other := NewUID(), NewUID()
+	u := NewURL()
+
+	shortURL, err := s.WriteURL(context.Background(), u, owner)
+	require.NoError(t, err)
+	id := idFromShortURL(shortURL)
+
+	// removal by another user must be ignored, control row proves the removal was processed
+	control, err := s.WriteURL(context.Background(), NewURL(), other)
+	require.NoError(t, err)
+	_, err = s.BatchRemove(context.Background(), other, id, idFromShortURL(control))
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		_, err := s.ReadURL(context.Background(), idFromShortURL(control))
+		return errors.Is(err, store.ErrDeleted)
+	}, removeTimeout, 10*time.Millisecond, "control url must become deleted")
+
+	got, err := s.ReadURL(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, u, got)
+	assert.Len(t, userData(t, s, owner), 1)
+}
+
+func testRewriteRemoved(t *testing.T, s store.Store) {
+	uid, u := NewUID(), NewURL()
+
+	shortURL, err := s.WriteURL(context.Background(), u, uid)
+	require.NoError(t, err)
+	_, err = s.BatchRemove(context.Background(), uid, idFromShortURL(shortURL))
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		_, err := s.ReadURL(context.Background(), idFromShortURL(shortURL))
+		return errors.Is(err, store.ErrDeleted)
+	}, removeTimeout, 10*time.Millisecond, "removed url must become deleted")
+
+	newShortURL, err := s.WriteURL(context.Background(), u, uid)
+	require.NoError(t, err, "removed url must be available for shortening")
+	assert.NotEqual(t, shortURL, newShortURL)
+}
+
+func testUserData(t *testing.T, s store.Store) {
+	uid, other := NewUID(), NewUID()
+
+	assert.Empty(t, userData(t, s, uid))
+
+	urls := []string{NewURL(), NewURL()}
+	shortURLs := make(map[string]string)
+	for _, u := range urls {
+		shortURL, err := s.WriteURL(context.Background(), u, uid)
+		require.NoError(t, err)
+		shortURLs[shortURL] = u
+	}
+	_, err := s.WriteURL(context.Background(), NewURL(), other)
+	require.NoError(t, err)
+
+	rows := userData(t, s, uid)
+	require.Len(t, rows, len(urls))
+	for _, row := range rows {
+		assert.Equal(t, shortURLs[row.ShortURL], row.OriginalURL)
+		assert.Equal(t, idFromShortURL(row.ShortURL), row.ID)
+	}
+}
+
+func testUserDataPages(t *testing.T, s store.Store) {
+	uid := NewUID()
+
+	urls := make([]string, 5)
+	for i := range urls {
+		urls[i] = fmt.Sprintf("http://%d.example.org/%s", i, uuid.NewString())
+		_, err := s.WriteURL(context.Background(), urls[i], uid)
+		require.NoError(t, err)
+	}
+	_, err := s.WriteURL(context.Background(), NewURL(), uid)
+	require.NoError(t, err)
+
+	readAll := func(q store.UserDataQuery) []string {
+		var got []string
+		for i := 0; ; i++ {
+			require.Less(t, i, 10, "pagination must terminate")
+			page, err := s.ReadUserData(context.Background(), uid, q)
+			require.NoError(t, err)
+			require.LessOrEqual(t, len(page.Records), q.Limit)
+			for _, row := range page.Records {
+				got = append(got, row.OriginalURL)
+			}
+			if page.NextCursor == "" {
+				return got
+			}
+			q.Cursor = page.NextCursor
+		}
+	}
+
+	assert.Equal(t, urls, readAll(store.UserDataQuery{Limit: 2, URLContains: "http://"}))
+	assert.Equal(t, []string{urls[4], urls[3], urls[2], urls[1], urls[0]},
+		readAll(store.UserDataQuery{Limit: 2, URLContains: "http://", Sort: store.SortURLDesc}))
+	assert.Equal(t, []string{urls[3]}, readAll(store.UserDataQuery{Limit: 2, URLContains: "http://3."}))
+
+	page, err := s.ReadUserData(context.Background(), uid, store.UserDataQuery{Sort: store.SortCreatedDesc})
+	require.NoError(t, err)
+	require.Len(t, page.Records, len(urls)+1)
+	assert.Empty(t, page.NextCursor)
+	assert.Equal(t, urls[0], page.Records[len(urls)].OriginalURL)
+
+	created := page.Records[len(urls)].CreatedAt
+	page, err = s.ReadUserData(context.Background(), uid, store.UserDataQuery{CreatedTo: &created})
+	require.NoError(t, err)
+	assert.Empty(t, page.Records, "range end must be exclusive")
+	page, err = s.ReadUserData(context.Background(), uid, store.UserDataQuery{CreatedFrom: &created})
+	require.NoError(t, err)
+	assert.Len(t, page.Records, len(urls)+1, "range start must be inclusive")
+
+	first, err := s.ReadUserData(context.Background(), uid, store.UserDataQuery{Limit: 1})
+	require.NoError(t, err)
+	_, err = s.ReadUserData(context.Background(), uid, store.UserDataQuery{Cursor: first.NextCursor, Sort: store.SortURLAsc})
+	assert.ErrorIs(t, err, store.ErrBadInput, "cursor must not be reused with another sort")
+	_, err = s.ReadUserData(context.Background(), uid, store.UserDataQuery{Cursor: "garbage"})
+	assert.ErrorIs(t, err, store.ErrBadInput)
+	_, err = s.ReadUserData(context.Background(), uid, store.UserDataQuery{Limit: store.MaxPageLimit + 1})
+	assert.ErrorIs(t, err, store.ErrBadInput)
+}
+
+// userData reads all active urls of the user
+func userData(t *testing.T, s store.Store, uid string) []store.Record {
+	page, err := s.ReadUserData(context.Background(), uid, store.UserDataQuery{Limit: store.MaxPageLimit})
+	require.NoError(t, err)
+	return page.Records
+}
+
+func testDedupScope(t *testing.T, scope store.DedupScope, s store.Store) {
+	owner, other, u := NewUID(), NewUID(), NewURL()
+
+	shortURL, err := s.WriteURL(context.Background(), u, owner)
+	require.NoError(t, err)
+
+	// conflict is reported with the link of the same scope
+	assertConflict := func(t *testing.T, err error) {
+		var errConflict *store.ConflictError
+		require.True(t, errors.As(err, &errConflict), "expected conflict error, got %v", err)
+		assert.Equal(t, shortURL, errConflict.ExistingURL)
+	}
+
+	otherURL, err := s.WriteURL(context.Background(), u, other)
+	if scope == store.DedupGlobal {
+		assertConflict(t, err)
+	} else {
+		require.NoError(t, err, "other user must own the link to the same url")
+		assert.NotEqual(t, shortURL, otherURL)
+		records := userData(t, s, other)
+		require.Len(t, records, 1)
+		assert.Equal(t, otherURL, records[0].ShortURL)
+	}
+
+	repeatURL, err := s.WriteURL(context.Background(), u, owner)
+	if scope == store.DedupNone {
+		require.NoError(t, err)
+		assert.NotEqual(t, shortURL, repeatURL)
+	} else {
+		assertConflict(t, err)
+	}
+
+	got, err := s.BatchWrite(context.Background(), owner, []store.Record{{OriginalURL: u}, {OriginalURL: u}})
+	require.NoError(t, err)
+	if scope == store.DedupNone {
+		require.NoError(t, got[0].Err)
+		require.NoError(t, got[1].Err)
+		assert.NotEqual(t, got[0].ShortURL, got[1].ShortURL, "batch repeats must get own links")
+		assert.Len(t, userData(t, s, owner), 4)
+	} else {
+		assertConflict(t, got[0].Err)
+		assertConflict(t, got[1].Err)
+		assert.Len(t, userData(t, s, owner), 1)
+	}
+
+	// duplicates are removed, so the store could be started with the wider scope again
+	for _, uid := range []string{owner, other} {
+		var ids []string
+		for _, rec := range userData(t, s, uid) {
+			ids = append(ids, rec.ID)
+		}
+		if len(ids) == 0 {
+			continue
+		}
+		_, err := s.BatchRemove(context.Background(), uid, ids...)
+		require.NoError(t, err)
+		assert.Eventually(t, func() bool {
+			return len(userData(t, s, uid)) == 0
+		}, removeTimeout, 10*time.Millisecond, "duplicates must become deleted")
+	}
+}
+
+func testStat(t *testing.T, s store.Store) {
+	sp, ok := s.(store.StatProvider)
+	if !ok {
+		t.Skip("store does not implement store.StatProvider")
+	}
+
+	before, err := sp.Stat(context.Background())
+	require.NoError(t, err)
+
+	first, second := NewUID(), NewUID()
+	for _, uid := range []string{first, first, second} {
+		_, err := s.WriteURL(context.Background(), NewURL(), uid)
+		require.NoError(t, err)
+	}
+
+	after, err := sp.Stat(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, after.URLCount-before.URLCount)
+	assert.Equal(t, 2, after.UserCount-before.UserCount)
+}
+
+func testWriteAlias(t *testing.T, s store.Store) {
+	uid, u := NewUID(), NewURL()
+	alias := "Alias_" + uuid.New().String()[:8]
+
+	shortURL, err := s.WriteAlias(context.Background(), u, alias, uid)
+	require.NoError(t, err)
+	assert.Equal(t, alias, idFromShortURL(shortURL))
+
+	got, err := s.ReadURL(context.Background(), alias)
+	require.NoError(t, err)
+	assert.Equal(t, u, got)
+
+	_, err = s.WriteAlias(context.Background(), NewURL(), alias, NewUID())
+	assert.ErrorIs(t, err, store.ErrAliasTaken)
+
+	_, err = s.WriteAlias(context.Background(), u, "other-"+alias, uid)
+	var errConflict *store.ConflictError
+	require.True(t, errors.As(err, &errConflict), "expected conflict error, got %v", err)
+	assert.Equal(t, shortURL, errConflict.ExistingURL)
+
+	for _, bad := range []string{"api", "Ping", "with space", "slash/alias"} {
+		_, err = s.WriteAlias(context.Background(), NewURL(), bad, uid)
+		assert.ErrorIs(t, err, store.ErrBadInput, "alias %q", bad)
+	}
+}
+
+func testBatchWriteAlias(t *testing.T, s store.Store) {
+	uid := NewUID()
+	alias := "batch-" + uuid.New().String()[:8]
+
+	out, err := s.BatchWrite(context.Background(), uid, []store.Record{
+		{CorrelationID: "generated", OriginalURL: NewURL()},
+		{CorrelationID: "alias", OriginalURL: NewURL(), ID: alias},
+	})
+	require.NoError(t, err)
+	require.Len(t, out, 2)
+	assert.NotEqual(t, alias, out[0].ID)
+	assert.Equal(t, alias, out[1].ID)
+	assert.Equal(t, alias, idFromShortURL(out[1].ShortURL))
+
+	// whole atomic batch fails when alias is taken
+	u := NewURL()
+	_, err = s.BatchWrite(context.Background(), uid, []store.Record{
+		{OriginalURL: u},
+		{OriginalURL: NewURL(), ID: alias},
+	}, store.WithAtomic())
+	assert.ErrorIs(t, err, store.ErrAliasTaken)
+
+	_, err = s.WriteURL(context.Background(), u, uid)
+	assert.NoError(t, err, "failed batch must not store anything")
+}
+
+func testExpiration(t *testing.T, s store.Store) {
+	const ttl = 200 * time.Millisecond
+	uid, u := NewUID(), NewURL()
+
+	past := time.Now().Add(-time.Minute)
+	_, err := s.WriteURL(context.Background(), u, uid, store.WithExpiresAt(&past))
+	assert.ErrorIs(t, err, store.ErrBadInput)
+	out, err := s.BatchWrite(context.Background(), uid, []store.Record{{OriginalURL: u, ExpiresAt: &past}})
+	require.NoError(t, err)
+	assert.ErrorIs(t, out[0].Err, store.ErrBadInput)
+	_, err = s.BatchWrite(context.Background(), uid, []store.Record{{OriginalURL: u, ExpiresAt: &past}}, store.WithAtomic())
+	assert.ErrorIs(t, err, store.ErrBadInput)
+
+	expiresAt := time.Now().Add(ttl)
+	shortURL, err := s.WriteURL(context.Background(), u, uid, store.WithExpiresAt(&expiresAt))
+	require.NoError(t, err)
+	id := idFromShortURL(shortURL)
+
+	got, err := s.ReadURL(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, u, got)
+
+	rows := userData(t, s, uid)
+	require.Len(t, rows, 1)
+	require.NotNil(t, rows[0].ExpiresAt)
+	assert.WithinDuration(t, expiresAt, *rows[0].ExpiresAt, time.Millisecond)
+
+	assert.Eventually(t, func() bool {
+		_, err := s.ReadURL(context.Background(), id)
+		return errors.Is(err, store.ErrExpired)
+	}, removeTimeout, 10*time.Millisecond, "url must expire")
+	assert.Empty(t, userData(t, s, uid))
+
+	newShortURL, err := s.WriteURL(context.Background(), u, uid)
+	require.NoError(t, err, "expired url must be available for shortening")
+	assert.NotEqual(t, shortURL, newShortURL)
+}
+
+func testClicks(t *testing.T, s store.Store) {
+	recorder, ok := s.(store.ClickRecorder)
+	if !ok {
+		t.Skip("store does not implement store.ClickRecorder")
+	}
+	stats, ok := s.(store.LinkStatReader)
+	if !ok {
+		t.Skip("store does not implement store.LinkStatReader")
+	}
+
+	uid := NewUID()
+	shortURL, err := s.WriteURL(context.Background(), NewURL(), uid)
+	require.NoError(t, err)
+	id := idFromShortURL(shortURL)
+
+	now := time.Now()
+	require.NoError(t, recorder.RecordClicks(context.Background(),
+		store.ClickEvent{ID: id, Time: now, IP: "10.0.0.1", UserAgent: "test", RequestID: "r1"},
+		store.ClickEvent{ID: id, Time: now, IP: "10.0.0.2", Referer: "https://referer.test"},
+		store.ClickEvent{ID: id, Time: now.AddDate(0, 0, -1), IP: "10.0.0.1"},
+		store.ClickEvent{ID: "zzzzzzzzzz", Time: now, IP: "10.0.0.3"},
+	))
+
+	stat, err := stats.ReadLinkStat(context.Background(), uid, id, 7)
+	require.NoError(t, err)
+	assert.Equal(t, id, stat.ID)
+	assert.Equal(t, shortURL, stat.ShortURL)
+	assert.Equal(t, 3, stat.TotalClicks)
+	assert.Equal(t, 2, stat.UniqueVisitors)
+	require.Len(t, stat.Daily, 7)
+	assert.Equal(t, store.Day(now), stat.Daily[6].Date)
+	assert.Equal(t, 2, stat.Daily[6].Clicks)
+	assert.Equal(t, 1, stat.Daily[5].Clicks)
+
+	_, err = stats.ReadLinkStat(context.Background(), NewUID(), id, 7)
+	assert.ErrorIs(t, err, store.ErrNotFound, "stats are available to the owner only")
+}
+
+func testCanceledContext(t *testing.T, s store.Store) {
+	uid := NewUID()
+	shortURL, err := s.WriteURL(context.Background(), NewURL(), uid)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = s.WriteURL(ctx, NewURL(), uid)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	_, err = s.ReadURL(ctx, idFromShortURL(shortURL))
+	assert.ErrorIs(t, err, context.Canceled)
+
+	_, err = s.BatchWrite(ctx, uid, []store.Record{{OriginalURL: NewURL()}})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	_, err = s.BatchRemove(ctx, uid, idFromShortURL(shortURL))
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func testOperations(t *testing.T, s store.Store) {
+	ops, ok := s.(store.OperationReader)
+	if !ok {
+		t.Skip("store does not implement store.OperationReader")
+	}
+
+	uid, other := NewUID(), NewUID()
+	out, err := s.BatchWrite(context.Background(), uid, []store.Record{
+		{OriginalURL: NewURL()},
+		{OriginalURL: NewURL()},
+	})
+	require.NoError(t, err)
+	foreign, err := s.WriteURL(context.Background(), NewURL(), other)
+	require.NoError(t, err)
+
+	_, err = s.BatchRemove(context.Background(), uid)
+	assert.ErrorIs(t, err, store.ErrBadInput, "empty removal must be rejected")
+
+	first, err := s.BatchRemove(context.Background(), uid, out[0].ID)
+	require.NoError(t, err)
+	waitOperation(t, ops, uid, first)
+
+	opID, err := s.BatchRemove(context.Background(), uid, out[0].ID, out[1].ID, idFromShortURL(foreign), "bad/id")
+	require.NoError(t, err)
+	require.NotEmpty(t, opID)
+
+	op := waitOperation(t, ops, uid, opID)
+	assert.Equal(t, opID, op.ID)
+	assert.Equal(t, store.OperationCompleted, op.Status)
+	assert.NotNil(t, op.CompletedAt)
+	assert.Equal(t, []store.OperationItem{
+		{ID: out[0].ID, Outcome: store.OutcomeAlreadyDeleted},
+		{ID: out[1].ID, Outcome: store.OutcomeDeleted},
+		{ID: idFromShortURL(foreign), Outcome: store.OutcomeNotFound},
+		{ID: "bad/id", Outcome: store.OutcomeInvalid},
+	}, op.Items)
+
+	_, err = ops.ReadOperation(context.Background(), other, opID)
+	assert.ErrorIs(t, err, store.ErrNotFound, "operation of another user must not be visible")
+
+	_, err = ops.ReadOperation(context.Background(), uid, "missing")
+	assert.ErrorIs(t, err, store.ErrNotFound)
+}
+
+// waitOperation waits for the operation to leave pending state
+func waitOperation(t *testing.T, ops store.OperationReader, uid string, id string) *store.Operation {
+	var op *store.Operation
+	require.Eventually(t, func() bool {
+		var err error
+		op, err = ops.ReadOperation(context.Background(), uid, id)
+		require.NoError(t, err)
+		return op.Status != store.OperationPending
+	}, removeTimeout, 10*time.Millisecond, "operation must be completed")
+	return op
+}
+
+func testRestore(t *testing.T, s store.Store) {
+	restorer, ok := s.(store.Restorer)
+	if !ok {
+		t.Skip("store does not implement store.Restorer")
+	}
+
+	uid, other := NewUID(), NewUID()
+	out, err := s.BatchWrite(context.Background(), uid, []store.Record{
+		{OriginalURL: NewURL()},
+		{OriginalURL: NewURL()},
+		{OriginalURL: NewURL()},
+	})
+	require.NoError(t, err)
+	foreign, err := s.WriteURL(context.Background(), NewURL(), other)
+	require.NoError(t, err)
+
+	_, err = s.BatchRemove(context.Background(), uid, out[0].ID, out[1].ID)
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		_, err0 := s.ReadURL(context.Background(), out[0].ID)
+		_, err1 := s.ReadURL(context.Background(), out[1].ID)
+		return errors.Is(err0, store.ErrDeleted) && errors.Is(err1, store.ErrDeleted)
+	}, removeTimeout, 10*time.Millisecond, "removed urls must become deleted")
+
+	// url of the removed row is shortened again, so the row can not be restored
+	_, err = s.WriteURL(context.Background(), out[1].OriginalURL, uid)
+	require.NoError(t, err)
+
+	got, err := restorer.Restore(context.Background(), uid, out[0].ID, out[1].ID, out[2].ID, idFromShortURL(foreign), "bad/id")
+	require.NoError(t, err)
+	assert.Equal(t, []store.OperationItem{
+		{ID: out[0].ID, Outcome: store.OutcomeRestored},
+		{ID: out[1].ID, Outcome: store.OutcomeConflict},
+		{ID: out[2].ID, Outcome: store.OutcomeNotDeleted},
+		{ID: idFromShortURL(foreign), Outcome: store.OutcomeNotFound},
+		{ID: "bad/id", Outcome: store.OutcomeInvalid},
+	}, got)
+
+	u, err := s.ReadURL(context.Background(), out[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, out[0].OriginalURL, u)
+	assert.Len(t, userData(t, s, uid), 3)
+
+	// restored url is active again
+	_, err = s.WriteURL(context.Background(), out[0].OriginalURL, uid)
+	var errConflict *store.ConflictError
+	require.True(t, errors.As(err, &errConflict), "expected conflict error, got %v", err)
+	assert.Equal(t, out[0].ShortURL, errConflict.ExistingURL)
+}
+
+func testEdit(t *testing.T, s store.Store) {
+	editor, ok := s.(store.Editor)
+	if !ok {
+		t.Skip("store does not implement store.Editor")
+	}
+
+	uid, other := NewUID(), NewUID()
+	first, second, third := NewURL(), NewURL(), NewURL()
+	shortURL, err := s.WriteURL(context.Background(), first, uid)
+	require.NoError(t, err)
+	id := idFromShortURL(shortURL)
+	taken, err := s.WriteURL(context.Background(), second, uid)
+	require.NoError(t, err)
+
+	v, err := editor.UpdateURL(context.Background(), uid, id, third)
+	require.NoError(t, err)
+	assert.Equal(t, 2, v.Version)
+	assert.Equal(t, third, v.OriginalURL)
+
+	got, err := s.ReadURL(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, third, got)
+
+	same, err := editor.UpdateURL(context.Background(), uid, id, third)
+	require.NoError(t, err)
+	assert.Equal(t, 2, same.Version, "same destination must not create a version")
+
+	_, err = editor.UpdateURL(context.Background(), uid, id, second)
+	var errConflict *store.ConflictError
+	require.True(t, errors.As(err, &errConflict), "expected conflict error, got %v", err)
+	assert.Equal(t, taken, errConflict.ExistingURL)
+
+	_, err = editor.UpdateURL(context.Background(), uid, id, "not an url")
+	assert.ErrorIs(t, err, store.ErrBadInput)
+	_, err = editor.UpdateURL(context.Background(), other, id, NewURL())
+	assert.ErrorIs(t, err, store.ErrNotFound, "link of another user must not be editable")
+
+	v, err = editor.RollbackURL(context.Background(), uid, id, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 3, v.Version)
+	assert.Equal(t, first, v.OriginalURL)
+
+	got, err = s.ReadURL(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, first, got)
+
+	_, err = editor.RollbackURL(context.Background(), uid, id, 10)
+	assert.ErrorIs(t, err, store.ErrNotFound)
+
+	history, err := editor.ReadHistory(context.Background(), uid, id)
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	for i, want := range []string{first, third, first} {
+		assert.Equal(t, 3-i, history[i].Version)
+		assert.Equal(t, want, history[i].OriginalURL)
+	}
+
+	// previous destination is free to be shortened again
+	_, err = s.WriteURL(context.Background(), third, uid)
+	assert.NoError(t, err)
+
+	_, err = editor.ReadHistory(context.Background(), other, id)
+	assert.ErrorIs(t, err, store.ErrNotFound)
+
+	// the link does not conflict with itself when the new url has the same canonical form
+	variant := strings.Replace(first, "example.org", "EXAMPLE.org", 1)
+	v, err = editor.UpdateURL(context.Background(), uid, id, variant)
+	require.NoError(t, err)
+	assert.Equal(t, 4, v.Version)
+	_, err = s.WriteURL(context.Background(), first, uid)
+	require.True(t, errors.As(err, &errConflict), "edited link must keep the url, got %v", err)
+	assert.Equal(t, shortURL, errConflict.ExistingURL)
+}
+
+func testQuarantine(t *testing.T, s store.Store) {
+	q, ok := s.(store.Quarantiner)
+	if !ok {
+		t.Skip("store does not implement store.Quarantiner")
+	}
+
+	ctx := context.Background()
+	uid := NewUID()
+	url := NewURL()
+	shortURL, err := s.WriteURL(ctx, url, uid)
+	require.NoError(t, err)
+	id := idFromShortURL(shortURL)
+
+	n, err := q.Quarantine(ctx, store.QuarantineItem{ID: id, OriginalURL: NewURL(), Threat: "malware"})
+	require.NoError(t, err)
+	assert.Zero(t, n, "link with the other destination must be skipped")
+
+	n, err = q.Quarantine(ctx,
+		store.QuarantineItem{ID: id, OriginalURL: url, Threat: "malware"},
+		store.QuarantineItem{ID: "zzzzzzzzzz", OriginalURL: url, Threat: "malware"},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	_, err = s.ReadURL(ctx, id)
+	var errQuarantine *store.QuarantineError
+	require.True(t, errors.As(err, &errQuarantine), "expected quarantine error, got %v", err)
+	assert.Equal(t, url, errQuarantine.OriginalURL)
+	assert.Equal(t, "malware", errQuarantine.Threat)
+
+	n, err = q.Quarantine(ctx, store.QuarantineItem{ID: id, OriginalURL: url, Threat: "malware"})
+	require.NoError(t, err)
+	assert.Zero(t, n, "unchanged threat must not be counted")
+
+	n, err = q.Quarantine(ctx, store.QuarantineItem{ID: id, OriginalURL: url})
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	got, err := s.ReadURL(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, url, got)
+
+	editor, ok := s.(store.Editor)
+	if !ok {
+		return
+	}
+	_, err = q.Quarantine(ctx, store.QuarantineItem{ID: id, OriginalURL: url, Threat: "phishing"})
+	require.NoError(t, err)
+	next := NewURL()
+	_, err = editor.UpdateURL(ctx, uid, id, next)
+	require.NoError(t, err)
+	got, err = s.ReadURL(ctx, id)
+	require.NoError(t, err, "new destination must release the link")
+	assert.Equal(t, next, got)
+}
+
+func testModeration(t *testing.T, s store.Store) {
+	m, ok := s.(store.Moderator)
+	if !ok {
+		t.Skip("store does not implement store.Moderator")
+	}
+
+	ctx := context.Background()
+	uid := NewUID()
+	url := NewURL()
+	shortURL, err := s.WriteURL(ctx, url, uid)
+	require.NoError(t, err)
+	id := idFromShortURL(shortURL)
+
+	_, err = m.Report(ctx, store.AbuseReport{LinkID: "zzzzzzzzzz", Reason: store.ReasonSpam})
+	assert.ErrorIs(t, err, store.ErrNotFound)
+	_, err = m.Report(ctx, store.AbuseReport{LinkID: id, Reason: "boring"})
+	assert.ErrorIs(t, err, store.ErrBadInput)
+
+	first, err := m.Report(ctx, store.AbuseReport{LinkID: id, Reason: store.ReasonPhishing, Comment: "asks for a password", Reporter: "10.0.0.1"})
+	require.NoError(t, err)
+	assert.NotZero(t, first.ID)
+	assert.False(t, first.CreatedAt.IsZero())
+	second, err := m.Report(ctx, store.AbuseReport{LinkID: id, Reason: store.ReasonSpam})
+	require.NoError(t, err)
+
+	reports, err := m.ReadReports(ctx, store.ReportQuery{LinkID: id})
+	require.NoError(t, err)
+	require.Len(t, reports, 2)
+	assert.Equal(t, first.ID, reports[0].ID)
+	assert.Equal(t, store.ReasonPhishing, reports[0].Reason)
+	assert.Equal(t, "asks for a password", reports[0].Comment)
+	assert.Equal(t, "10.0.0.1", reports[0].Reporter)
+
+	reports, err = m.ReadReports(ctx, store.ReportQuery{LinkID: id, After: first.ID, Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	assert.Equal(t, second.ID, reports[0].ID)
+
+	assert.ErrorIs(t, m.SetDisabled(ctx, "zzzzzzzzzz", true), store.ErrNotFound)
+	require.NoError(t, m.SetDisabled(ctx, id, true))
+	_, err = s.ReadURL(ctx, id)
+	assert.ErrorIs(t, err, store.ErrDisabled)
+
+	reports, err = m.ReadReports(ctx, store.ReportQuery{LinkID: id})
+	require.NoError(t, err)
+	assert.Empty(t, reports, "reports of the disabled link must be resolved")
+
+	require.NoError(t, m.SetDisabled(ctx, id, false))
+	got, err := s.ReadURL(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, url, got)
+
+	require.NoError(t, m.SetBanned(ctx, uid, true))
+	require.NoError(t, m.SetBanned(ctx, uid, true), "ban must be idempotent")
+	_, err = s.ReadURL(ctx, id)
+	assert.ErrorIs(t, err, store.ErrDisabled)
+	later, err := s.WriteURL(ctx, NewURL(), uid)
+	require.NoError(t, err)
+	_, err = s.ReadURL(ctx, idFromShortURL(later))
+	assert.ErrorIs(t, err, store.ErrDisabled, "links written after the ban must be disabled")
+
+	require.NoError(t, m.SetBanned(ctx, uid, false))
+	got, err = s.ReadURL(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, url, got)
+}
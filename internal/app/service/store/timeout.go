@@ -0,0 +1,24 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// Timeouts of the store operations. Zero value means no timeout.
+type Timeouts struct {
+	// Read limits single record and user data reads, stats and health checks
+	Read time.Duration
+	// Write limits single record writes
+	Write time.Duration
+	// Batch limits batch writes and removals
+	Batch time.Duration
+}
+
+// WithTimeout returns context limited by the timeout, zero timeout means no limit
+func WithTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
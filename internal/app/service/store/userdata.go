@@ -0,0 +1,178 @@
+package store
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultPageLimit is a page size used when the query limit is not set
+	DefaultPageLimit = 100
+	// MaxPageLimit is the largest allowed page size
+	MaxPageLimit = 1000
+)
+
+// UserDataSort is an order of the user links listing
+type UserDataSort string
+
+const (
+	SortCreatedAsc  UserDataSort = "created_at"
+	SortCreatedDesc UserDataSort = "-created_at"
+	SortURLAsc      UserDataSort = "original_url"
+	SortURLDesc     UserDataSort = "-original_url"
+)
+
+// Desc reports if the order is descending
+func (s UserDataSort) Desc() bool {
+	return strings.HasPrefix(string(s), "-")
+}
+
+// ByURL reports if the links are ordered by the original url, otherwise by the creation time
+func (s UserDataSort) ByURL() bool {
+	return strings.TrimPrefix(string(s), "-") == string(SortURLAsc)
+}
+
+// UserDataQuery selects a page of the user links
+type UserDataQuery struct {
+	// Limit of the page size, DefaultPageLimit is used if zero
+	Limit int
+	// Cursor is the UserDataPage.NextCursor of the previous page, empty for the first page
+	Cursor string
+	// CreatedFrom and CreatedTo limit creation time of the links to the [from, to) range, nil means unlimited
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	// URLContains filters links by the substring of the original url
+	URLContains string
+	// Sort order, SortCreatedAsc is used if empty
+	Sort UserDataSort
+}
+
+// Normalize validates query and fills the defaults. Decoded cursor is returned, nil for the first page.
+func (q *UserDataQuery) Normalize() (*Cursor, error) {
+	switch {
+	case q.Limit == 0:
+		q.Limit = DefaultPageLimit
+	case q.Limit < 0 || q.Limit > MaxPageLimit:
+		return nil, fmt.Errorf("limit must be in range 1..%d: %w", MaxPageLimit, ErrBadInput)
+	}
+
+	switch q.Sort {
+	case "":
+		q.Sort = SortCreatedAsc
+	case SortCreatedAsc, SortCreatedDesc, SortURLAsc, SortURLDesc:
+	default:
+		return nil, fmt.Errorf("unknown sort %q: %w", q.Sort, ErrBadInput)
+	}
+
+	if q.CreatedFrom != nil && q.CreatedTo != nil && !q.CreatedFrom.Before(*q.CreatedTo) {
+		return nil, fmt.Errorf("empty creation time range: %w", ErrBadInput)
+	}
+
+	if q.Cursor == "" {
+		return nil, nil
+	}
+	c, err := DecodeCursor(q.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	if c.Sort != q.Sort {
+		return nil, fmt.Errorf("cursor of another sort order: %w", ErrBadInput)
+	}
+	return c, nil
+}
+
+// UserDataPage is a page of the user links
+type UserDataPage struct {
+	Records []Record
+	// NextCursor to read the next page, empty if this page is the last one
+	NextCursor string
+}
+
+// SortKey of the link, RowID is a store specific link id breaking the ties
+type SortKey struct {
+	CreatedAt time.Time `json:"c,omitempty"`
+	URL       string    `json:"u,omitempty"`
+	RowID     uint64    `json:"r"`
+}
+
+// Less reports if the link a goes before the link b in the order
+func (s UserDataSort) Less(a, b SortKey) bool {
+	var cmp int
+	if s.ByURL() {
+		cmp = strings.Compare(a.URL, b.URL)
+	} else {
+		cmp = compareTime(a.CreatedAt, b.CreatedAt)
+	}
+	if cmp == 0 {
+		cmp = compareUint64(a.RowID, b.RowID)
+	}
+	if s.Desc() {
+		return cmp > 0
+	}
+	return cmp < 0
+}
+
+// Cursor points at the last link of the page
+type Cursor struct {
+	Sort UserDataSort `json:"s"`
+	Key  SortKey      `json:"k"`
+}
+
+// NewCursor pointing at the link, only the key field used by the order is kept
+func NewCursor(sort UserDataSort, key SortKey) *Cursor {
+	if sort.ByURL() {
+		key.CreatedAt = time.Time{}
+	} else {
+		key.URL = ""
+	}
+	return &Cursor{Sort: sort, Key: key}
+}
+
+// After reports if the link goes after the cursor
+func (c *Cursor) After(key SortKey) bool {
+	return c.Sort.Less(c.Key, key)
+}
+
+// Encode cursor into the opaque string
+func (c *Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor returns cursor encoded by Cursor.Encode
+func DecodeCursor(s string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("malformed cursor: %w", ErrBadInput)
+	}
+	c := &Cursor{}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("malformed cursor: %w", ErrBadInput)
+	}
+	return c, nil
+}
+
+func compareUint64(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func compareTime(a, b time.Time) int {
+	switch {
+	case a.Before(b):
+		return -1
+	case a.After(b):
+		return 1
+	default:
+		return 0
+	}
+}
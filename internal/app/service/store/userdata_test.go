@@ -0,0 +1,69 @@
+package store
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestUserDataQuery_Normalize(t *testing.T) {
+	now := time.Now()
+	earlier := now.Add(-time.Hour)
+	cursor := NewCursor(SortCreatedDesc, SortKey{CreatedAt: now, URL: "dropped", RowID: 3})
+
+	tests := []struct {
+		name    string
+		q       UserDataQuery
+		want    UserDataQuery
+		cursor  *Cursor
+		wantErr bool
+	}{
+		{name: "defaults", want: UserDataQuery{Limit: DefaultPageLimit, Sort: SortCreatedAsc}},
+		{
+			name:   "cursor",
+			q:      UserDataQuery{Limit: 5, Cursor: cursor.Encode(), Sort: SortCreatedDesc},
+			want:   UserDataQuery{Limit: 5, Cursor: cursor.Encode(), Sort: SortCreatedDesc},
+			cursor: cursor,
+		},
+		{name: "negative limit", q: UserDataQuery{Limit: -1}, wantErr: true},
+		{name: "huge limit", q: UserDataQuery{Limit: MaxPageLimit + 1}, wantErr: true},
+		{name: "unknown sort", q: UserDataQuery{Sort: "short_url"}, wantErr: true},
+		{name: "empty range", q: UserDataQuery{CreatedFrom: &now, CreatedTo: &earlier}, wantErr: true},
+		{name: "malformed cursor", q: UserDataQuery{Cursor: "!!"}, wantErr: true},
+		{name: "cursor of another sort", q: UserDataQuery{Cursor: cursor.Encode()}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.q.Normalize()
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrBadInput)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, tt.q)
+			if tt.cursor == nil {
+				assert.Nil(t, got)
+				return
+			}
+			assert.Equal(t, tt.cursor.Sort, got.Sort)
+			assert.Empty(t, got.Key.URL, "only the sort field must be kept")
+			assert.True(t, tt.cursor.Key.CreatedAt.Equal(got.Key.CreatedAt))
+			assert.Equal(t, tt.cursor.Key.RowID, got.Key.RowID)
+		})
+	}
+}
+
+func TestUserDataSort_Less(t *testing.T) {
+	now := time.Now()
+	a := SortKey{CreatedAt: now, URL: "b", RowID: 1}
+	b := SortKey{CreatedAt: now.Add(time.Second), URL: "a", RowID: 2}
+	tie := SortKey{CreatedAt: now, URL: "b", RowID: 3}
+
+	assert.True(t, SortCreatedAsc.Less(a, b))
+	assert.True(t, SortCreatedDesc.Less(b, a))
+	assert.True(t, SortURLAsc.Less(b, a))
+	assert.True(t, SortURLDesc.Less(a, b))
+	assert.True(t, SortCreatedAsc.Less(a, tie), "ties must be ordered by row id")
+	assert.True(t, SortURLDesc.Less(tie, a), "ties must follow the direction")
+}
@@ -0,0 +1,249 @@
+/*
+Package threat looks up link destinations in the phishing and malware lists.
+*/
+package threat
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"shortener/internal/app/logger"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrAlreadyStarted = errors.New("already started")
+	ErrNotStarted     = errors.New("not started")
+)
+
+// Hash prefix length limits, 4 bytes is the shortest prefix of the Safe Browsing lists and 32 is the full hash
+const (
+	minPrefixLen = 4
+	maxPrefixLen = sha256.Size
+)
+
+// Checker looks up urls in the threat lists
+type Checker interface {
+	// Check returns the threat type the url is listed for, empty string if the url is not listed
+	Check(ctx context.Context, url string) (string, error)
+}
+
+// Blocklist is a local Checker backed by the file of SHA-256 hash prefixes of the url expressions,
+// the hashes are computed the same way as by Safe Browsing. Every line of the file holds threat type and
+// hex encoded hash prefix separated by space, blank lines and lines starting with # are skipped:
+//
+//	# threat prefix
+//	malware 4b1c2a3d
+//	phishing 9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08
+//
+// The file is reloaded periodically once the watcher is started, invalid file keeps the previous list.
+type Blocklist struct {
+	file           string
+	reloadInterval time.Duration
+	log            logger.Logger
+
+	mu sync.RWMutex
+	// prefixes map hash prefixes to the threat types
+	prefixes map[string]string
+	// lengths of the prefixes in the ascending order
+	lengths []int
+	modTime time.Time
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// Checker interface implementation
+var _ Checker = (*Blocklist)(nil)
+
+// BlocklistOption is a functional parameter of the Blocklist
+type BlocklistOption func(*Blocklist)
+
+// WithReloadInterval sets how often the started watcher checks the file for changes
+func WithReloadInterval(d time.Duration) BlocklistOption {
+	return func(b *Blocklist) {
+		b.reloadInterval = d
+	}
+}
+
+// NewBlocklist creates the blocklist and loads the file
+func NewBlocklist(file string, opts ...BlocklistOption) (*Blocklist, error) {
+	b := &Blocklist{
+		file: file,
+		log:  logger.Global().Component("Blocklist"),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	if err := b.Reload(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Check looks up hashes of all the url expressions
+func (b *Blocklist) Check(ctx context.Context, url string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, expr := range expressions(url) {
+		sum := sha256.Sum256([]byte(expr))
+		for _, n := range b.lengths {
+			if threat, ok := b.prefixes[string(sum[:n])]; ok {
+				return threat, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// Len returns number of the listed prefixes
+func (b *Blocklist) Len() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return len(b.prefixes)
+}
+
+// Reload reads the file, the active list is kept if the file is invalid
+func (b *Blocklist) Reload() error {
+	info, err := os.Stat(b.file)
+	if err != nil {
+		return fmt.Errorf("blocklist file: %w", err)
+	}
+	data, err := os.ReadFile(b.file)
+	if err != nil {
+		return fmt.Errorf("blocklist file: %w", err)
+	}
+	prefixes, lengths, err := parseBlocklist(data)
+	if err != nil {
+		return fmt.Errorf("blocklist file %s: %w", b.file, err)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.prefixes = prefixes
+	b.lengths = lengths
+	b.modTime = info.ModTime()
+	return nil
+}
+
+// Start watching the file, nothing is watched without the reload interval
+func (b *Blocklist) Start() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.stop != nil {
+		return ErrAlreadyStarted
+	}
+	b.stop = make(chan struct{})
+	b.done = make(chan struct{})
+	if b.reloadInterval <= 0 {
+		close(b.done)
+		return nil
+	}
+	go b.watch(b.stop, b.done)
+
+	return nil
+}
+
+// Stop watching the file
+func (b *Blocklist) Stop() error {
+	b.mu.Lock()
+	if b.stop == nil {
+		b.mu.Unlock()
+		return ErrNotStarted
+	}
+	close(b.stop)
+	done := b.done
+	b.stop, b.done = nil, nil
+	b.mu.Unlock()
+
+	<-done
+	return nil
+}
+
+// watch reloads the file once its modification time is changed
+func (b *Blocklist) watch(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(b.reloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(b.file)
+		if err != nil {
+			b.log.Warn().Err(err).Msg("Blocklist file check failure")
+			continue
+		}
+		b.mu.RLock()
+		changed := !info.ModTime().Equal(b.modTime)
+		b.mu.RUnlock()
+		if !changed {
+			continue
+		}
+
+		if err := b.Reload(); err != nil {
+			b.log.Error().Err(err).Msg("Blocklist reload failure, previous list is kept")
+			continue
+		}
+		b.log.Info().Int("prefixes", b.Len()).Msg("Blocklist reloaded")
+	}
+}
+
+// parseBlocklist decodes the file lines into the prefix map and the sorted prefix lengths
+func parseBlocklist(data []byte) (map[string]string, []int, error) {
+	prefixes := make(map[string]string)
+	seen := make(map[int]struct{})
+
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, nil, fmt.Errorf("line %d: expected threat type and hash prefix", line)
+		}
+		prefix, err := hex.DecodeString(fields[1])
+		if err != nil {
+			return nil, nil, fmt.Errorf("line %d: hash prefix: %w", line, err)
+		}
+		if len(prefix) < minPrefixLen || len(prefix) > maxPrefixLen {
+			return nil, nil, fmt.Errorf("line %d: hash prefix must be %d to %d bytes long", line, minPrefixLen, maxPrefixLen)
+		}
+		prefixes[string(prefix)] = fields[0]
+		seen[len(prefix)] = struct{}{}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, nil, fmt.Errorf("read: %w", err)
+	}
+
+	lengths := make([]int, 0, len(seen))
+	for n := range seen {
+		lengths = append(lengths, n)
+	}
+	sort.Ints(lengths)
+
+	return prefixes, lengths, nil
+}
@@ -0,0 +1,109 @@
+package threat
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// hashPrefix returns hex encoded hash prefix of the expression
+func hashPrefix(expr string, n int) string {
+	sum := sha256.Sum256([]byte(expr))
+	return hex.EncodeToString(sum[:n])
+}
+
+func writeBlocklist(t *testing.T, path string, lines ...string) {
+	t.Helper()
+	data := ""
+	for _, line := range lines {
+		data += line + "\n"
+	}
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+}
+
+func TestBlocklist_Check(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	writeBlocklist(t, path,
+		"# threat prefix",
+		"",
+		fmt.Sprintf("malware %s", hashPrefix("evil.example.org/", 4)),
+		fmt.Sprintf("phishing %s", hashPrefix("example.net/login/", 32)),
+		fmt.Sprintf("unwanted %s", hashPrefix("example.com/exact.html?a=1", 8)),
+	)
+
+	b, err := NewBlocklist(path)
+	require.NoError(t, err)
+	assert.Equal(t, 3, b.Len())
+
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://evil.example.org/", "malware"},
+		{"http://www.EVIL.example.org:8080/any/path?q=1", "malware"},
+		{"https://example.org/", ""},
+		{"https://example.net/login/form.html", "phishing"},
+		{"https://example.net/login", ""},
+		{"https://example.com/exact.html?a=1", "unwanted"},
+		{"https://example.com/exact.html?a=2", ""},
+		{"not an url", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			got, err := b.Check(context.Background(), tt.url)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestBlocklist_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+
+	_, err := NewBlocklist(path)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	writeBlocklist(t, path, "malware "+hashPrefix("example.org/", 4))
+	b, err := NewBlocklist(path)
+	require.NoError(t, err)
+
+	for _, invalid := range []string{"malware", "malware zz", "malware 0102", "malware " + hashPrefix("example.org/", 32) + "00"} {
+		writeBlocklist(t, path, invalid)
+		assert.Error(t, b.Reload(), invalid)
+	}
+	got, err := b.Check(context.Background(), "https://example.org/")
+	require.NoError(t, err)
+	assert.Equal(t, "malware", got, "invalid file keeps the list")
+
+	writeBlocklist(t, path)
+	require.NoError(t, b.Reload())
+	assert.Zero(t, b.Len())
+}
+
+func TestBlocklist_Watch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	writeBlocklist(t, path)
+
+	b, err := NewBlocklist(path, WithReloadInterval(10*time.Millisecond))
+	require.NoError(t, err)
+	require.NoError(t, b.Start())
+	assert.ErrorIs(t, b.Start(), ErrAlreadyStarted)
+
+	writeBlocklist(t, path, "phishing "+hashPrefix("example.org/", 4))
+	// modification time resolution of some file systems is coarse
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Second)))
+	assert.Eventually(t, func() bool {
+		got, _ := b.Check(context.Background(), "https://example.org/")
+		return got == "phishing"
+	}, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, b.Stop())
+	assert.ErrorIs(t, b.Stop(), ErrNotStarted)
+}
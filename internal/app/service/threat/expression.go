@@ -0,0 +1,137 @@
+package threat
+
+import (
+	"golang.org/x/net/idna"
+	"net"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// Limits of the url expressions, see https://developers.google.com/safe-browsing/v4/urls-hashing
+const (
+	// maxHostSuffixes is the number of the host suffixes tried besides the exact host
+	maxHostSuffixes = 4
+	// maxPathPrefixes is the number of the path prefixes tried besides the exact path
+	maxPathPrefixes = 4
+)
+
+// expressions returns host suffix and path prefix combinations of the canonical url, the exact url goes first.
+// Nil is returned for the urls which could not be parsed.
+func expressions(raw string) []string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" {
+		return nil
+	}
+
+	hosts := hostSuffixes(canonicalHost(u.Hostname()))
+	paths := pathPrefixes(canonicalPath(u.EscapedPath()), escape(unescape(u.RawQuery)))
+
+	res := make([]string, 0, len(hosts)*len(paths))
+	for _, h := range hosts {
+		for _, p := range paths {
+			res = append(res, h+p)
+		}
+	}
+	return res
+}
+
+// canonicalHost lower-cases the host, removes the leading, trailing and repeated dots and converts it to punycode
+func canonicalHost(host string) string {
+	labels := strings.FieldsFunc(strings.ToLower(unescape(host)), func(r rune) bool { return r == '.' })
+	host = strings.Join(labels, ".")
+	if ascii, err := idna.Lookup.ToASCII(host); err == nil {
+		host = ascii
+	}
+	return host
+}
+
+// canonicalPath resolves . and .. segments and repeated slashes keeping the trailing slash
+func canonicalPath(p string) string {
+	p = unescape(p)
+	if p == "" {
+		return "/"
+	}
+	clean := path.Clean("/" + p)
+	if strings.HasSuffix(p, "/") && clean != "/" {
+		clean += "/"
+	}
+	return escape(clean)
+}
+
+// hostSuffixes returns the exact host and up to maxHostSuffixes hosts formed by the last five labels
+// removing the leading labels one by one, the top-level domain alone is skipped. Ip address is used as is.
+func hostSuffixes(host string) []string {
+	res := []string{host}
+	if net.ParseIP(host) != nil {
+		return res
+	}
+	labels := strings.Split(host, ".")
+	start := len(labels) - maxHostSuffixes - 1
+	if start < 1 {
+		start = 1
+	}
+	for i := start; i < len(labels)-1; i++ {
+		res = append(res, strings.Join(labels[i:], "."))
+	}
+	return res
+}
+
+// pathPrefixes returns the exact path with and without query and up to maxPathPrefixes prefixes
+// formed by the root and the successive path segments
+func pathPrefixes(p string, query string) []string {
+	res := make([]string, 0, maxPathPrefixes+2)
+	add := func(s string) {
+		for _, r := range res {
+			if r == s {
+				return
+			}
+		}
+		res = append(res, s)
+	}
+
+	if query != "" {
+		add(p + "?" + query)
+	}
+	add(p)
+
+	prefix := "/"
+	add(prefix)
+	segments := strings.Split(p[1:], "/")
+	for i := 0; i < len(segments)-1 && i < maxPathPrefixes-1; i++ {
+		prefix += segments[i] + "/"
+		add(prefix)
+	}
+	return res
+}
+
+// unescape decodes percent escapes repeatedly until no escapes are left, invalid escapes are kept
+func unescape(s string) string {
+	for strings.Contains(s, "%") {
+		decoded, err := url.PathUnescape(s)
+		if err != nil || decoded == s {
+			break
+		}
+		s = decoded
+	}
+	return s
+}
+
+// escape encodes control, non-ascii, space, # and % characters
+func escape(s string) string {
+	const hex = "0123456789ABCDEF"
+
+	var sb strings.Builder
+	sb.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c <= 0x20 || c >= 0x7f || c == '#' || c == '%' {
+			sb.WriteByte('%')
+			sb.WriteByte(hex[c>>4])
+			sb.WriteByte(hex[c&0xf])
+			continue
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
@@ -0,0 +1,76 @@
+package threat
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestExpressions(t *testing.T) {
+	tests := []struct {
+		url  string
+		want []string
+	}{
+		{
+			"http://a.b.c/1/2.html?param=1",
+			[]string{
+				"a.b.c/1/2.html?param=1", "a.b.c/1/2.html", "a.b.c/", "a.b.c/1/",
+				"b.c/1/2.html?param=1", "b.c/1/2.html", "b.c/", "b.c/1/",
+			},
+		},
+		{
+			"http://a.b.c.d.e.f.g/1.html",
+			[]string{
+				"a.b.c.d.e.f.g/1.html", "a.b.c.d.e.f.g/",
+				"c.d.e.f.g/1.html", "c.d.e.f.g/",
+				"d.e.f.g/1.html", "d.e.f.g/",
+				"e.f.g/1.html", "e.f.g/",
+				"f.g/1.html", "f.g/",
+			},
+		},
+		{
+			"http://1.2.3.4/1/",
+			[]string{"1.2.3.4/1/", "1.2.3.4/"},
+		},
+		{
+			"https://example.org/a/b/c/d/e/f.html",
+			[]string{
+				"example.org/a/b/c/d/e/f.html", "example.org/", "example.org/a/", "example.org/a/b/", "example.org/a/b/c/",
+			},
+		},
+		{"not an url", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			assert.Equal(t, tt.want, expressions(tt.url))
+		})
+	}
+}
+
+func TestExpressions_Canonical(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"http://www.GOOgle.com/", "www.google.com/"},
+		{"http://www.google.com", "www.google.com/"},
+		{"http://www.google.com:8080/", "www.google.com/"},
+		{"http://...www.google.com.../", "www.google.com/"},
+		{"http://www.google.com/blah/..", "www.google.com/"},
+		{"http://www.google.com/foo/./bar/", "www.google.com/foo/bar/"},
+		{"http://host.com//twoslashes?more//slashes", "host.com/twoslashes?more//slashes"},
+		{"http://host/%25%32%35", "host/%25"},
+		{"http://host/%25%32%35%25%32%35", "host/%25%25"},
+		{"http://host/asdf%25%32%35asd", "host/asdf%25asd"},
+		{"http://www.google.com/q?r?s", "www.google.com/q?r?s"},
+		{"http://evil.com/foo#bar#baz", "evil.com/foo"},
+		{"http://пример.рф/путь", "xn--e1afmkfd.xn--p1ai/%D0%BF%D1%83%D1%82%D1%8C"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			got := expressions(tt.url)
+			if assert.NotEmpty(t, got) {
+				assert.Equal(t, tt.want, got[0])
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package threat
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"shortener/internal/app/logger"
+	"shortener/internal/app/service/store"
+	"shortener/pkg/workerpool"
+	"sync"
+	"time"
+)
+
+// Store of the scanned links
+type Store interface {
+	store.Dumper
+	store.Quarantiner
+}
+
+// ScanResult counts the links of one scan
+type ScanResult struct {
+	Checked     int
+	Quarantined int
+	Released    int
+	Failed      int
+}
+
+// Scanner re-checks destinations of the existing links periodically, the links are checked in batches by the
+// worker pool. Listed links are quarantined and the links which are not listed anymore are released.
+type Scanner struct {
+	store     Store
+	checker   Checker
+	log       logger.Logger
+	wp        *workerpool.Pool
+	workers   int
+	batchSize int
+	interval  time.Duration
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// ScannerOption is a functional parameter of the Scanner
+type ScannerOption func(*Scanner)
+
+// NewScanner constructor
+func NewScanner(s Store, c Checker, opts ...ScannerOption) *Scanner {
+	const (
+		defaultBatchSize = 500
+		defaultInterval  = time.Hour
+	)
+
+	sc := &Scanner{
+		store:     s,
+		checker:   c,
+		log:       logger.Global().Component("ThreatScanner"),
+		wp:        workerpool.New(),
+		workers:   runtime.GOMAXPROCS(0),
+		batchSize: defaultBatchSize,
+		interval:  defaultInterval,
+	}
+	for _, opt := range opts {
+		opt(sc)
+	}
+	return sc
+}
+
+// WithScanInterval sets the time between the scans, zero disables periodic scans
+func WithScanInterval(d time.Duration) ScannerOption {
+	return func(s *Scanner) {
+		s.interval = d
+	}
+}
+
+// WithScanBatchSize sets number of the links checked by one job
+func WithScanBatchSize(n int) ScannerOption {
+	return func(s *Scanner) {
+		s.batchSize = n
+	}
+}
+
+// WithScanWorkers sets number of the concurrent jobs
+func WithScanWorkers(n int) ScannerOption {
+	return func(s *Scanner) {
+		s.workers = n
+	}
+}
+
+// Start workers and periodic scans
+func (s *Scanner) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.done != nil {
+		return ErrAlreadyStarted
+	}
+	if s.batchSize <= 0 || s.workers <= 0 {
+		return fmt.Errorf("batch size and workers must be positive")
+	}
+
+	s.wp.Start(s.workers)
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done = make(chan struct{})
+	if s.interval <= 0 {
+		close(s.done)
+		return nil
+	}
+	go s.loop(ctx, s.done)
+
+	return nil
+}
+
+// Stop interrupts the running scan and stops workers
+func (s *Scanner) Stop() error {
+	s.mu.Lock()
+	if s.done == nil {
+		s.mu.Unlock()
+		return ErrNotStarted
+	}
+	s.cancel()
+	done := s.done
+	s.cancel, s.done = nil, nil
+	s.mu.Unlock()
+
+	<-done
+	s.wp.Stop()
+	return nil
+}
+
+// loop scans the links once per interval until ctx is canceled
+func (s *Scanner) loop(ctx context.Context, done chan<- struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		started := time.Now()
+		res, err := s.Scan(ctx)
+		if err != nil {
+			s.log.Error().Err(err).Msg("Threat scan failure")
+			continue
+		}
+		s.log.Info().
+			Int("checked", res.Checked).
+			Int("quarantined", res.Quarantined).
+			Int("released", res.Released).
+			Int("failed", res.Failed).
+			Dur("duration", time.Since(started)).
+			Msg("Threat scan finished")
+	}
+}
+
+// Scan checks all the working links, the scanner must be started. Links failed to be checked are counted and skipped.
+func (s *Scanner) Scan(ctx context.Context) (ScanResult, error) {
+	var (
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		res ScanResult
+	)
+
+	var after uint64
+	for {
+		rows, err := s.store.Dump(ctx, after, s.batchSize)
+		if err != nil {
+			wg.Wait()
+			return res, fmt.Errorf("dump after %d: %w", after, err)
+		}
+		if len(rows) == 0 {
+			break
+		}
+		after = rows[len(rows)-1].Key
+
+		batch := activeRows(rows, time.Now())
+		if len(batch) == 0 {
+			continue
+		}
+		wg.Add(1)
+		// blocks while all the workers are busy, so the store is not read ahead of the checks
+		s.wp.Run(func(context.Context) error {
+			defer wg.Done()
+
+			r, err := s.checkBatch(ctx, batch)
+			mu.Lock()
+			res.Checked += r.Checked
+			res.Quarantined += r.Quarantined
+			res.Released += r.Released
+			res.Failed += r.Failed
+			mu.Unlock()
+			return err
+		})
+	}
+	wg.Wait()
+
+	return res, ctx.Err()
+}
+
+// checkBatch checks the rows and updates threats of the rows with the changed verdict
+func (s *Scanner) checkBatch(ctx context.Context, rows []store.DumpRow) (ScanResult, error) {
+	var res ScanResult
+	var items []store.QuarantineItem
+	for _, r := range rows {
+		threat, err := s.checker.Check(ctx, r.OriginalURL)
+		if err != nil {
+			res.Failed++
+			continue
+		}
+		res.Checked++
+		if threat != r.Threat {
+			items = append(items, store.QuarantineItem{ID: r.ID, OriginalURL: r.OriginalURL, Threat: threat})
+		}
+	}
+	if len(items) == 0 {
+		return res, nil
+	}
+
+	if _, err := s.store.Quarantine(ctx, items...); err != nil {
+		res.Failed += len(items)
+		return res, fmt.Errorf("quarantine: %w", err)
+	}
+	// counts are approximate, links edited since the dump are skipped by the store
+	for _, item := range items {
+		if item.Threat != "" {
+			res.Quarantined++
+		} else {
+			res.Released++
+		}
+	}
+	return res, nil
+}
+
+// activeRows returns rows of the working links
+func activeRows(rows []store.DumpRow, now time.Time) []store.DumpRow {
+	res := make([]store.DumpRow, 0, len(rows))
+	for _, r := range rows {
+		if r.DeletedAt == nil && !store.Expired(r.ExpiresAt, now) {
+			res = append(res, r)
+		}
+	}
+	return res
+}
@@ -0,0 +1,93 @@
+package threat
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"shortener/internal/app/service/store"
+	"shortener/internal/app/service/store/memorystore"
+	"testing"
+	"time"
+)
+
+// checkerStub lists the urls of the map, urls mapped to the error fail
+type checkerStub map[string]interface{}
+
+func (c checkerStub) Check(_ context.Context, url string) (string, error) {
+	switch v := c[url].(type) {
+	case string:
+		return v, nil
+	case error:
+		return "", v
+	default:
+		return "", nil
+	}
+}
+
+func TestScanner_Scan(t *testing.T) {
+	ctx := context.Background()
+	s := memorystore.NewStore(memorystore.WithBaseURL("http://localhost:8080"))
+
+	write := func(url string) string {
+		shortURL, err := s.WriteURL(ctx, url, "uid")
+		require.NoError(t, err)
+		return shortURL[len("http://localhost:8080/"):]
+	}
+	evil := write("https://evil.example.org/")
+	released := write("https://example.org/released")
+	failed := write("https://example.org/failed")
+	for i := 0; i < 5; i++ {
+		write("https://example.org/" + string(rune('a'+i)))
+	}
+	_, err := s.Quarantine(ctx, store.QuarantineItem{ID: released, OriginalURL: "https://example.org/released", Threat: "malware"})
+	require.NoError(t, err)
+
+	checker := checkerStub{
+		"https://evil.example.org/":  "phishing",
+		"https://example.org/failed": errors.New("lookup failure"),
+	}
+	sc := NewScanner(s, checker, WithScanInterval(0), WithScanBatchSize(3), WithScanWorkers(2))
+	require.NoError(t, sc.Start())
+	defer func() {
+		require.NoError(t, sc.Stop())
+	}()
+
+	res, err := sc.Scan(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, ScanResult{Checked: 7, Quarantined: 1, Released: 1, Failed: 1}, res)
+
+	_, err = s.ReadURL(ctx, evil)
+	var errQuarantine *store.QuarantineError
+	require.True(t, errors.As(err, &errQuarantine), "expected quarantine error, got %v", err)
+	assert.Equal(t, "phishing", errQuarantine.Threat)
+	_, err = s.ReadURL(ctx, released)
+	assert.NoError(t, err)
+	_, err = s.ReadURL(ctx, failed)
+	assert.NoError(t, err)
+
+	res, err = sc.Scan(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, ScanResult{Checked: 7, Failed: 1}, res, "unchanged verdicts must not be updated")
+}
+
+func TestScanner_Periodic(t *testing.T) {
+	ctx := context.Background()
+	s := memorystore.NewStore(memorystore.WithBaseURL("http://localhost:8080"))
+	shortURL, err := s.WriteURL(ctx, "https://evil.example.org/", "uid")
+	require.NoError(t, err)
+	id := shortURL[len("http://localhost:8080/"):]
+
+	sc := NewScanner(s, checkerStub{"https://evil.example.org/": "malware"}, WithScanInterval(10*time.Millisecond))
+	require.NoError(t, sc.Start())
+	assert.ErrorIs(t, sc.Start(), ErrAlreadyStarted)
+
+	assert.Eventually(t, func() bool {
+		_, err := s.ReadURL(ctx, id)
+		var errQuarantine *store.QuarantineError
+		return errors.As(err, &errQuarantine)
+	}, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, sc.Stop())
+	assert.ErrorIs(t, sc.Stop(), ErrNotStarted)
+}
@@ -0,0 +1,84 @@
+package app
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"shortener/internal/app/config"
+	"shortener/internal/app/service/policy"
+	"shortener/internal/app/service/store"
+	"shortener/internal/app/service/store/memorystore"
+	"shortener/internal/app/service/store/sqlstore"
+	"shortener/internal/migrate"
+)
+
+var ErrUnknownStorage = errors.New("unknown storage type")
+
+// newStore creates storage backend selected by config, writes are checked by the destination policy
+func newStore(c *config.AppConfig, opts ...policy.StoreOption) (store.Backend, error) {
+	p, err := c.Policy()
+	if err != nil {
+		return nil, fmt.Errorf("policy: %w", err)
+	}
+	b, err := newBackend(c)
+	if err != nil {
+		return nil, err
+	}
+	return policy.NewStore(b, p, opts...), nil
+}
+
+// newBackend creates storage backend selected by config
+func newBackend(c *config.AppConfig) (store.Backend, error) {
+	idGen, err := c.IDGenerator()
+	if err != nil {
+		return nil, fmt.Errorf("id generator: %w", err)
+	}
+
+	switch c.Storage() {
+	case config.StoragePostgres:
+		db, err := sql.Open("postgres", c.DSN)
+		if err != nil {
+			return nil, fmt.Errorf("db open: %w", err)
+		}
+
+		if err = migrate.Up(db); err != nil {
+			return nil, fmt.Errorf("migrate up: %w", err)
+		}
+
+		return sqlstore.New(
+			db,
+			sqlstore.WithBaseURL(c.BaseURL),
+			sqlstore.WithTimeouts(c.StoreTimeouts()),
+			sqlstore.WithIDGenerator(idGen),
+			sqlstore.WithSweepInterval(c.ExpireSweepInterval),
+			sqlstore.WithExpiredGracePeriod(c.ExpireGracePeriod),
+			sqlstore.WithDeletedRetention(c.DeletedRetention),
+			sqlstore.WithDeletionPollInterval(c.DeletionPollInterval),
+			sqlstore.WithDeletionWindow(c.DeletionWindow),
+			sqlstore.WithDedupScope(store.DedupScope(c.DedupScope)),
+			sqlstore.WithCanonicalizer(c.Canonicalizer()),
+		)
+	case config.StorageFile:
+		return memorystore.NewStore(
+			memorystore.WithBaseURL(c.BaseURL),
+			memorystore.WithIDGenerator(idGen),
+			memorystore.WithFilePath(c.StorageFilePath),
+			memorystore.WithFlushInterval(c.StorageFlushInterval),
+			memorystore.WithExpiredGracePeriod(c.ExpireGracePeriod),
+			memorystore.WithDeletedRetention(c.DeletedRetention),
+			memorystore.WithDedupScope(store.DedupScope(c.DedupScope)),
+			memorystore.WithCanonicalizer(c.Canonicalizer()),
+		), nil
+	case config.StorageMemory:
+		return memorystore.NewStore(
+			memorystore.WithBaseURL(c.BaseURL),
+			memorystore.WithIDGenerator(idGen),
+			memorystore.WithExpiredGracePeriod(c.ExpireGracePeriod),
+			memorystore.WithDeletedRetention(c.DeletedRetention),
+			memorystore.WithDedupScope(store.DedupScope(c.DedupScope)),
+			memorystore.WithCanonicalizer(c.Canonicalizer()),
+		), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownStorage, c.Storage())
+	}
+}
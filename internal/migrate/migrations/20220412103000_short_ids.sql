@@ -0,0 +1,38 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION base36(n BIGINT) RETURNS TEXT AS
+$$
+DECLARE
+    alphabet CONSTANT TEXT := '0123456789abcdefghijklmnopqrstuvwxyz';
+    result            TEXT := '';
+BEGIN
+    IF n = 0 THEN
+        RETURN '0';
+    END IF;
+    WHILE n > 0
+        LOOP
+            result := substr(alphabet, (n % 36)::INT + 1, 1) || result;
+            n := n / 36;
+        END LOOP;
+    RETURN result;
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+ALTER TABLE urls
+    ADD COLUMN IF NOT EXISTS short_id TEXT;
+UPDATE urls
+SET short_id = base36(id)
+WHERE short_id IS NULL;
+ALTER TABLE urls
+    ALTER COLUMN short_id SET NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS urls_unique_short_id
+    ON urls (short_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS urls_unique_short_id;
+ALTER TABLE urls
+    DROP COLUMN IF EXISTS short_id;
+DROP FUNCTION IF EXISTS base36(BIGINT);
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS "clicks"
+(
+    id         BIGSERIAL primary key,
+    url_id     BIGINT      NOT NULL REFERENCES urls (id) ON DELETE CASCADE,
+    clicked_at TIMESTAMPTZ NOT NULL,
+    referer    TEXT        NOT NULL DEFAULT '',
+    user_agent TEXT        NOT NULL DEFAULT '',
+    ip         TEXT        NOT NULL DEFAULT '',
+    request_id TEXT        NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS clicks_url_id_clicked_at
+    ON clicks (url_id, clicked_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS "clicks";
+-- +goose StatementEnd
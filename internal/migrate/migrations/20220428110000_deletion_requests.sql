@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS "deletion_requests"
+(
+    id           BIGSERIAL primary key,
+    uid          UUID        NOT NULL,
+    short_ids    TEXT[]      NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    processed_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS deletion_requests_pending
+    ON deletion_requests (id)
+    WHERE processed_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS "deletion_requests";
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE deletion_requests
+    ADD COLUMN IF NOT EXISTS affected  INT,
+    ADD COLUMN IF NOT EXISTS not_owned TEXT[];
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE deletion_requests
+    DROP COLUMN IF EXISTS affected,
+    DROP COLUMN IF EXISTS not_owned;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE deletion_requests
+    ADD COLUMN IF NOT EXISTS unchanged  TEXT[],
+    ADD COLUMN IF NOT EXISTS invalid    TEXT[],
+    ADD COLUMN IF NOT EXISTS attempts   INT     NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS last_error TEXT,
+    ADD COLUMN IF NOT EXISTS failed     BOOLEAN NOT NULL DEFAULT FALSE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE deletion_requests
+    DROP COLUMN IF EXISTS unchanged,
+    DROP COLUMN IF EXISTS invalid,
+    DROP COLUMN IF EXISTS attempts,
+    DROP COLUMN IF EXISTS last_error,
+    DROP COLUMN IF EXISTS failed;
+-- +goose StatementEnd
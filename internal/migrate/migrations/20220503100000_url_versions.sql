@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE urls
+    ADD COLUMN IF NOT EXISTS version    INT NOT NULL DEFAULT 1,
+    ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ;
+CREATE TABLE IF NOT EXISTS "url_versions"
+(
+    url_id       BIGINT      NOT NULL REFERENCES urls (id) ON DELETE CASCADE,
+    version      INT         NOT NULL,
+    original_url TEXT        NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (url_id, version)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS "url_versions";
+ALTER TABLE urls
+    DROP COLUMN IF EXISTS version,
+    DROP COLUMN IF EXISTS updated_at;
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE urls
+    ADD COLUMN IF NOT EXISTS dedup_key TEXT;
+UPDATE urls
+SET dedup_key = original_url
+WHERE dedup_key IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS urls_unique_dedup_key
+    ON urls (dedup_key)
+    WHERE deleted_at IS NULL;
+DROP INDEX IF EXISTS urls_unique_original_url_null;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE UNIQUE INDEX IF NOT EXISTS urls_unique_original_url_null
+    ON urls (original_url)
+    WHERE deleted_at IS NULL;
+DROP INDEX IF EXISTS urls_unique_dedup_key;
+ALTER TABLE urls
+    DROP COLUMN IF EXISTS dedup_key;
+-- +goose StatementEnd
@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE urls
+    ADD COLUMN IF NOT EXISTS disabled_at TIMESTAMPTZ;
+CREATE TABLE IF NOT EXISTS "reports"
+(
+    id          BIGSERIAL primary key,
+    url_id      BIGINT      NOT NULL REFERENCES urls (id) ON DELETE CASCADE,
+    reason      TEXT        NOT NULL,
+    comment     TEXT        NOT NULL DEFAULT '',
+    reporter    TEXT        NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    resolved_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS reports_open
+    ON reports (id)
+    WHERE resolved_at IS NULL;
+CREATE INDEX IF NOT EXISTS reports_url_id
+    ON reports (url_id);
+CREATE TABLE IF NOT EXISTS "banned_users"
+(
+    uid        UUID primary key,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS "banned_users";
+DROP TABLE IF EXISTS "reports";
+ALTER TABLE urls
+    DROP COLUMN IF EXISTS disabled_at;
+-- +goose StatementEnd